@@ -0,0 +1,191 @@
+package alert
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/cryptoSelect/backendapi/models"
+	"github.com/cryptoSelect/public/database"
+	"gorm.io/gorm/clause"
+)
+
+// Pending 推迟或推送失败、等待重试的一条推送
+type Pending struct {
+	ID             uint
+	UserID         uint
+	SubscriptionID uint
+	Channel        string
+	Event          Event
+	Attempts       int // 失败次数，推迟不计
+	NextAttemptAt  time.Time
+	CreatedAt      time.Time
+}
+
+// DeliveryStore 推送记录，按 (user_id, channel, event_key) 去重；推迟或失败的推送按同一键排队重试
+type DeliveryStore interface {
+	// Delivered 事件是否已推送给该用户；查询失败时返回错误，调用方不能视为未推送
+	Delivered(userID uint, channel, eventKey string) (bool, error)
+	MarkDelivered(userID uint, channel string, ev Event) error
+	// Enqueue 排队等待重试，同一 (user_id, channel, event_key) 已在队列中时不重复添加
+	Enqueue(p Pending) error
+	// Due 下次尝试时间不晚于 now 的排队推送
+	Due(now time.Time, limit int) ([]Pending, error)
+	// Reschedule 更新失败次数与下次尝试时间
+	Reschedule(id uint, attempts int, next time.Time, lastErr string) error
+	// Dequeue 移出队列（已推送、放弃或不再适用）
+	Dequeue(id uint) error
+}
+
+// MemoryDeliveryStore 进程内存储，适合测试
+type MemoryDeliveryStore struct {
+	mu      sync.Mutex
+	sent    map[string]bool
+	pending []*Pending
+	nextID  uint
+}
+
+func NewMemoryDeliveryStore() *MemoryDeliveryStore {
+	return &MemoryDeliveryStore{sent: map[string]bool{}}
+}
+
+func (s *MemoryDeliveryStore) Delivered(userID uint, channel, eventKey string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sent[deliveryKey(userID, channel, eventKey)], nil
+}
+
+func (s *MemoryDeliveryStore) MarkDelivered(userID uint, channel string, ev Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sent[deliveryKey(userID, channel, ev.Key)] = true
+	return nil
+}
+
+func (s *MemoryDeliveryStore) Enqueue(p Pending) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, q := range s.pending {
+		if deliveryKey(q.UserID, q.Channel, q.Event.Key) == deliveryKey(p.UserID, p.Channel, p.Event.Key) {
+			return nil
+		}
+	}
+	s.nextID++
+	p.ID = s.nextID
+	s.pending = append(s.pending, &p)
+	return nil
+}
+
+func (s *MemoryDeliveryStore) Due(now time.Time, limit int) ([]Pending, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []Pending
+	for _, p := range s.pending {
+		if !p.NextAttemptAt.After(now) && len(out) < limit {
+			out = append(out, *p)
+		}
+	}
+	return out, nil
+}
+
+func (s *MemoryDeliveryStore) Reschedule(id uint, attempts int, next time.Time, lastErr string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, p := range s.pending {
+		if p.ID == id {
+			p.Attempts, p.NextAttemptAt = attempts, next
+		}
+	}
+	return nil
+}
+
+func (s *MemoryDeliveryStore) Dequeue(id uint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, p := range s.pending {
+		if p.ID == id {
+			s.pending = append(s.pending[:i], s.pending[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+func deliveryKey(userID uint, channel, eventKey string) string {
+	return fmt.Sprintf("%d|%s|%s", userID, channel, eventKey)
+}
+
+// DBDeliveryStore 基于 alert_delivery 表，重启后不会重复推送
+type DBDeliveryStore struct{}
+
+func (DBDeliveryStore) Delivered(userID uint, channel, eventKey string) (bool, error) {
+	var count int64
+	err := database.DB.Model(&models.AlertDelivery{}).
+		Where("user_id = ? AND channel = ? AND event_key = ?", userID, channel, eventKey).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (DBDeliveryStore) MarkDelivered(userID uint, channel string, ev Event) error {
+	row := models.AlertDelivery{
+		UserID:   userID,
+		Channel:  channel,
+		EventKey: ev.Key,
+		Symbol:   ev.Symbol,
+		Cycle:    ev.Cycle,
+	}
+	return database.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&row).Error
+}
+
+func (DBDeliveryStore) Enqueue(p Pending) error {
+	raw, err := json.Marshal(p.Event)
+	if err != nil {
+		return err
+	}
+	row := models.AlertPending{
+		UserID:         p.UserID,
+		SubscriptionID: p.SubscriptionID,
+		Channel:        p.Channel,
+		EventKey:       p.Event.Key,
+		Event:          string(raw),
+		Attempts:       p.Attempts,
+		NextAttemptAt:  p.NextAttemptAt,
+	}
+	return database.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&row).Error
+}
+
+func (DBDeliveryStore) Due(now time.Time, limit int) ([]Pending, error) {
+	var rows []models.AlertPending
+	if err := database.DB.Where("next_attempt_at <= ?", now).Order("next_attempt_at").Limit(limit).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	out := make([]Pending, 0, len(rows))
+	for _, row := range rows {
+		p := Pending{
+			ID:             row.ID,
+			UserID:         row.UserID,
+			SubscriptionID: row.SubscriptionID,
+			Channel:        row.Channel,
+			Attempts:       row.Attempts,
+			NextAttemptAt:  row.NextAttemptAt,
+			CreatedAt:      row.CreatedAt,
+		}
+		if json.Unmarshal([]byte(row.Event), &p.Event) != nil {
+			// 无法解析的事件不再重试
+			database.DB.Delete(&models.AlertPending{}, row.ID)
+			continue
+		}
+		out = append(out, p)
+	}
+	return out, nil
+}
+
+func (DBDeliveryStore) Reschedule(id uint, attempts int, next time.Time, lastErr string) error {
+	return database.DB.Model(&models.AlertPending{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"attempts": attempts, "next_attempt_at": next, "last_error": lastErr}).Error
+}
+
+func (DBDeliveryStore) Dequeue(id uint) error {
+	return database.DB.Delete(&models.AlertPending{}, id).Error
+}
@@ -0,0 +1,290 @@
+// Package alert 订阅告警推送：定时扫描被订阅的 symbol+cycle，检测信号变化并推送给订阅用户
+package alert
+
+import (
+	"errors"
+	"time"
+
+	"github.com/cryptoSelect/backendapi/config"
+	"github.com/cryptoSelect/backendapi/preference"
+	"github.com/cryptoSelect/backendapi/utils/logger"
+	"github.com/cryptoSelect/backendapi/utils/telegram"
+	publicModels "github.com/cryptoSelect/public/models"
+)
+
+// ErrSkip 通道不适用于该用户（如未绑定 Telegram），不视为失败
+var ErrSkip = errors.New("alert channel skipped")
+
+// ErrDeferred 暂不推送（如处于免打扰时段），不记录为已推送，排队稍后重试
+var ErrDeferred = errors.New("alert delivery deferred")
+
+// Channel 推送通道
+type Channel interface {
+	Name() string
+	Deliver(sub Subscriber, ev Event) error
+}
+
+// userPreferences 读取用户偏好，测试时可替换
+var userPreferences = preference.Get
+
+// 告警数据读写，测试时可替换
+var (
+	loadRecords     = subscribedRecords
+	loadSnapshotMap = loadSnapshots
+	storeSnapshot   = saveSnapshot
+	loadSubscribers = subscribers
+	loadSubscriber  = subscriber
+	loadRules       = subscriptionRules
+)
+
+// 排队推送的重试参数：推迟的推送每 deferRetry 检查一次，失败的按 retryBase * 2^(n-1) 退避，
+// 失败 maxAttempts 次或排队超过 maxPendingAge 后放弃
+const (
+	deferRetry    = 5 * time.Minute
+	retryBase     = time.Minute
+	maxAttempts   = 5
+	maxPendingAge = 24 * time.Hour
+	pendingBatch  = 100
+)
+
+// TelegramChannel 通过 Bot 向用户绑定的 telegram_id 推送
+type TelegramChannel struct{}
+
+func (TelegramChannel) Name() string { return "telegram" }
+
+// Deliver 用户关闭 Telegram 通知时跳过；处于免打扰时段时推迟到免打扰结束后推送
+func (TelegramChannel) Deliver(sub Subscriber, ev Event) error {
+	if sub.TelegramID == "" {
+		return ErrSkip
+	}
+	prefs := userPreferences(sub.UserID)
+	if !prefs.Channels.Telegram {
+		return ErrSkip
+	}
+	if prefs.InQuietHours(time.Now()) {
+		return ErrDeferred
+	}
+	return telegram.SendMessage(sub.TelegramID, FormatMessage(ev, prefs))
+}
+
+// Dispatcher 告警调度器
+type Dispatcher struct {
+	Thresholds Thresholds
+	Channels   []Channel
+	Store      DeliveryStore
+}
+
+// NewDispatcher 按配置创建调度器，未配置的阈值使用默认值 70/30
+func NewDispatcher(cfg config.AlertConfig, channels ...Channel) *Dispatcher {
+	th := Thresholds{RsiOverbought: cfg.RsiOverbought, RsiOversold: cfg.RsiOversold}
+	if th.RsiOverbought <= 0 {
+		th.RsiOverbought = 70
+	}
+	if th.RsiOversold <= 0 {
+		th.RsiOversold = 30
+	}
+	return &Dispatcher{Thresholds: th, Channels: channels, Store: DBDeliveryStore{}}
+}
+
+// Run 按配置间隔循环执行 Tick；Alert.Enabled 为 false 时直接返回
+func Run() {
+	cfg := config.Cfg.Alert
+	if !cfg.Enabled {
+		logger.Log.Info("alert dispatcher skip: Alert.Enabled is false", nil)
+		return
+	}
+	interval := time.Duration(cfg.IntervalSeconds) * time.Second
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	d := NewDispatcher(cfg, TelegramChannel{}, WebhookChannel{})
+	logger.Log.Info("alert dispatcher starting", map[string]interface{}{"interval": interval.String()})
+	for {
+		if err := d.Tick(); err != nil {
+			logger.Log.Error("alert dispatcher tick failed", map[string]interface{}{"error": err.Error()})
+		}
+		time.Sleep(interval)
+	}
+}
+
+// Tick 扫描一轮：首次见到的 symbol+cycle 只记录快照，之后每次记录更新都与快照比较并推送变化，再重试到期的排队推送。
+// 快照总是前移：推迟或失败的推送按用户与通道单独排队，不会因此对其他订阅用户重复推送
+func (d *Dispatcher) Tick() error {
+	records, err := loadRecords()
+	if err != nil {
+		return err
+	}
+	snapshots, err := loadSnapshotMap()
+	if err != nil {
+		return err
+	}
+	for i := range records {
+		cur := &records[i]
+		prev, ok := snapshots[snapshotKey(cur.Symbol, cur.Cycle)]
+		if ok && !cur.UpdatedAt.After(prev.UpdatedAt) {
+			continue
+		}
+		if ok && !d.dispatch(prev, cur) {
+			// 订阅用户或规则读取失败，尚未推送给任何人，保留旧快照下轮重新检测
+			continue
+		}
+		if err := storeSnapshot(cur); err != nil {
+			logger.Log.Error("alert save snapshot failed", map[string]interface{}{"symbol": cur.Symbol, "cycle": cur.Cycle, "error": err.Error()})
+		}
+	}
+	d.retryPending(time.Now())
+	return nil
+}
+
+// dispatch 检测 prev→cur 的变化并推送给全部订阅用户，订阅用户或规则读取失败时返回 false。
+// 订阅配置了规则时只推送规则事件，否则推送通用信号事件
+func (d *Dispatcher) dispatch(prev, cur *publicModels.SymbolRecord) bool {
+	subs, err := loadSubscribers(cur.Symbol, cur.Cycle)
+	if err != nil {
+		logger.Log.Error("alert load subscribers failed", map[string]interface{}{"symbol": cur.Symbol, "cycle": cur.Cycle, "error": err.Error()})
+		return false
+	}
+	rules, err := loadRules(subs)
+	if err != nil {
+		logger.Log.Error("alert load rules failed", map[string]interface{}{"symbol": cur.Symbol, "cycle": cur.Cycle, "error": err.Error()})
+		return false
+	}
+	d.notify(subs, rules, prev, cur, time.Now())
+	return true
+}
+
+// notify 检测 prev→cur 的变化并按各订阅的规则推送；推迟或失败的推送排队重试
+func (d *Dispatcher) notify(subs []Subscriber, rules map[uint][]storedRule, prev, cur *publicModels.SymbolRecord, now time.Time) {
+	generic := Detect(prev, cur, d.Thresholds)
+	for _, sub := range subs {
+		events := generic
+		if rs := rules[sub.SubscriptionID]; len(rs) > 0 {
+			events = DetectRules(prev, cur, rs)
+		}
+		for _, ev := range events {
+			for _, ch := range d.Channels {
+				d.deliver(ch, sub, ev, now)
+			}
+		}
+	}
+}
+
+// deliver 推送单个事件，已推送过的跳过；推迟或失败时按 (user, channel, event) 排队
+func (d *Dispatcher) deliver(ch Channel, sub Subscriber, ev Event, now time.Time) {
+	done, err := d.Store.Delivered(sub.UserID, ch.Name(), ev.Key)
+	if err != nil {
+		logger.Log.Error("alert load delivery failed", map[string]interface{}{"channel": ch.Name(), "user_id": sub.UserID, "event": ev.Key, "error": err.Error()})
+	}
+	if done {
+		return
+	}
+	p := Pending{UserID: sub.UserID, SubscriptionID: sub.SubscriptionID, Channel: ch.Name(), Event: ev, NextAttemptAt: now.Add(deferRetry), CreatedAt: now}
+	if err == nil {
+		err = d.send(ch, sub, ev)
+		if err == nil || errors.Is(err, ErrSkip) {
+			return
+		}
+		if !errors.Is(err, ErrDeferred) {
+			p.Attempts = 1
+			p.NextAttemptAt = now.Add(retryBase)
+		}
+	}
+	if err := d.Store.Enqueue(p); err != nil {
+		logger.Log.Error("alert enqueue failed", map[string]interface{}{"channel": ch.Name(), "user_id": sub.UserID, "event": ev.Key, "error": err.Error()})
+	}
+}
+
+// send 通过通道推送并记录为已推送；返回通道的错误（ErrSkip、ErrDeferred 或推送失败）
+func (d *Dispatcher) send(ch Channel, sub Subscriber, ev Event) error {
+	err := ch.Deliver(sub, ev)
+	if errors.Is(err, ErrSkip) {
+		return err
+	}
+	if errors.Is(err, ErrDeferred) {
+		logger.Log.Debug("alert deliver deferred", map[string]interface{}{"channel": ch.Name(), "user_id": sub.UserID, "event": ev.Key})
+		return err
+	}
+	if err != nil {
+		logger.Log.Error("alert deliver failed", map[string]interface{}{"channel": ch.Name(), "user_id": sub.UserID, "event": ev.Key, "error": err.Error()})
+		return err
+	}
+	if err := d.Store.MarkDelivered(sub.UserID, ch.Name(), ev); err != nil {
+		logger.Log.Error("alert mark delivered failed", map[string]interface{}{"channel": ch.Name(), "user_id": sub.UserID, "event": ev.Key, "error": err.Error()})
+	}
+	logger.Log.Info("alert delivered", map[string]interface{}{"channel": ch.Name(), "user_id": sub.UserID, "event": ev.Key})
+	return nil
+}
+
+// retryPending 重试到期的排队推送：已推送、订阅已删除、通道已移除或不再适用时出队；
+// 仍推迟时稍后再试，失败时退避，失败 maxAttempts 次或排队超过 maxPendingAge 后放弃
+func (d *Dispatcher) retryPending(now time.Time) {
+	due, err := d.Store.Due(now, pendingBatch)
+	if err != nil {
+		logger.Log.Error("alert load pending failed", map[string]interface{}{"error": err.Error()})
+		return
+	}
+	for _, p := range due {
+		fields := map[string]interface{}{"channel": p.Channel, "user_id": p.UserID, "event": p.Event.Key}
+		if now.Sub(p.CreatedAt) > maxPendingAge {
+			logger.Log.Warn("alert pending expired", fields)
+			d.dequeue(p)
+			continue
+		}
+		ch := d.channel(p.Channel)
+		if ch == nil {
+			d.dequeue(p)
+			continue
+		}
+		if done, err := d.Store.Delivered(p.UserID, p.Channel, p.Event.Key); err != nil || done {
+			if done {
+				d.dequeue(p)
+			}
+			continue
+		}
+		sub, err := loadSubscriber(p.SubscriptionID)
+		if err != nil {
+			fields["error"] = err.Error()
+			logger.Log.Error("alert load pending subscriber failed", fields)
+			continue
+		}
+		if sub == nil {
+			d.dequeue(p)
+			continue
+		}
+
+		err = d.send(ch, *sub, p.Event)
+		switch {
+		case err == nil || errors.Is(err, ErrSkip):
+			d.dequeue(p)
+		case errors.Is(err, ErrDeferred):
+			d.reschedule(p, p.Attempts, now.Add(deferRetry), "")
+		case p.Attempts+1 >= maxAttempts:
+			fields["attempts"] = p.Attempts + 1
+			logger.Log.Error("alert delivery abandoned", fields)
+			d.dequeue(p)
+		default:
+			d.reschedule(p, p.Attempts+1, now.Add(retryBase<<p.Attempts), err.Error())
+		}
+	}
+}
+
+func (d *Dispatcher) channel(name string) Channel {
+	for _, ch := range d.Channels {
+		if ch.Name() == name {
+			return ch
+		}
+	}
+	return nil
+}
+
+func (d *Dispatcher) dequeue(p Pending) {
+	if err := d.Store.Dequeue(p.ID); err != nil {
+		logger.Log.Error("alert dequeue failed", map[string]interface{}{"id": p.ID, "error": err.Error()})
+	}
+}
+
+func (d *Dispatcher) reschedule(p Pending, attempts int, next time.Time, lastErr string) {
+	if err := d.Store.Reschedule(p.ID, attempts, next, lastErr); err != nil {
+		logger.Log.Error("alert reschedule failed", map[string]interface{}{"id": p.ID, "error": err.Error()})
+	}
+}
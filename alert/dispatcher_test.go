@@ -0,0 +1,358 @@
+package alert
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cryptoSelect/backendapi/config"
+	"github.com/cryptoSelect/backendapi/preference"
+	"github.com/cryptoSelect/backendapi/rule"
+	"github.com/cryptoSelect/backendapi/utils/logger"
+	publicModels "github.com/cryptoSelect/public/models"
+)
+
+// fakeBotAPI 记录 sendMessage 请求的假 Telegram Bot API
+type fakeBotAPI struct {
+	mu     sync.Mutex
+	status int
+	sent   []map[string]string
+}
+
+func (f *fakeBotAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !strings.HasSuffix(r.URL.Path, "/sendMessage") {
+		http.NotFound(w, r)
+		return
+	}
+	if f.status != http.StatusOK {
+		w.WriteHeader(f.status)
+		return
+	}
+	var body map[string]string
+	json.NewDecoder(r.Body).Decode(&body)
+	f.sent = append(f.sent, body)
+	w.Write([]byte(`{"ok":true}`))
+}
+
+func (f *fakeBotAPI) messages() []map[string]string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]map[string]string(nil), f.sent...)
+}
+
+func (f *fakeBotAPI) setStatus(status int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.status = status
+}
+
+func setupDispatcher(t *testing.T, prefs func(uint) preference.Preferences) (*Dispatcher, *fakeBotAPI) {
+	t.Helper()
+	logger.Init("test")
+	api := &fakeBotAPI{status: http.StatusOK}
+	srv := httptest.NewServer(api)
+	t.Cleanup(srv.Close)
+
+	oldCfg, oldPrefs := config.Cfg, userPreferences
+	oldRecords, oldSnapshots, oldStore := loadRecords, loadSnapshotMap, storeSnapshot
+	oldSubs, oldSub, oldRules := loadSubscribers, loadSubscriber, loadRules
+	config.Cfg = &config.ServerConfig{TelegramBotToken: "test-token", TelegramAPIBase: srv.URL}
+	if prefs == nil {
+		prefs = func(uint) preference.Preferences { return preference.Default() }
+	}
+	userPreferences = prefs
+	t.Cleanup(func() {
+		config.Cfg, userPreferences = oldCfg, oldPrefs
+		loadRecords, loadSnapshotMap, storeSnapshot = oldRecords, oldSnapshots, oldStore
+		loadSubscribers, loadSubscriber, loadRules = oldSubs, oldSub, oldRules
+	})
+
+	d := NewDispatcher(config.AlertConfig{}, TelegramChannel{})
+	d.Store = NewMemoryDeliveryStore()
+	return d, api
+}
+
+func crossRecords() (prev, cur *publicModels.SymbolRecord) {
+	t0 := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	prev = &publicModels.SymbolRecord{Symbol: "BTCUSDT", Cycle: "1h", CrossType: 2, CrossTime: t0, Rsi: 50, UpdatedAt: t0}
+	cur = &publicModels.SymbolRecord{Symbol: "BTCUSDT", Cycle: "1h", CrossType: 1, CrossTime: t0.Add(time.Hour), Rsi: 50, UpdatedAt: t0.Add(time.Hour)}
+	return prev, cur
+}
+
+func TestNotifySendsAndDedups(t *testing.T) {
+	d, api := setupDispatcher(t, nil)
+	prev, cur := crossRecords()
+	subs := []Subscriber{
+		{UserID: 1, SubscriptionID: 10, TelegramID: "111"},
+		{UserID: 2, SubscriptionID: 20}, // 未绑定 Telegram，跳过
+	}
+
+	d.notify(subs, nil, prev, cur, time.Now())
+	msgs := api.messages()
+	if len(msgs) != 1 {
+		t.Fatalf("sent %d messages, want 1", len(msgs))
+	}
+	if msgs[0]["chat_id"] != "111" || !strings.Contains(msgs[0]["text"], "BTCUSDT 1h") {
+		t.Fatalf("unexpected message %v", msgs[0])
+	}
+
+	// 同一事件再次处理不会重复推送
+	d.notify(subs, nil, prev, cur, time.Now())
+	if n := len(api.messages()); n != 1 {
+		t.Fatalf("sent %d messages after retry, want 1", n)
+	}
+	if due, _ := d.Store.Due(time.Now().Add(time.Hour), 10); len(due) != 0 {
+		t.Fatalf("%d pending entries, want none", len(due))
+	}
+}
+
+func TestNotifyRetriesAfterFailure(t *testing.T) {
+	d, api := setupDispatcher(t, nil)
+	loadSubscriber = func(id uint) (*Subscriber, error) {
+		return &Subscriber{UserID: 1, SubscriptionID: id, TelegramID: "111"}, nil
+	}
+	prev, cur := crossRecords()
+	subs := []Subscriber{{UserID: 1, SubscriptionID: 10, TelegramID: "111"}}
+	now := time.Now()
+
+	api.setStatus(http.StatusInternalServerError)
+	d.notify(subs, nil, prev, cur, now)
+	if done, _ := d.Store.Delivered(1, "telegram", Detect(prev, cur, d.Thresholds)[0].Key); done {
+		t.Fatal("failed delivery must not be recorded")
+	}
+	due, _ := d.Store.Due(now.Add(retryBase), 10)
+	if len(due) != 1 || due[0].Attempts != 1 {
+		t.Fatalf("pending = %+v, want one entry after the first failure", due)
+	}
+
+	// 再次失败时退避翻倍
+	d.retryPending(now.Add(retryBase))
+	if due, _ := d.Store.Due(now.Add(2*retryBase), 10); len(due) != 0 {
+		t.Fatalf("retried before the backoff: %+v", due)
+	}
+	due, _ = d.Store.Due(now.Add(3*retryBase), 10)
+	if len(due) != 1 || due[0].Attempts != 2 {
+		t.Fatalf("pending = %+v, want attempts 2", due)
+	}
+
+	api.setStatus(http.StatusOK)
+	d.retryPending(now.Add(3 * retryBase))
+	d.retryPending(now.Add(time.Hour))
+	if n := len(api.messages()); n != 1 {
+		t.Fatalf("sent %d messages, want 1", n)
+	}
+	if due, _ := d.Store.Due(now.Add(time.Hour), 10); len(due) != 0 {
+		t.Fatalf("pending not cleared: %+v", due)
+	}
+}
+
+func TestRetryPendingGivesUp(t *testing.T) {
+	d, api := setupDispatcher(t, nil)
+	loadSubscriber = func(id uint) (*Subscriber, error) {
+		return &Subscriber{UserID: 1, SubscriptionID: id, TelegramID: "111"}, nil
+	}
+	prev, cur := crossRecords()
+	subs := []Subscriber{{UserID: 1, SubscriptionID: 10, TelegramID: "111"}}
+	now := time.Now()
+
+	api.setStatus(http.StatusInternalServerError)
+	d.notify(subs, nil, prev, cur, now)
+	for i := 1; i < maxAttempts; i++ {
+		now = now.Add(time.Hour)
+		d.retryPending(now)
+	}
+	if due, _ := d.Store.Due(now.Add(maxPendingAge), 10); len(due) != 0 {
+		t.Fatalf("pending kept after %d failures: %+v", maxAttempts, due)
+	}
+
+	// 排队过久的推送直接丢弃
+	api.setStatus(http.StatusOK)
+	d.Store.Enqueue(Pending{UserID: 1, SubscriptionID: 10, Channel: "telegram", Event: Detect(prev, cur, d.Thresholds)[0], NextAttemptAt: now, CreatedAt: now.Add(-maxPendingAge - time.Minute)})
+	d.retryPending(now)
+	if n := len(api.messages()); n != 0 {
+		t.Fatalf("sent %d expired messages", n)
+	}
+	if due, _ := d.Store.Due(now, 10); len(due) != 0 {
+		t.Fatalf("expired entry kept: %+v", due)
+	}
+}
+
+func TestNotifyRulesReplaceGenericEvents(t *testing.T) {
+	d, api := setupDispatcher(t, nil)
+	prev, cur := crossRecords()
+	cur.Rsi = 75
+	r, err := rule.Parse("rsi >= 70")
+	if err != nil {
+		t.Fatal(err)
+	}
+	subs := []Subscriber{{UserID: 1, SubscriptionID: 10, TelegramID: "111"}}
+	rules := map[uint][]storedRule{10: {{ID: 5, Rule: r}}}
+
+	d.notify(subs, rules, prev, cur, time.Now())
+	msgs := api.messages()
+	if len(msgs) != 1 {
+		t.Fatalf("sent %d messages, want only the rule event", len(msgs))
+	}
+	if !strings.Contains(msgs[0]["text"], "rsi >= 70") {
+		t.Fatalf("message does not mention the rule: %q", msgs[0]["text"])
+	}
+}
+
+func TestNotifyRespectsMutedChannel(t *testing.T) {
+	d, api := setupDispatcher(t, func(uint) preference.Preferences {
+		p := preference.Default()
+		p.Channels.Telegram = false
+		return p
+	})
+	prev, cur := crossRecords()
+	subs := []Subscriber{{UserID: 1, SubscriptionID: 10, TelegramID: "111"}}
+
+	d.notify(subs, nil, prev, cur, time.Now())
+	if n := len(api.messages()); n != 0 {
+		t.Fatalf("sent %d messages to a muted channel", n)
+	}
+	if due, _ := d.Store.Due(time.Now().Add(time.Hour), 10); len(due) != 0 {
+		t.Fatalf("muted channel queued %d entries", len(due))
+	}
+}
+
+// quietPrefs 返回按 quiet 决定是否处于免打扰时段的偏好
+func quietPrefs(quiet func(uint) bool) func(uint) preference.Preferences {
+	return func(userID uint) preference.Preferences {
+		p := preference.Default()
+		p.Timezone = "UTC"
+		if quiet(userID) {
+			now := time.Now().UTC()
+			p.QuietHours = preference.QuietHours{Start: now.Add(-time.Hour).Format("15:04"), End: now.Add(time.Hour).Format("15:04")}
+		}
+		return p
+	}
+}
+
+func TestNotifyDefersDuringQuietHours(t *testing.T) {
+	quiet := true
+	d, api := setupDispatcher(t, quietPrefs(func(uint) bool { return quiet }))
+	loadSubscriber = func(id uint) (*Subscriber, error) {
+		return &Subscriber{UserID: 1, SubscriptionID: id, TelegramID: "111"}, nil
+	}
+	prev, cur := crossRecords()
+	subs := []Subscriber{{UserID: 1, SubscriptionID: 10, TelegramID: "111"}}
+	now := time.Now()
+
+	// 免打扰时段内不推送，也不记录为已推送，排队稍后重试
+	d.notify(subs, nil, prev, cur, now)
+	if n := len(api.messages()); n != 0 {
+		t.Fatalf("sent %d messages during quiet hours", n)
+	}
+	if done, _ := d.Store.Delivered(1, "telegram", Detect(prev, cur, d.Thresholds)[0].Key); done {
+		t.Fatal("deferred delivery must not be recorded")
+	}
+	d.retryPending(now.Add(deferRetry))
+	if n := len(api.messages()); n != 0 {
+		t.Fatalf("sent %d messages while still quiet", n)
+	}
+
+	// 免打扰结束后补发一次
+	quiet = false
+	d.retryPending(now.Add(2 * deferRetry))
+	d.retryPending(now.Add(time.Hour))
+	if n := len(api.messages()); n != 1 {
+		t.Fatalf("sent %d messages after quiet hours, want 1", n)
+	}
+}
+
+// 两个订阅用户、其中一个处于免打扰，跨两次记录更新：快照照常前移，
+// 另一个用户不重复收到，免打扰用户结束后只补发一次
+func TestTickQueuesQuietSubscriber(t *testing.T) {
+	quietUser := true
+	d, api := setupDispatcher(t, quietPrefs(func(userID uint) bool { return userID == 2 && quietUser }))
+
+	subs := []Subscriber{
+		{UserID: 1, SubscriptionID: 10, TelegramID: "111"},
+		{UserID: 2, SubscriptionID: 20, TelegramID: "222"},
+	}
+	prev, r1 := crossRecords()
+	snapshots := map[string]*publicModels.SymbolRecord{snapshotKey(prev.Symbol, prev.Cycle): prev}
+	var records []publicModels.SymbolRecord
+
+	loadRecords = func() ([]publicModels.SymbolRecord, error) { return records, nil }
+	loadSnapshotMap = func() (map[string]*publicModels.SymbolRecord, error) {
+		out := map[string]*publicModels.SymbolRecord{}
+		for k, v := range snapshots {
+			rec := *v
+			out[k] = &rec
+		}
+		return out, nil
+	}
+	storeSnapshot = func(rec *publicModels.SymbolRecord) error {
+		cp := *rec
+		snapshots[snapshotKey(rec.Symbol, rec.Cycle)] = &cp
+		return nil
+	}
+	loadSubscribers = func(string, string) ([]Subscriber, error) { return subs, nil }
+	loadSubscriber = func(id uint) (*Subscriber, error) {
+		for i := range subs {
+			if subs[i].SubscriptionID == id {
+				return &subs[i], nil
+			}
+		}
+		return nil, nil
+	}
+	loadRules = func([]Subscriber) (map[uint][]storedRule, error) { return nil, nil }
+
+	count := func(chatID string) int {
+		n := 0
+		for _, m := range api.messages() {
+			if m["chat_id"] == chatID {
+				n++
+			}
+		}
+		return n
+	}
+
+	// 第一次更新：金叉，用户 1 收到，用户 2 排队，快照前移
+	records = []publicModels.SymbolRecord{*r1}
+	if err := d.Tick(); err != nil {
+		t.Fatal(err)
+	}
+	if count("111") != 1 || count("222") != 0 {
+		t.Fatalf("after r1: user1 %d user2 %d messages, want 1 and 0", count("111"), count("222"))
+	}
+	if got := snapshots[snapshotKey(r1.Symbol, r1.Cycle)]; !got.UpdatedAt.Equal(r1.UpdatedAt) {
+		t.Fatalf("snapshot not advanced: %v", got.UpdatedAt)
+	}
+
+	// 第二次更新：只有价格变化，不产生新事件，也不重复推送旧事件
+	r2 := *r1
+	r2.Price = r1.Price + 1
+	r2.UpdatedAt = r1.UpdatedAt.Add(time.Minute)
+	records = []publicModels.SymbolRecord{r2}
+	if err := d.Tick(); err != nil {
+		t.Fatal(err)
+	}
+	if count("111") != 1 || count("222") != 0 {
+		t.Fatalf("after r2: user1 %d user2 %d messages, want 1 and 0", count("111"), count("222"))
+	}
+	if got := snapshots[snapshotKey(r2.Symbol, r2.Cycle)]; !got.UpdatedAt.Equal(r2.UpdatedAt) {
+		t.Fatalf("snapshot not advanced to r2: %v", got.UpdatedAt)
+	}
+
+	// 免打扰结束：用户 2 补发一次 r1 的金叉，用户 1 不受影响
+	quietUser = false
+	d.retryPending(time.Now().Add(deferRetry))
+	d.retryPending(time.Now().Add(time.Hour))
+	if count("111") != 1 || count("222") != 1 {
+		t.Fatalf("after quiet hours: user1 %d user2 %d messages, want 1 and 1", count("111"), count("222"))
+	}
+	for _, m := range api.messages() {
+		if m["chat_id"] == "222" && !strings.Contains(m["text"], "BTCUSDT 1h") {
+			t.Fatalf("user2 got %q, want the r1 cross", m["text"])
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package alert
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/cryptoSelect/backendapi/rule"
+	publicModels "github.com/cryptoSelect/public/models"
+)
+
+// 事件类型
+const (
+	KindCross         = "cross"          // MACD 出现新的金叉/死叉
+	KindShape         = "shape"          // 缠论分型变化
+	KindVpSignal      = "vp_signal"      // 量价信号变化
+	KindSMCSignal     = "smc_signal"     // SMC 信号变化
+	KindRsiOverbought = "rsi_overbought" // RSI 上穿超买线
+	KindRsiOversold   = "rsi_oversold"   // RSI 下穿超卖线
+	KindRule          = "rule"           // 用户订阅规则由不满足变为满足
+)
+
+// Event 某个 symbol+cycle 的一次信号变化，Telegram 消息与其它推送通道共用同一份负载
+type Event struct {
+	Key    string                    `json:"key"` // 事件唯一键，用于去重
+	Kind   string                    `json:"kind"`
+	Symbol string                    `json:"symbol"`
+	Cycle  string                    `json:"cycle"`
+	From   string                    `json:"from"`
+	To     string                    `json:"to"`
+	Rule   string                    `json:"rule,omitempty"` // KindRule 时为触发的规则表达式
+	Record publicModels.SymbolRecord `json:"record"`
+}
+
+// Thresholds RSI 阈值
+type Thresholds struct {
+	RsiOverbought float64
+	RsiOversold   float64
+}
+
+// Detect 比较同一 symbol+cycle 的前后两条记录，返回有意义的信号变化
+func Detect(prev, cur *publicModels.SymbolRecord, th Thresholds) []Event {
+	if prev == nil || cur == nil {
+		return nil
+	}
+	var events []Event
+	add := func(kind, from, to string) {
+		events = append(events, newEvent(cur, kind, from, to))
+	}
+
+	// 新的交叉：类型变化，或同类型但交叉时间更新
+	if cur.CrossType != 0 && (cur.CrossType != prev.CrossType || !cur.CrossTime.Equal(prev.CrossTime)) {
+		add(KindCross, strconv.Itoa(prev.CrossType), strconv.Itoa(cur.CrossType))
+	}
+	if cur.Shape != 0 && cur.Shape != prev.Shape {
+		add(KindShape, strconv.Itoa(prev.Shape), strconv.Itoa(cur.Shape))
+	}
+	if cur.VpSignal != "" && cur.VpSignal != prev.VpSignal {
+		add(KindVpSignal, prev.VpSignal, cur.VpSignal)
+	}
+	if cur.SMCSignal != "" && cur.SMCSignal != prev.SMCSignal {
+		add(KindSMCSignal, prev.SMCSignal, cur.SMCSignal)
+	}
+	if th.RsiOverbought > 0 && prev.Rsi < th.RsiOverbought && cur.Rsi >= th.RsiOverbought {
+		add(KindRsiOverbought, formatFloat(prev.Rsi), formatFloat(cur.Rsi))
+	}
+	if th.RsiOversold > 0 && prev.Rsi > th.RsiOversold && cur.Rsi <= th.RsiOversold {
+		add(KindRsiOversold, formatFloat(prev.Rsi), formatFloat(cur.Rsi))
+	}
+	return events
+}
+
+// storedRule 订阅下已解析的规则
+type storedRule struct {
+	ID   uint
+	Rule *rule.Rule
+}
+
+// DetectRules 返回由不满足变为满足的规则事件
+func DetectRules(prev, cur *publicModels.SymbolRecord, rules []storedRule) []Event {
+	if prev == nil || cur == nil {
+		return nil
+	}
+	var events []Event
+	for _, r := range rules {
+		if !r.Rule.Triggered(prev, cur) {
+			continue
+		}
+		ev := newEvent(cur, KindRule, "false", "true")
+		ev.Key = fmt.Sprintf("%s:%s:%s:%d:%d", cur.Symbol, cur.Cycle, KindRule, r.ID, cur.UpdatedAt.Unix())
+		ev.Rule = r.Rule.String()
+		events = append(events, ev)
+	}
+	return events
+}
+
+func newEvent(rec *publicModels.SymbolRecord, kind, from, to string) Event {
+	return Event{
+		Key:    fmt.Sprintf("%s:%s:%s:%s>%s:%d", rec.Symbol, rec.Cycle, kind, from, to, rec.UpdatedAt.Unix()),
+		Kind:   kind,
+		Symbol: rec.Symbol,
+		Cycle:  rec.Cycle,
+		From:   from,
+		To:     to,
+		Record: *rec,
+	}
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', 2, 64)
+}
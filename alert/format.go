@@ -0,0 +1,62 @@
+package alert
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/cryptoSelect/backendapi/preference"
+	"github.com/cryptoSelect/backendapi/utils/i18n"
+)
+
+// FormatMessage 按用户语言与时区生成推送给用户的 Telegram 文本
+func FormatMessage(ev Event, prefs preference.Preferences) string {
+	lang := prefs.Language
+	var b strings.Builder
+	fmt.Fprintf(&b, "🔔 %s %s\n", ev.Symbol, ev.Cycle)
+	b.WriteString(describe(ev, lang))
+	b.WriteString("\n")
+	r := ev.Record
+	b.WriteString(i18n.T(lang, "alert.price_line", num(r.Price), num(r.Rsi), num(r.Change)))
+	if !r.UpdatedAt.IsZero() {
+		b.WriteString("\n")
+		b.WriteString(i18n.T(lang, "alert.time", prefs.FormatTime(r.UpdatedAt)))
+	}
+	return b.String()
+}
+
+func describe(ev Event, lang string) string {
+	switch ev.Kind {
+	case KindCross:
+		return i18n.T(lang, "alert.cross", label(lang, "alert.cross.", ev.To))
+	case KindShape:
+		return i18n.T(lang, "alert.shape", label(lang, "alert.shape.", ev.To))
+	case KindVpSignal:
+		return i18n.T(lang, "alert.vp_signal", ev.To)
+	case KindSMCSignal:
+		return i18n.T(lang, "alert.smc_signal", ev.To)
+	case KindRsiOverbought:
+		return i18n.T(lang, "alert.rsi_overbought", ev.From, ev.To)
+	case KindRsiOversold:
+		return i18n.T(lang, "alert.rsi_oversold", ev.From, ev.To)
+	case KindRule:
+		return i18n.T(lang, "alert.rule", ev.Rule)
+	}
+	return ev.Kind + ": " + ev.From + " → " + ev.To
+}
+
+// label 取信号值对应的文案，目录中没有时原样返回
+func label(lang, prefix, v string) string {
+	if l := i18n.T(lang, prefix+v); l != prefix+v {
+		return l
+	}
+	return v
+}
+
+func num(v float64) string {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return "-"
+	}
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
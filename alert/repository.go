@@ -0,0 +1,121 @@
+package alert
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/cryptoSelect/backendapi/models"
+	"github.com/cryptoSelect/backendapi/rule"
+	"github.com/cryptoSelect/public/database"
+	publicModels "github.com/cryptoSelect/public/models"
+	"gorm.io/gorm/clause"
+)
+
+// Subscriber 订阅了某个 symbol+cycle 的用户
+type Subscriber struct {
+	UserID         uint   `json:"user_id"`
+	SubscriptionID uint   `json:"subscription_id"`
+	TelegramID     string `json:"-"`
+}
+
+// subscribedRecords 查询所有被订阅的 symbol+cycle 的最新记录
+func subscribedRecords() ([]publicModels.SymbolRecord, error) {
+	var records []publicModels.SymbolRecord
+	err := database.DB.Model(&publicModels.SymbolRecord{}).
+		Joins("JOIN (SELECT DISTINCT symbol, cycle FROM subscription) s ON s.symbol = symbol_records.symbol AND s.cycle = symbol_records.cycle").
+		Find(&records).Error
+	return records, err
+}
+
+// subscribers 查询订阅了 symbol+cycle 的用户及其 telegram_id
+func subscribers(symbol, cycle string) ([]Subscriber, error) {
+	return querySubscribers("subscription.symbol = ? AND subscription.cycle = ?", symbol, cycle)
+}
+
+// subscriber 按订阅 ID 查询订阅用户，订阅已删除或账号已禁用时返回 nil
+func subscriber(subscriptionID uint) (*Subscriber, error) {
+	subs, err := querySubscribers("subscription.id = ?", subscriptionID)
+	if err != nil || len(subs) == 0 {
+		return nil, err
+	}
+	return &subs[0], nil
+}
+
+// querySubscribers 查询满足条件的订阅用户，跳过已禁用的账号
+func querySubscribers(cond string, args ...interface{}) ([]Subscriber, error) {
+	var subs []Subscriber
+	err := database.DB.Table("subscription").
+		Select("subscription.id AS subscription_id, subscription.user_id AS user_id, user_info.telegram_id AS telegram_id").
+		Joins("JOIN user_info ON user_info.id = subscription.user_id").
+		Joins("LEFT JOIN user_account ON user_account.user_id = subscription.user_id").
+		Where(cond, args...).
+		Where("user_account.disabled_at IS NULL").
+		Scan(&subs).Error
+	for i := range subs {
+		subs[i].TelegramID = strings.TrimSpace(subs[i].TelegramID)
+	}
+	return subs, err
+}
+
+// subscriptionRules 查询订阅下的规则，key 为 subscription_id；无法解析的规则跳过
+func subscriptionRules(subs []Subscriber) (map[uint][]storedRule, error) {
+	out := make(map[uint][]storedRule)
+	if len(subs) == 0 {
+		return out, nil
+	}
+	ids := make([]uint, 0, len(subs))
+	for _, s := range subs {
+		ids = append(ids, s.SubscriptionID)
+	}
+	var rows []models.SubscriptionRule
+	if err := database.DB.Where("subscription_id IN ?", ids).Order("id").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		r, err := rule.Parse(row.Expr)
+		if err != nil {
+			continue
+		}
+		out[row.SubscriptionID] = append(out[row.SubscriptionID], storedRule{ID: row.ID, Rule: r})
+	}
+	return out, nil
+}
+
+// loadSnapshots 读取全部快照，key 为 symbol|cycle
+func loadSnapshots() (map[string]*publicModels.SymbolRecord, error) {
+	var rows []models.AlertSnapshot
+	if err := database.DB.Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	out := make(map[string]*publicModels.SymbolRecord, len(rows))
+	for _, row := range rows {
+		var rec publicModels.SymbolRecord
+		if json.Unmarshal([]byte(row.Record), &rec) != nil {
+			continue
+		}
+		out[snapshotKey(row.Symbol, row.Cycle)] = &rec
+	}
+	return out, nil
+}
+
+// saveSnapshot 写入（或覆盖）symbol+cycle 的快照
+func saveSnapshot(rec *publicModels.SymbolRecord) error {
+	raw, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	row := models.AlertSnapshot{
+		Symbol:          rec.Symbol,
+		Cycle:           rec.Cycle,
+		Record:          string(raw),
+		RecordUpdatedAt: rec.UpdatedAt,
+	}
+	return database.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "symbol"}, {Name: "cycle"}},
+		DoUpdates: clause.AssignmentColumns([]string{"record", "record_updated_at", "updated_at"}),
+	}).Create(&row).Error
+}
+
+func snapshotKey(symbol, cycle string) string {
+	return symbol + "|" + cycle
+}
@@ -0,0 +1,63 @@
+package alert
+
+import (
+	"errors"
+	"time"
+
+	"github.com/cryptoSelect/backendapi/webhook"
+	publicModels "github.com/cryptoSelect/public/models"
+)
+
+// EventTypePrefix Webhook 事件类型前缀，完整类型如 signal.cross、signal.rule
+const EventTypePrefix = "signal."
+
+// KindTest 用户手动触发的测试事件
+const KindTest = "test"
+
+// WebhookChannel 将事件投递到用户已启用的全部 Webhook
+type WebhookChannel struct{}
+
+func (WebhookChannel) Name() string { return "webhook" }
+
+func (WebhookChannel) Deliver(sub Subscriber, ev Event) error {
+	if !userPreferences(sub.UserID).Channels.Webhook {
+		return ErrSkip
+	}
+	hooks, err := webhook.EnabledByUser(sub.UserID)
+	if err != nil {
+		return err
+	}
+	if len(hooks) == 0 {
+		return ErrSkip
+	}
+	opts := webhook.DefaultOptions()
+	var errs []error
+	for i := range hooks {
+		// 首次尝试失败时由 webhook 包排队重试，不阻塞本轮推送
+		if _, err := webhook.Deliver(&hooks[i], ev.Key, EventType(ev), ev, opts); err != nil && !errors.Is(err, webhook.ErrRetryScheduled) {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// EventType 返回事件在 Webhook 中的类型名
+func EventType(ev Event) string {
+	return EventTypePrefix + ev.Kind
+}
+
+// TestEvent 构造用于"发送测试事件"的示例事件，负载结构与真实事件一致
+func TestEvent(now time.Time) Event {
+	rec := publicModels.SymbolRecord{
+		Symbol:    "BTCUSDT",
+		Cycle:     "1h",
+		Price:     65000,
+		Rsi:       55,
+		CrossType: 1,
+		CrossTime: now,
+		UpdatedAt: now,
+	}
+	ev := newEvent(&rec, KindTest, "", "")
+	ev.Key = "test:" + now.Format("20060102150405.000000")
+	return ev
+}
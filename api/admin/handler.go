@@ -0,0 +1,218 @@
+package admin
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/cryptoSelect/backendapi/api/auth"
+	"github.com/cryptoSelect/backendapi/api/subscription"
+	"github.com/cryptoSelect/backendapi/config"
+	"github.com/cryptoSelect/backendapi/models"
+	"github.com/cryptoSelect/backendapi/response"
+	"github.com/gin-gonic/gin"
+)
+
+// 审计动作
+const (
+	actionDisable   = "user.disable"
+	actionEnable    = "user.enable"
+	actionSetRole   = "user.set_role"
+	actionUnbind    = "user.unbind_telegram"
+	actionDeleteSub = "subscription.delete"
+)
+
+type RoleRequest struct {
+	Role string `json:"role" binding:"required"`
+}
+
+// pagination 解析 page、page_size，page_size 默认取 config.Page.PageSize，最大 100
+func pagination(c *gin.Context) (int, int) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if page <= 0 {
+		page = 1
+	}
+	pageSize, _ := strconv.Atoi(c.Query("page_size"))
+	if pageSize <= 0 {
+		pageSize = config.Cfg.Page.PageSize
+	}
+	if pageSize <= 0 || pageSize > 100 {
+		pageSize = 100
+	}
+	return page, pageSize
+}
+
+// targetUser 解析路径中的用户 ID 并确认用户存在，失败时已写响应
+func targetUser(c *gin.Context) (*UserItem, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || id == 0 {
+		response.Fail(c, response.CodeInvalidID, nil)
+		return nil, false
+	}
+	user, err := getUser(uint(id))
+	if errors.Is(err, ErrUserNotFound) {
+		response.Fail(c, response.CodeUserNotFound, nil)
+		return nil, false
+	}
+	if err != nil {
+		response.ServerError(c, "admin get user failed", err, map[string]interface{}{"user_id": id})
+		return nil, false
+	}
+	return user, true
+}
+
+func actor(c *gin.Context) uint {
+	return c.MustGet(auth.ContextUserIDKey).(uint)
+}
+
+// ListUsers 用户列表，支持 q 搜索（邮箱模糊 / Telegram ID / 用户 ID）
+func ListUsers(c *gin.Context) {
+	page, pageSize := pagination(c)
+	items, total, err := listUsers(c.Query("q"), page, pageSize)
+	if err != nil {
+		response.ServerError(c, "admin list users failed", err, nil)
+		return
+	}
+	response.OK(c, gin.H{"data": items, "count": total})
+}
+
+// GetUser 用户详情，含订阅列表
+func GetUser(c *gin.Context) {
+	user, ok := targetUser(c)
+	if !ok {
+		return
+	}
+	subs, err := subscription.ListByUserID(user.ID)
+	if err != nil {
+		response.ServerError(c, "admin list user subscriptions failed", err, map[string]interface{}{"user_id": user.ID})
+		return
+	}
+	response.OK(c, gin.H{"user": user, "subscriptions": subs})
+}
+
+// DisableUser 停用账号并注销其全部会话
+func DisableUser(c *gin.Context) {
+	setDisabled(c, true)
+}
+
+// EnableUser 恢复已停用的账号
+func EnableUser(c *gin.Context) {
+	setDisabled(c, false)
+}
+
+func setDisabled(c *gin.Context, disabled bool) {
+	user, ok := targetUser(c)
+	if !ok {
+		return
+	}
+	if disabled && user.ID == actor(c) {
+		response.Fail(c, response.CodeCannotModifySelf, nil)
+		return
+	}
+	if err := auth.SetDisabled(user.ID, disabled); err != nil {
+		response.ServerError(c, "admin set disabled failed", err, map[string]interface{}{"user_id": user.ID})
+		return
+	}
+	action := actionEnable
+	if disabled {
+		action = actionDisable
+	}
+	writeAudit(actor(c), c.ClientIP(), action, "user", user.ID, gin.H{"email": user.Email})
+	response.OK(c, gin.H{"ok": true})
+}
+
+// SetUserRole 修改用户角色（user / admin），不能修改自己的角色
+func SetUserRole(c *gin.Context) {
+	user, ok := targetUser(c)
+	if !ok {
+		return
+	}
+	var req RoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil || (req.Role != models.RoleUser && req.Role != models.RoleAdmin) {
+		response.Fail(c, response.CodeInvalidRequest, gin.H{"field": "role", "message": "role must be user or admin"})
+		return
+	}
+	if user.ID == actor(c) {
+		response.Fail(c, response.CodeCannotModifySelf, nil)
+		return
+	}
+	if err := auth.SetRole(user.ID, req.Role); err != nil {
+		response.ServerError(c, "admin set role failed", err, map[string]interface{}{"user_id": user.ID})
+		return
+	}
+	writeAudit(actor(c), c.ClientIP(), actionSetRole, "user", user.ID, gin.H{"from": user.Role, "to": req.Role})
+	response.OK(c, gin.H{"ok": true})
+}
+
+// UnbindTelegram 强制解除用户的 Telegram 绑定
+func UnbindTelegram(c *gin.Context) {
+	user, ok := targetUser(c)
+	if !ok {
+		return
+	}
+	oldTelegramID, err := auth.ForceUnbindTelegram(user.ID)
+	if err != nil {
+		response.ServerError(c, "admin unbind telegram failed", err, map[string]interface{}{"user_id": user.ID})
+		return
+	}
+	if oldTelegramID == "" {
+		response.Fail(c, response.CodeTelegramNotBound, nil)
+		return
+	}
+	writeAudit(actor(c), c.ClientIP(), actionUnbind, "user", user.ID, gin.H{"telegram_id": oldTelegramID})
+	response.OK(c, gin.H{"ok": true})
+}
+
+// UserSubscriptions 查看用户的订阅
+func UserSubscriptions(c *gin.Context) {
+	user, ok := targetUser(c)
+	if !ok {
+		return
+	}
+	subs, err := subscription.ListByUserID(user.ID)
+	if err != nil {
+		response.ServerError(c, "admin list user subscriptions failed", err, map[string]interface{}{"user_id": user.ID})
+		return
+	}
+	response.OK(c, gin.H{"data": subs})
+}
+
+// DeleteSubscription 删除任意用户的订阅及其规则
+func DeleteSubscription(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || id == 0 {
+		response.Fail(c, response.CodeInvalidID, nil)
+		return
+	}
+	sub, err := subscription.DeleteByID(uint(id))
+	if errors.Is(err, subscription.ErrSubscriptionNotFound) {
+		response.Fail(c, response.CodeSubscriptionNotFound, nil)
+		return
+	}
+	if err != nil {
+		response.ServerError(c, "admin delete subscription failed", err, map[string]interface{}{"id": id})
+		return
+	}
+	writeAudit(actor(c), c.ClientIP(), actionDeleteSub, "subscription", sub.ID, gin.H{"user_id": sub.UserID, "symbol": sub.Symbol, "cycle": sub.Cycle})
+	response.OK(c, gin.H{"ok": true})
+}
+
+// GetStats 系统统计：用户数、订阅数、各币种订阅数、Telegram 绑定比例
+func GetStats(c *gin.Context) {
+	stats, err := loadStats()
+	if err != nil {
+		response.ServerError(c, "admin stats failed", err, nil)
+		return
+	}
+	response.OK(c, stats)
+}
+
+// ListAuditLogs 管理员操作审计日志
+func ListAuditLogs(c *gin.Context) {
+	page, pageSize := pagination(c)
+	logs, total, err := listAuditLogs(page, pageSize)
+	if err != nil {
+		response.ServerError(c, "admin list audit logs failed", err, nil)
+		return
+	}
+	response.OK(c, gin.H{"data": logs, "count": total})
+}
@@ -0,0 +1,127 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/cryptoSelect/backendapi/models"
+	"github.com/cryptoSelect/backendapi/utils/logger"
+	"github.com/cryptoSelect/public/database"
+	"gorm.io/gorm"
+)
+
+var ErrUserNotFound = errors.New("user not found")
+
+// UserItem 管理端用户列表项
+type UserItem struct {
+	ID              uint       `json:"id"`
+	Email           string     `json:"email"`
+	TelegramID      string     `json:"telegram_id"`
+	Role            string     `json:"role"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`
+	TelegramOnly    bool       `json:"telegram_only"`
+	DisabledAt      *time.Time `json:"disabled_at"`
+	DeletedAt       *time.Time `json:"deleted_at"` // 用户已注销，等待彻底删除
+	CreatedAt       time.Time  `json:"created_at"`
+}
+
+func usersQuery() *gorm.DB {
+	return database.DB.Table("user_info").
+		Select("user_info.id, user_info.email, user_info.telegram_id, COALESCE(user_account.role, ?) AS role, "+
+			"user_account.email_verified_at, COALESCE(user_account.telegram_only, false) AS telegram_only, "+
+			"user_account.disabled_at, user_account.deleted_at, user_info.created_at", models.RoleUser).
+		Joins("LEFT JOIN user_account ON user_account.user_id = user_info.id")
+}
+
+// listUsers 分页查询用户，q 按邮箱模糊匹配或按 Telegram ID / 用户 ID 精确匹配
+func listUsers(q string, page, pageSize int) ([]UserItem, int64, error) {
+	query := usersQuery()
+	if q = strings.TrimSpace(q); q != "" {
+		query = query.Where("user_info.email ILIKE ? OR user_info.telegram_id = ? OR CAST(user_info.id AS TEXT) = ?", "%"+q+"%", q, q)
+	}
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	items := []UserItem{}
+	err := query.Order("user_info.id DESC").Offset((page - 1) * pageSize).Limit(pageSize).Scan(&items).Error
+	return items, total, err
+}
+
+func getUser(userID uint) (*UserItem, error) {
+	var items []UserItem
+	if err := usersQuery().Where("user_info.id = ?", userID).Limit(1).Scan(&items).Error; err != nil {
+		return nil, err
+	}
+	if len(items) == 0 {
+		return nil, ErrUserNotFound
+	}
+	return &items[0], nil
+}
+
+// SymbolCount 每个币种的订阅数
+type SymbolCount struct {
+	Symbol string `json:"symbol"`
+	Count  int64  `json:"count"`
+}
+
+// Stats 系统统计
+type Stats struct {
+	UserCount          int64         `json:"user_count"`
+	DisabledCount      int64         `json:"disabled_count"`
+	TelegramBoundCount int64         `json:"telegram_bound_count"`
+	TelegramBoundRatio float64       `json:"telegram_bound_ratio"` // 已绑定 Telegram 用户占比，0~1
+	SubscriptionCount  int64         `json:"subscription_count"`
+	BySymbol           []SymbolCount `json:"by_symbol"` // 按订阅数倒序
+}
+
+func loadStats() (*Stats, error) {
+	var s Stats
+	if err := database.DB.Table("user_info").Count(&s.UserCount).Error; err != nil {
+		return nil, err
+	}
+	if err := database.DB.Model(&models.UserAccount{}).Where("disabled_at IS NOT NULL").Count(&s.DisabledCount).Error; err != nil {
+		return nil, err
+	}
+	if err := database.DB.Table("user_info").Where("telegram_id IS NOT NULL AND telegram_id <> ''").Count(&s.TelegramBoundCount).Error; err != nil {
+		return nil, err
+	}
+	if err := database.DB.Table("subscription").Count(&s.SubscriptionCount).Error; err != nil {
+		return nil, err
+	}
+	s.BySymbol = []SymbolCount{}
+	if err := database.DB.Table("subscription").Select("symbol, COUNT(*) AS count").
+		Group("symbol").Order("count DESC, symbol").Scan(&s.BySymbol).Error; err != nil {
+		return nil, err
+	}
+	if s.UserCount > 0 {
+		s.TelegramBoundRatio = float64(s.TelegramBoundCount) / float64(s.UserCount)
+	}
+	return &s, nil
+}
+
+// writeAudit 写入审计日志，失败只记录日志，不影响操作结果
+func writeAudit(actorID uint, ip, action, targetType string, targetID uint, detail interface{}) {
+	text := ""
+	if detail != nil {
+		if b, err := json.Marshal(detail); err == nil {
+			text = string(b)
+		}
+	}
+	entry := models.AdminAuditLog{ActorID: actorID, Action: action, TargetType: targetType, TargetID: targetID, Detail: text, IP: ip}
+	if err := database.DB.Create(&entry).Error; err != nil {
+		logger.Log.Error("admin audit write failed", map[string]interface{}{"actor_id": actorID, "action": action, "error": err.Error()})
+	}
+}
+
+func listAuditLogs(page, pageSize int) ([]models.AdminAuditLog, int64, error) {
+	var total int64
+	if err := database.DB.Model(&models.AdminAuditLog{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	logs := []models.AdminAuditLog{}
+	err := database.DB.Order("id DESC").Offset((page - 1) * pageSize).Limit(pageSize).Find(&logs).Error
+	return logs, total, err
+}
@@ -0,0 +1,23 @@
+package admin
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// SetupAdminRoutes 管理接口，整组需登录且为管理员；所有修改操作写入审计日志
+func SetupAdminRoutes(router *gin.RouterGroup, requireAuth, requireAdmin gin.HandlerFunc) {
+	router.Use(requireAuth, requireAdmin)
+
+	router.GET("/users", ListUsers)
+	router.GET("/users/:id", GetUser)
+	router.POST("/users/:id/disable", DisableUser)
+	router.POST("/users/:id/enable", EnableUser)
+	router.PUT("/users/:id/role", SetUserRole)
+	router.DELETE("/users/:id/telegram", UnbindTelegram)
+	router.GET("/users/:id/subscriptions", UserSubscriptions)
+
+	router.DELETE("/subscriptions/:id", DeleteSubscription)
+
+	router.GET("/stats", GetStats)
+	router.GET("/audit-logs", ListAuditLogs)
+}
@@ -0,0 +1,183 @@
+package auth
+
+import (
+	"errors"
+	"time"
+
+	"github.com/cryptoSelect/backendapi/models"
+	"github.com/cryptoSelect/backendapi/response"
+	"github.com/cryptoSelect/backendapi/utils/logger"
+	"github.com/cryptoSelect/public/database"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+var ErrTokenInvalid = errors.New("token invalid, used or expired")
+
+// ensureAccount 获取用户的账号扩展记录，不存在时创建（未验证邮箱）；仅在修改账号状态前调用
+func ensureAccount(userID uint) (*models.UserAccount, error) {
+	account := models.UserAccount{UserID: userID}
+	if err := database.DB.Where(models.UserAccount{UserID: userID}).FirstOrCreate(&account).Error; err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+// loadAccount 只读获取用户的账号扩展记录，不存在时返回默认值（未验证、普通用户），不写数据库
+func loadAccount(userID uint) (*models.UserAccount, error) {
+	var account models.UserAccount
+	err := database.DB.Where("user_id = ?", userID).First(&account).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &models.UserAccount{UserID: userID}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+// EmailVerified 用户邮箱是否已验证
+func EmailVerified(userID uint) bool {
+	account, err := loadAccount(userID)
+	return err == nil && account.EmailVerifiedAt != nil
+}
+
+// TelegramOnly 账号是否仅通过 Telegram 登录创建、尚未设置邮箱密码
+func TelegramOnly(userID uint) bool {
+	account, err := loadAccount(userID)
+	return err == nil && account.TelegramOnly
+}
+
+// AccountVerified 账号身份是否已验证：邮箱已验证，或由 Telegram 登录创建（Telegram 身份已校验）
+func AccountVerified(userID uint) bool {
+	account, err := loadAccount(userID)
+	return err == nil && (account.EmailVerifiedAt != nil || account.TelegramOnly)
+}
+
+// UserRole 用户角色，查询失败时按普通用户处理
+func UserRole(userID uint) string {
+	account, err := loadAccount(userID)
+	if err != nil || account.Role == "" {
+		return models.RoleUser
+	}
+	return account.Role
+}
+
+// Disabled 账号是否已被管理员停用或已注销
+func Disabled(userID uint) bool {
+	account, err := loadAccount(userID)
+	return err == nil && (account.DisabledAt != nil || account.DeletedAt != nil)
+}
+
+// SetRole 修改用户角色
+func SetRole(userID uint, role string) error {
+	if _, err := ensureAccount(userID); err != nil {
+		return err
+	}
+	return database.DB.Model(&models.UserAccount{}).Where("user_id = ?", userID).Update("role", role).Error
+}
+
+// SetDisabled 停用或恢复账号；停用时注销该用户全部会话
+func SetDisabled(userID uint, disabled bool) error {
+	if _, err := ensureAccount(userID); err != nil {
+		return err
+	}
+	var value interface{}
+	if disabled {
+		value = time.Now()
+	}
+	if err := database.DB.Model(&models.UserAccount{}).Where("user_id = ?", userID).Update("disabled_at", value).Error; err != nil {
+		return err
+	}
+	if disabled {
+		_, err := RevokeUserSessions(userID, "")
+		return err
+	}
+	return nil
+}
+
+// PromoteAdmins 将配置中的邮箱设为管理员，用于初始化第一个管理员
+func PromoteAdmins(emails []string) {
+	for _, email := range emails {
+		user, err := UserLogin(email)
+		if err != nil {
+			logger.Log.Warn("promote admin user not found", map[string]interface{}{"email": email})
+			continue
+		}
+		if err := SetRole(user.ID, models.RoleAdmin); err != nil {
+			logger.Log.Error("promote admin failed", map[string]interface{}{"user_id": user.ID, "error": err.Error()})
+		}
+	}
+}
+
+func markEmailVerified(userID uint) error {
+	if _, err := ensureAccount(userID); err != nil {
+		return err
+	}
+	return database.DB.Model(&models.UserAccount{}).Where("user_id = ?", userID).Update("email_verified_at", time.Now()).Error
+}
+
+// BackfillVerifiedAccounts 为已有用户补建账号记录并视为已验证；仅在 user_account 表首次创建时调用
+func BackfillVerifiedAccounts() error {
+	return database.DB.Exec(`INSERT INTO user_account (user_id, email_verified_at, created_at, updated_at)
+		SELECT id, created_at, NOW(), NOW() FROM user_info
+		WHERE id NOT IN (SELECT user_id FROM user_account)`).Error
+}
+
+// createUserToken 生成一次性令牌并保存哈希，返回明文令牌；同用途的旧令牌作废
+func createUserToken(userID uint, purpose, email string, ttl time.Duration) (string, error) {
+	raw, err := randomHex(32)
+	if err != nil {
+		return "", err
+	}
+	now := time.Now()
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.UserToken{}).
+			Where("user_id = ? AND purpose = ? AND used_at IS NULL", userID, purpose).
+			Update("used_at", now).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.UserToken{
+			UserID:    userID,
+			Purpose:   purpose,
+			TokenHash: hashToken(raw),
+			Email:     email,
+			ExpiresAt: now.Add(ttl),
+		}).Error
+	})
+	if err != nil {
+		return "", err
+	}
+	return raw, nil
+}
+
+// consumeUserToken 校验并使用一次性令牌，成功后令牌立即失效
+func consumeUserToken(purpose, raw string) (*models.UserToken, error) {
+	now := time.Now()
+	var token models.UserToken
+	err := database.DB.Where("token_hash = ? AND purpose = ? AND used_at IS NULL AND expires_at > ?", hashToken(raw), purpose, now).First(&token).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrTokenInvalid
+	}
+	if err != nil {
+		return nil, err
+	}
+	res := database.DB.Model(&models.UserToken{}).Where("id = ? AND used_at IS NULL", token.ID).Update("used_at", now)
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return nil, ErrTokenInvalid
+	}
+	return &token, nil
+}
+
+// RequireVerified 要求账号已验证（见 AccountVerified），需放在 RequireAuth 之后
+func RequireVerified(c *gin.Context) {
+	userID := c.MustGet(ContextUserIDKey).(uint)
+	if !AccountVerified(userID) {
+		response.Abort(c, response.CodeEmailNotVerified, nil)
+		return
+	}
+	c.Next()
+}
@@ -0,0 +1,170 @@
+package auth
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/cryptoSelect/backendapi/config"
+	"github.com/cryptoSelect/backendapi/models"
+	"github.com/cryptoSelect/backendapi/preference"
+	"github.com/cryptoSelect/backendapi/utils/i18n"
+	"github.com/cryptoSelect/backendapi/utils/logger"
+	"github.com/cryptoSelect/public/database"
+	publicModels "github.com/cryptoSelect/public/models"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrPasswordMismatch 确认密码错误
+	ErrPasswordMismatch = errors.New("password mismatch")
+	// ErrConfirmRequired 仅 Telegram 登录的账号注销时需显式确认
+	ErrConfirmRequired = errors.New("deletion confirm required")
+)
+
+// DeleteConfirmText 仅 Telegram 登录的账号没有密码，注销时以此文本确认
+const DeleteConfirmText = "DELETE"
+
+func deletionGrace() time.Duration {
+	if d := config.Cfg.Auth.DeletionGraceDays; d > 0 {
+		return time.Duration(d) * 24 * time.Hour
+	}
+	return 30 * 24 * time.Hour
+}
+
+// ConfirmDeletion 注销前的身份确认：有密码的账号校验密码，仅 Telegram 登录的账号需传 DeleteConfirmText；已开启两步验证时还需验证码或恢复码
+func ConfirmDeletion(userID uint, password, confirm, code string) error {
+	user, err := GetUserByID(userID)
+	if err != nil {
+		return err
+	}
+	if TelegramOnly(userID) {
+		if confirm != DeleteConfirmText {
+			return ErrConfirmRequired
+		}
+	} else if bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)) != nil {
+		return ErrPasswordMismatch
+	}
+	if TwoFactorEnabled(userID) {
+		return verifySecondFactor(userID, code)
+	}
+	return nil
+}
+
+// DeleteAccount 注销账号：删除订阅、Webhook、API key 等可继续产生动作的数据，解除 Telegram 绑定并发送告别消息，注销全部会话；
+// 账号标记为已注销，宽限期后由 RunMaintenance 彻底删除
+func DeleteAccount(userID uint) error {
+	lang := preference.Language(userID)
+	if _, err := ensureAccount(userID); err != nil {
+		return err
+	}
+	var oldTelegramID string
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var user publicModels.UserInfo
+		if err := tx.Select("id", "telegram_id").Where("id = ?", userID).First(&user).Error; err != nil {
+			return err
+		}
+		if err := deleteUserActivity(tx, userID); err != nil {
+			return err
+		}
+		oldTelegramID = strings.TrimSpace(user.TelegramID)
+		if oldTelegramID != "" {
+			if err := tx.Model(&publicModels.UserInfo{}).Where("id = ?", userID).Update("telegram_id", "").Error; err != nil {
+				return err
+			}
+			if err := tx.Where("user_id = ?", userID).Delete(&models.TelegramProfile{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Create(&models.TelegramBindLog{UserID: userID, TelegramID: oldTelegramID, Action: models.TelegramBindActionDeleted}).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Model(&models.UserAccount{}).Where("user_id = ?", userID).Update("deleted_at", time.Now()).Error
+	})
+	if err != nil {
+		return err
+	}
+	if _, err := RevokeUserSessions(userID, ""); err != nil {
+		logger.Log.Error("account delete revoke sessions failed", map[string]interface{}{"user_id": userID, "error": err.Error()})
+	}
+	if oldTelegramID != "" {
+		sendTelegramMessage(oldTelegramID, i18n.T(lang, "bind.goodbye"))
+	}
+	logger.Log.Info("account deleted", map[string]interface{}{"user_id": userID, "purge_after": time.Now().Add(deletionGrace())})
+	return nil
+}
+
+// deleteUserActivity 删除注销后不应再生效的数据
+func deleteUserActivity(tx *gorm.DB, userID uint) error {
+	var hookIDs []uint
+	if err := tx.Model(&models.Webhook{}).Where("user_id = ?", userID).Pluck("id", &hookIDs).Error; err != nil {
+		return err
+	}
+	if len(hookIDs) > 0 {
+		if err := tx.Where("webhook_id IN ?", hookIDs).Delete(&models.WebhookDelivery{}).Error; err != nil {
+			return err
+		}
+	}
+	for _, model := range []interface{}{
+		&models.SubscriptionRule{},
+		&publicModels.Subscription{},
+		&models.Webhook{},
+		&models.APIKey{},
+		&models.TwoFactor{},
+		&models.RecoveryCode{},
+		&models.LoginChallenge{},
+		&models.UserToken{},
+		&models.TelegramBindToken{},
+		&models.AlertPending{},
+	} {
+		if err := tx.Where("user_id = ?", userID).Delete(model).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// purgeDeletedAccounts 彻底删除注销超过宽限期的账号及其剩余数据，返回删除的账号数
+func purgeDeletedAccounts(now time.Time) (int64, error) {
+	var userIDs []uint
+	if err := database.DB.Model(&models.UserAccount{}).
+		Where("deleted_at IS NOT NULL AND deleted_at <= ?", now.Add(-deletionGrace())).
+		Pluck("user_id", &userIDs).Error; err != nil {
+		return 0, err
+	}
+	var purged int64
+	for _, userID := range userIDs {
+		err := database.DB.Transaction(func(tx *gorm.DB) error {
+			if err := deleteUserActivity(tx, userID); err != nil {
+				return err
+			}
+			for _, model := range []interface{}{
+				&models.TelegramProfile{},
+				&models.TelegramBindLog{},
+				&models.Session{},
+				&models.AlertDelivery{},
+				&models.UserPreference{},
+				&models.UserAccount{},
+			} {
+				if err := tx.Where("user_id = ?", userID).Delete(model).Error; err != nil {
+					return err
+				}
+			}
+			return tx.Where("id = ?", userID).Delete(&publicModels.UserInfo{}).Error
+		})
+		if err != nil {
+			return purged, err
+		}
+		logger.Log.Info("account purged", map[string]interface{}{"user_id": userID})
+		purged++
+	}
+	return purged, nil
+}
+
+// TelegramBindLogs 用户的 Telegram 绑定历史，按时间正序
+func TelegramBindLogs(userID uint) ([]models.TelegramBindLog, error) {
+	var logs []models.TelegramBindLog
+	err := database.DB.Where("user_id = ?", userID).Order("id ASC").Find(&logs).Error
+	return logs, err
+}
@@ -0,0 +1,243 @@
+package auth
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cryptoSelect/backendapi/models"
+	"github.com/cryptoSelect/backendapi/ratelimit"
+	"github.com/cryptoSelect/backendapi/response"
+	"github.com/cryptoSelect/public/database"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	// HeaderAPIKey 程序化访问时携带 API key 的请求头
+	HeaderAPIKey = "X-API-Key"
+	// ContextAPIKeyIDKey 通过 API key 认证时写入 context 的 key ID
+	ContextAPIKeyIDKey = "api_key_id"
+	// MaxAPIKeysPerUser 每个用户最多可持有的未吊销 API key 数
+	MaxAPIKeysPerUser = 10
+
+	apiKeyPrefix = "cs_"
+)
+
+var (
+	ErrAPIKeyNotFound = errors.New("api key not found")
+	ErrTooManyAPIKeys = errors.New("too many api keys")
+	ErrInvalidScope   = errors.New("invalid api key scope")
+)
+
+// validScopes 可授予 API key 的权限范围
+var validScopes = map[string]bool{
+	models.ScopeReadSymbol:        true,
+	models.ScopeReadFunds:         true,
+	models.ScopeWriteSubscription: true,
+}
+
+// APIKeyItem 返回给用户的 API key 信息，Key 仅创建时返回
+type APIKeyItem struct {
+	ID                 uint       `json:"id"`
+	Name               string     `json:"name"`
+	Key                string     `json:"key,omitempty"`
+	Prefix             string     `json:"prefix"`
+	Scopes             []string   `json:"scopes"`
+	RateLimitPerMinute int        `json:"rate_limit_per_minute"`
+	LastUsedAt         *time.Time `json:"last_used_at"`
+	CreatedAt          time.Time  `json:"created_at"`
+}
+
+func toAPIKeyItem(k *models.APIKey) APIKeyItem {
+	return APIKeyItem{
+		ID:                 k.ID,
+		Name:               k.Name,
+		Prefix:             k.Prefix,
+		Scopes:             splitScopes(k.Scopes),
+		RateLimitPerMinute: k.RateLimitPerMinute,
+		LastUsedAt:         k.LastUsedAt,
+		CreatedAt:          k.CreatedAt,
+	}
+}
+
+func splitScopes(s string) []string {
+	scopes := []string{}
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			scopes = append(scopes, v)
+		}
+	}
+	return scopes
+}
+
+// normalizeScopes 校验并去重权限范围
+func normalizeScopes(scopes []string) (string, error) {
+	seen := map[string]bool{}
+	out := make([]string, 0, len(scopes))
+	for _, s := range scopes {
+		s = strings.TrimSpace(s)
+		if !validScopes[s] {
+			return "", ErrInvalidScope
+		}
+		if !seen[s] {
+			seen[s] = true
+			out = append(out, s)
+		}
+	}
+	if len(out) == 0 {
+		return "", ErrInvalidScope
+	}
+	return strings.Join(out, ","), nil
+}
+
+// CreateAPIKey 创建 API key，返回包含明文 key 的信息（仅此一次）
+func CreateAPIKey(userID uint, name string, scopes []string, perMinute int) (*APIKeyItem, error) {
+	scopeText, err := normalizeScopes(scopes)
+	if err != nil {
+		return nil, err
+	}
+	var count int64
+	if err := database.DB.Model(&models.APIKey{}).Where("user_id = ? AND revoked_at IS NULL", userID).Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count >= MaxAPIKeysPerUser {
+		return nil, ErrTooManyAPIKeys
+	}
+	secret, err := randomHex(24)
+	if err != nil {
+		return nil, err
+	}
+	raw := apiKeyPrefix + secret
+	key := models.APIKey{
+		UserID:             userID,
+		Name:               name,
+		Prefix:             raw[:len(apiKeyPrefix)+8],
+		KeyHash:            hashToken(raw),
+		Scopes:             scopeText,
+		RateLimitPerMinute: perMinute,
+	}
+	if err := database.DB.Create(&key).Error; err != nil {
+		return nil, err
+	}
+	item := toAPIKeyItem(&key)
+	item.Key = raw
+	return &item, nil
+}
+
+// ListAPIKeys 用户未吊销的 API key 列表
+func ListAPIKeys(userID uint) ([]APIKeyItem, error) {
+	var keys []models.APIKey
+	if err := database.DB.Where("user_id = ? AND revoked_at IS NULL", userID).Order("id DESC").Find(&keys).Error; err != nil {
+		return nil, err
+	}
+	items := make([]APIKeyItem, 0, len(keys))
+	for i := range keys {
+		items = append(items, toAPIKeyItem(&keys[i]))
+	}
+	return items, nil
+}
+
+// RevokeAPIKey 吊销用户的 API key，立即失效
+func RevokeAPIKey(userID, id uint) error {
+	res := database.DB.Model(&models.APIKey{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, userID).
+		Update("revoked_at", time.Now())
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+// lookupAPIKey 按明文 key 查询未吊销的记录，并按 lastSeenInterval 更新最近使用时间
+func lookupAPIKey(raw string) (*models.APIKey, error) {
+	var key models.APIKey
+	err := database.DB.Where("key_hash = ? AND revoked_at IS NULL", hashToken(raw)).First(&key).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrAPIKeyNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) > lastSeenInterval {
+		database.DB.Model(&models.APIKey{}).Where("id = ?", key.ID).Update("last_used_at", now)
+	}
+	return &key, nil
+}
+
+func hasScope(key *models.APIKey, scope string) bool {
+	for _, s := range splitScopes(key.Scopes) {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// authAPIKey 校验 X-API-Key 的有效性、权限范围与限流，成功时写入 user_id 与 api_key_id；失败时已写响应
+func authAPIKey(c *gin.Context, raw, scope string) bool {
+	key, err := lookupAPIKey(strings.TrimSpace(raw))
+	if errors.Is(err, ErrAPIKeyNotFound) {
+		response.Fail(c, response.CodeInvalidAPIKey, nil)
+		return false
+	}
+	if err != nil {
+		response.ServerError(c, "api key lookup failed", err, nil)
+		return false
+	}
+	if Disabled(key.UserID) {
+		response.Fail(c, response.CodeAccountDisabled, nil)
+		return false
+	}
+	if !hasScope(key, scope) {
+		response.Fail(c, response.CodeInsufficientScope, gin.H{"required": scope})
+		return false
+	}
+	if !ratelimit.Allow(c, "api_key", strconv.FormatUint(uint64(key.ID), 10), ratelimit.APIKeyLimit(key.RateLimitPerMinute)) {
+		return false
+	}
+	c.Set(ContextUserIDKey, key.UserID)
+	c.Set(ContextUserRoleKey, models.RoleUser)
+	c.Set(ContextAPIKeyIDKey, key.ID)
+	return true
+}
+
+// RequireAuthOrAPIKey 接受 Authorization: Bearer <jwt> 或带 scope 权限的 X-API-Key，二者都写入相同的 ContextUserIDKey；
+// 登录 token 拥有全部权限，API key 只以普通用户身份访问
+func RequireAuthOrAPIKey(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if raw := c.GetHeader(HeaderAPIKey); raw != "" {
+			if !authAPIKey(c, raw, scope) {
+				c.Abort()
+				return
+			}
+			c.Next()
+			return
+		}
+		RequireAuth(c)
+	}
+}
+
+// OptionalAuthOrAPIKey 用于原本公开的查询接口：携带 X-API-Key 时按 RequireAuthOrAPIKey 校验，无效时拒绝；
+// 登录 token 有效时写入用户信息，无效、过期或会话已注销时按匿名放行，避免前端残留的旧 token 导致公开接口不可用
+func OptionalAuthOrAPIKey(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if raw := c.GetHeader(HeaderAPIKey); raw != "" {
+			if !authAPIKey(c, raw, scope) {
+				c.Abort()
+				return
+			}
+			c.Next()
+			return
+		}
+		if cl, _ := bearerClaims(c); cl != nil {
+			setClaims(c, cl)
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,63 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/cryptoSelect/backendapi/config"
+	"github.com/cryptoSelect/backendapi/utils/logger"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// 公开接口上无效、过期或签名错误的登录 token 按匿名访问
+func TestOptionalAuthOrAPIKeyIgnoresInvalidBearer(t *testing.T) {
+	logger.Init("test")
+	gin.SetMode(gin.TestMode)
+	old := config.Cfg
+	config.Cfg = &config.ServerConfig{JWTSecret: "test-secret"}
+	defer func() { config.Cfg = old }()
+
+	sign := func(secret string, expires time.Time) string {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims{
+			UserID:           1,
+			RegisteredClaims: jwt.RegisteredClaims{ID: "sid", ExpiresAt: jwt.NewNumericDate(expires)},
+		})
+		s, err := token.SignedString([]byte(secret))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+	tests := map[string]string{
+		"none":              "",
+		"garbage":           "Bearer not-a-jwt",
+		"scheme":            "Basic dXNlcjpwYXNz",
+		"empty":             "Bearer ",
+		"expired":           "Bearer " + sign("test-secret", time.Now().Add(-time.Hour)),
+		"bad sig":           "Bearer " + sign("other-secret", time.Now().Add(time.Hour)),
+		"expired lowercase": "bearer " + sign("test-secret", time.Now().Add(-time.Minute)),
+	}
+	for name, header := range tests {
+		t.Run(name, func(t *testing.T) {
+			r := gin.New()
+			r.GET("/symbol", OptionalAuthOrAPIKey("read:symbol"), func(c *gin.Context) {
+				if _, ok := c.Get(ContextUserIDKey); ok {
+					t.Error("invalid token must not set a user")
+				}
+				c.Status(http.StatusNoContent)
+			})
+			req := httptest.NewRequest("GET", "/symbol", nil)
+			if header != "" {
+				req.Header.Set("Authorization", header)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != http.StatusNoContent {
+				t.Fatalf("status %d %s, want anonymous access", w.Code, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cryptoSelect/backendapi/config"
+	"github.com/cryptoSelect/backendapi/models"
+	"github.com/cryptoSelect/backendapi/utils/dberr"
+	"github.com/cryptoSelect/public/database"
+)
+
+// 绑定回调签名请求头：signature = hex(HMAC-SHA256(TelegramCallbackSecret, timestamp + "." + nonce + "." + body))
+const (
+	HeaderBindTimestamp = "X-Bind-Timestamp"
+	HeaderBindNonce     = "X-Bind-Nonce"
+	HeaderBindSignature = "X-Bind-Signature"
+)
+
+// bindCallbackWindow 回调时间戳允许的偏差，窗口内的 nonce 不可重复
+const bindCallbackWindow = 5 * time.Minute
+
+var (
+	errCallbackDisabled  = errors.New("callback secret not configured")
+	errCallbackHeaders   = errors.New("missing signature headers")
+	errCallbackExpired   = errors.New("timestamp outside allowed window")
+	errCallbackSignature = errors.New("signature mismatch")
+	errCallbackReplay    = errors.New("nonce already used")
+)
+
+// maxNonceLen 与 telegram_bind_nonce.nonce 列长度一致
+const maxNonceLen = 64
+
+func callbackSecret() string {
+	return strings.TrimSpace(config.Cfg.TelegramCallbackSecret)
+}
+
+// SignBindCallback 为回调请求体生成签名头
+func SignBindCallback(secret string, body []byte, now time.Time) (http.Header, error) {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	ts := strconv.FormatInt(now.Unix(), 10)
+	nonce := hex.EncodeToString(b)
+	h := http.Header{}
+	h.Set(HeaderBindTimestamp, ts)
+	h.Set(HeaderBindNonce, nonce)
+	h.Set(HeaderBindSignature, bindSignature(secret, ts, nonce, body))
+	return h, nil
+}
+
+// VerifyBindCallback 校验回调签名、时间戳窗口与 nonce 是否重放；未配置密钥时一律拒绝。
+// nonce 写入 telegram_bind_nonce 表，多实例共享；写入失败时返回数据库错误（见 callbackRejected）
+func VerifyBindCallback(h http.Header, body []byte, now time.Time) error {
+	secret := callbackSecret()
+	if secret == "" {
+		return errCallbackDisabled
+	}
+	ts, nonce, sig := h.Get(HeaderBindTimestamp), h.Get(HeaderBindNonce), h.Get(HeaderBindSignature)
+	if ts == "" || nonce == "" || sig == "" || len(nonce) > maxNonceLen {
+		return errCallbackHeaders
+	}
+	sec, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return errCallbackHeaders
+	}
+	if d := now.Sub(time.Unix(sec, 0)); d > bindCallbackWindow || d < -bindCallbackWindow {
+		return errCallbackExpired
+	}
+	if !hmac.Equal([]byte(sig), []byte(bindSignature(secret, ts, nonce, body))) {
+		return errCallbackSignature
+	}
+
+	err = database.DB.Create(&models.TelegramBindNonce{Nonce: nonce, ExpiresAt: now.Add(2 * bindCallbackWindow)}).Error
+	if dberr.IsUniqueViolation(err) {
+		return errCallbackReplay
+	}
+	return err
+}
+
+// callbackRejected 是否为回调校验未通过；否则为记录 nonce 时的数据库错误
+func callbackRejected(err error) bool {
+	for _, e := range []error{errCallbackDisabled, errCallbackHeaders, errCallbackExpired, errCallbackSignature, errCallbackReplay} {
+		if errors.Is(err, e) {
+			return true
+		}
+	}
+	return false
+}
+
+// deleteExpiredNonces 删除已过时间窗口的 nonce，过期时间戳的回调本就会被拒绝
+func deleteExpiredNonces(now time.Time) (int64, error) {
+	res := database.DB.Where("expires_at <= ?", now).Delete(&models.TelegramBindNonce{})
+	return res.RowsAffected, res.Error
+}
+
+func bindSignature(secret, ts, nonce string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(ts + "." + nonce + "."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
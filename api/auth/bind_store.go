@@ -0,0 +1,187 @@
+package auth
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/cryptoSelect/backendapi/models"
+	"github.com/cryptoSelect/backendapi/utils/logger"
+	"github.com/cryptoSelect/public/database"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrBindTokenNotFound token 不存在或已过期
+	ErrBindTokenNotFound = errors.New("bind token not found or expired")
+	// ErrBindTokenUsed token 已被确认过，每个 token 只能确认一次
+	ErrBindTokenUsed = errors.New("bind token already used")
+)
+
+// BindRecord 一次 Telegram 绑定的状态
+type BindRecord struct {
+	Token      string
+	UserID     uint   // 发起绑定的用户 ID，用于区分不同用户
+	TelegramID string // Bot 确认后写入
+	ExpiresAt  time.Time
+}
+
+// BindStore 绑定 token 存储。已过期但尚未被清理的 token 必须视为不存在
+type BindStore interface {
+	Create(rec BindRecord) error
+	Get(token string, now time.Time) (*BindRecord, error)
+	// Confirm 写入 telegram_id 并在同一事务内调用 bind（写入用户的绑定），返回更新后的记录；
+	// bind 返回错误时 token 保持未确认，可再次使用；已确认过的 token 返回 ErrBindTokenUsed
+	Confirm(token, telegramID string, now time.Time, bind func(tx *gorm.DB, rec BindRecord) error) (*BindRecord, error)
+	// DeleteExpired 删除已过期的 token，返回删除条数
+	DeleteExpired(now time.Time) (int64, error)
+}
+
+var bindStore BindStore = NewMemoryBindStore()
+
+// SetBindStore 替换绑定 token 存储，需在注册路由前调用
+func SetBindStore(s BindStore) {
+	bindStore = s
+}
+
+// RunSweeper 按 interval 周期清理过期的绑定 token 与回调 nonce；会话与账号的清理见 RunMaintenance
+func RunSweeper(interval time.Duration) {
+	for {
+		time.Sleep(interval)
+		now := time.Now()
+		if n, err := bindStore.DeleteExpired(now); err != nil {
+			logger.Log.Error("tg bind sweep failed", map[string]interface{}{"error": err.Error()})
+		} else if n > 0 {
+			logger.Log.Debug("tg bind sweep", map[string]interface{}{"deleted": n})
+		}
+		if n, err := deleteExpiredNonces(now); err != nil {
+			logger.Log.Error("tg bind nonce sweep failed", map[string]interface{}{"error": err.Error()})
+		} else if n > 0 {
+			logger.Log.Debug("tg bind nonce sweep", map[string]interface{}{"deleted": n})
+		}
+	}
+}
+
+// MemoryBindStore 进程内存储，适合单实例与测试
+type MemoryBindStore struct {
+	mu      sync.Mutex
+	byToken map[string]BindRecord
+}
+
+func NewMemoryBindStore() *MemoryBindStore {
+	return &MemoryBindStore{byToken: map[string]BindRecord{}}
+}
+
+func (s *MemoryBindStore) Create(rec BindRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.byToken[rec.Token] = rec
+	return nil
+}
+
+func (s *MemoryBindStore) Get(token string, now time.Time) (*BindRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rec, ok := s.byToken[token]
+	if !ok || now.After(rec.ExpiresAt) {
+		return nil, ErrBindTokenNotFound
+	}
+	return &rec, nil
+}
+
+// Confirm 用户数据始终在数据库中，bind 在数据库事务内执行，成功后才标记 token 已确认
+func (s *MemoryBindStore) Confirm(token, telegramID string, now time.Time, bind func(tx *gorm.DB, rec BindRecord) error) (*BindRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rec, ok := s.byToken[token]
+	if !ok || now.After(rec.ExpiresAt) {
+		return nil, ErrBindTokenNotFound
+	}
+	if rec.TelegramID != "" {
+		return nil, ErrBindTokenUsed
+	}
+	rec.TelegramID = telegramID
+	if err := database.DB.Transaction(func(tx *gorm.DB) error { return bind(tx, rec) }); err != nil {
+		return nil, err
+	}
+	s.byToken[token] = rec
+	return &rec, nil
+}
+
+func (s *MemoryBindStore) DeleteExpired(now time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var n int64
+	for k, v := range s.byToken {
+		if now.After(v.ExpiresAt) {
+			delete(s.byToken, k)
+			n++
+		}
+	}
+	return n, nil
+}
+
+// DBBindStore 基于 telegram_bind_token 表的存储，多实例部署共享同一状态
+type DBBindStore struct{}
+
+func NewDBBindStore() *DBBindStore {
+	return &DBBindStore{}
+}
+
+func (DBBindStore) Create(rec BindRecord) error {
+	return database.DB.Create(&models.TelegramBindToken{
+		Token:      rec.Token,
+		UserID:     rec.UserID,
+		TelegramID: rec.TelegramID,
+		ExpiresAt:  rec.ExpiresAt,
+	}).Error
+}
+
+func (DBBindStore) Get(token string, now time.Time) (*BindRecord, error) {
+	return getBindToken(database.DB, token, now)
+}
+
+func getBindToken(db *gorm.DB, token string, now time.Time) (*BindRecord, error) {
+	var row models.TelegramBindToken
+	err := db.Where("token = ? AND expires_at > ?", token, now).First(&row).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrBindTokenNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &BindRecord{Token: row.Token, UserID: row.UserID, TelegramID: row.TelegramID, ExpiresAt: row.ExpiresAt}, nil
+}
+
+// Confirm 更新 token 时锁定该行，并发确认同一 token 只有一个成功；bind 失败时整个事务回滚
+func (DBBindStore) Confirm(token, telegramID string, now time.Time, bind func(tx *gorm.DB, rec BindRecord) error) (*BindRecord, error) {
+	var rec *BindRecord
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&models.TelegramBindToken{}).
+			Where("token = ? AND expires_at > ? AND (telegram_id = '' OR telegram_id IS NULL)", token, now).
+			Update("telegram_id", telegramID)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			if _, err := getBindToken(tx, token, now); err != nil {
+				return err
+			}
+			return ErrBindTokenUsed
+		}
+		var err error
+		if rec, err = getBindToken(tx, token, now); err != nil {
+			return err
+		}
+		return bind(tx, *rec)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rec, nil
+}
+
+func (DBBindStore) DeleteExpired(now time.Time) (int64, error) {
+	res := database.DB.Where("expires_at <= ?", now).Delete(&models.TelegramBindToken{})
+	return res.RowsAffected, res.Error
+}
@@ -0,0 +1,196 @@
+package auth
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/cryptoSelect/backendapi/models"
+	"github.com/cryptoSelect/backendapi/preference"
+	"github.com/cryptoSelect/backendapi/response"
+	"github.com/cryptoSelect/backendapi/utils/i18n"
+	"github.com/cryptoSelect/backendapi/utils/logger"
+	"github.com/cryptoSelect/public/database"
+	publicModels "github.com/cryptoSelect/public/models"
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+const changeEmailTTL = 24 * time.Hour
+
+var (
+	// ErrCredentialsNotSet 仅 Telegram 登录的账号尚未设置邮箱密码
+	ErrCredentialsNotSet = errors.New("credentials not set")
+	// ErrEmailUnchanged 新邮箱与当前邮箱相同
+	ErrEmailUnchanged = errors.New("email unchanged")
+	// ErrEmailReserved 邮箱使用了 Telegram 登录账号占位的保留域名
+	ErrEmailReserved = errors.New("email domain reserved")
+)
+
+// NormalizeEmail 邮箱统一去空格并转小写，与注册、登录一致
+func NormalizeEmail(email string) string {
+	return strings.TrimSpace(strings.ToLower(email))
+}
+
+// ReservedEmail 是否为 Telegram 登录账号的占位邮箱域名（含子域名），这类邮箱不能用于注册、登录或设置为登录邮箱
+func ReservedEmail(email string) bool {
+	email = NormalizeEmail(email)
+	return strings.HasSuffix(email, "@"+telegramPlaceholderDomain) || strings.HasSuffix(email, "."+telegramPlaceholderDomain)
+}
+
+// checkPassword 校验当前密码，仅 Telegram 登录的账号没有密码
+func checkPassword(userID uint, password string) (*publicModels.UserInfo, error) {
+	if TelegramOnly(userID) {
+		return nil, ErrCredentialsNotSet
+	}
+	user, err := GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)) != nil {
+		return nil, ErrPasswordMismatch
+	}
+	return user, nil
+}
+
+// notifyTelegram 向用户绑定的 Telegram 发送账号安全通知，未绑定时忽略
+func notifyTelegram(userID uint, key string, args ...interface{}) {
+	if chatID := GetUserTelegramID(userID); chatID != "" {
+		sendTelegramMessage(chatID, i18n.T(preference.Language(userID), key, args...))
+	}
+}
+
+// ChangePassword 校验当前密码后设置新密码，注销除 keepSessionID 外的全部会话，并通知绑定的 Telegram
+func ChangePassword(userID uint, current, next, keepSessionID string) error {
+	if _, err := checkPassword(userID, current); err != nil {
+		return err
+	}
+	hashed, err := bcrypt.GenerateFromPassword([]byte(next), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	if err := UpdateUserPassword(userID, string(hashed)); err != nil {
+		return err
+	}
+	if _, err := RevokeUserSessions(userID, keepSessionID); err != nil {
+		logger.Log.Error("revoke sessions after password change failed", map[string]interface{}{"user_id": userID, "error": err.Error()})
+	}
+	logger.Log.Info("password changed", map[string]interface{}{"user_id": userID})
+	notifyTelegram(userID, "account.password_changed")
+	return nil
+}
+
+// RequestEmailChange 校验当前密码后向新邮箱发送确认链接，确认前登录邮箱不变
+func RequestEmailChange(userID uint, password, newEmail string) error {
+	user, err := checkPassword(userID, password)
+	if err != nil {
+		return err
+	}
+	newEmail = NormalizeEmail(newEmail)
+	if newEmail == user.Email {
+		return ErrEmailUnchanged
+	}
+	if err := checkNewEmail(newEmail); err != nil {
+		return err
+	}
+	return sendEmailChangeLink(userID, newEmail)
+}
+
+// RequestAttachCredentials 为仅 Telegram 登录的账号设置密码（hashedPassword 为 bcrypt 哈希）并向邮箱发送确认链接；
+// 与修改邮箱相同，确认后才写入登录邮箱，之后可用邮箱登录
+func RequestAttachCredentials(userID uint, email, hashedPassword string) error {
+	email = NormalizeEmail(email)
+	if err := checkNewEmail(email); err != nil {
+		return err
+	}
+	if err := UpdateUserPassword(userID, hashedPassword); err != nil {
+		return err
+	}
+	return sendEmailChangeLink(userID, email)
+}
+
+// checkNewEmail 新登录邮箱不能是保留域名或已被其他账号使用
+func checkNewEmail(email string) error {
+	if ReservedEmail(email) {
+		return ErrEmailReserved
+	}
+	if EmailExists(email) {
+		return ErrEmailTaken
+	}
+	return nil
+}
+
+// sendEmailChangeLink 向新邮箱发送修改邮箱的确认链接，并通知绑定的 Telegram
+func sendEmailChangeLink(userID uint, newEmail string) error {
+	token, err := createUserToken(userID, models.TokenPurposeChangeEmail, newEmail, changeEmailTTL)
+	if err != nil {
+		return err
+	}
+	if err := sendUserMail(userID, newEmail, "change_email", mailLink("/confirm-email", token)); err != nil {
+		return err
+	}
+	logger.Log.Info("email change requested", map[string]interface{}{"user_id": userID})
+	notifyTelegram(userID, "account.email_change_requested", newEmail)
+	return nil
+}
+
+// changeEmail 将用户邮箱改为已验证的新邮箱；仅 Telegram 登录的账号由此完成设置邮箱密码
+func changeEmail(userID uint, email string) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&publicModels.UserInfo{}).Where("email = ? AND id <> ?", email, userID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrEmailTaken
+		}
+		if err := tx.Model(&publicModels.UserInfo{}).Where("id = ?", userID).Update("email", email).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.UserAccount{}).Where("user_id = ?", userID).
+			Updates(map[string]interface{}{"email_verified_at": time.Now(), "telegram_only": false}).Error
+	})
+}
+
+// ConfirmEmailChange 使用新邮箱收到的令牌完成邮箱修改，新邮箱视为已验证；旧邮箱与绑定的 Telegram 会收到通知
+func ConfirmEmailChange(c *gin.Context) {
+	var req TokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Fail(c, response.CodeInvalidRequest, nil)
+		return
+	}
+	token, err := consumeUserToken(models.TokenPurposeChangeEmail, req.Token)
+	if errors.Is(err, ErrTokenInvalid) {
+		response.Fail(c, response.CodeInvalidOrExpiredToken, nil)
+		return
+	}
+	if err != nil {
+		response.ServerError(c, "consume change email token failed", err, nil)
+		return
+	}
+	user, err := GetUserByID(token.UserID)
+	if err != nil || Disabled(token.UserID) {
+		response.Fail(c, response.CodeInvalidOrExpiredToken, nil)
+		return
+	}
+	oldEmail := user.Email
+	if err := changeEmail(token.UserID, token.Email); errors.Is(err, ErrEmailTaken) {
+		response.Fail(c, response.CodeEmailAlreadyRegistered, nil)
+		return
+	} else if err != nil {
+		response.ServerError(c, "email change failed", err, map[string]interface{}{"user_id": token.UserID})
+		return
+	}
+	logger.Log.Info("email changed", map[string]interface{}{"user_id": token.UserID})
+	notifyTelegram(token.UserID, "account.email_changed", token.Email)
+	// 原为 Telegram 登录的占位邮箱时无法投递，不再通知
+	if !ReservedEmail(oldEmail) {
+		go func() {
+			if err := sendUserMail(token.UserID, oldEmail, "email_changed", token.Email); err != nil {
+				logger.Log.Error("send email changed notice failed", map[string]interface{}{"user_id": token.UserID, "error": err.Error()})
+			}
+		}()
+	}
+	response.OK(c, gin.H{"ok": true})
+}
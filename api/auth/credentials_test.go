@@ -0,0 +1,53 @@
+package auth
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cryptoSelect/backendapi/config"
+	"github.com/cryptoSelect/backendapi/response"
+	"github.com/cryptoSelect/backendapi/utils/logger"
+	"github.com/gin-gonic/gin"
+)
+
+func TestReservedEmail(t *testing.T) {
+	tests := map[string]bool{
+		"tg123@telegram.invalid":      true,
+		" TG123@Telegram.Invalid ":    true,
+		"a@sub.telegram.invalid":      true,
+		"a@example.com":               false,
+		"a@telegram.invalid.com":      false,
+		"a@nottelegram.invalid":       false,
+		"telegram.invalid@example.io": false,
+	}
+	for email, want := range tests {
+		if got := ReservedEmail(email); got != want {
+			t.Errorf("ReservedEmail(%q) = %v, want %v", email, got, want)
+		}
+	}
+}
+
+// 保留域名在查库前即被拒绝
+func TestRegisterRejectsReservedEmail(t *testing.T) {
+	logger.Init("test")
+	gin.SetMode(gin.TestMode)
+	old := config.Cfg
+	config.Cfg = &config.ServerConfig{JWTSecret: "test-secret"}
+	defer func() { config.Cfg = old }()
+
+	r := gin.New()
+	r.POST("/register", Register)
+	req := httptest.NewRequest("POST", "/register", strings.NewReader(`{"email":"tg1@telegram.invalid","password":"secret123"}`))
+	req.Header.Set(response.HeaderAPIVersion, "2")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	var body response.Response
+	json.Unmarshal(w.Body.Bytes(), &body)
+	if w.Code != http.StatusBadRequest || body.Error != response.CodeEmailReserved {
+		t.Fatalf("status %d error %q, want 400 email_reserved", w.Code, body.Error)
+	}
+}
@@ -0,0 +1,186 @@
+package auth
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/cryptoSelect/backendapi/config"
+	"github.com/cryptoSelect/backendapi/models"
+	"github.com/cryptoSelect/backendapi/preference"
+	"github.com/cryptoSelect/backendapi/response"
+	"github.com/cryptoSelect/backendapi/utils/i18n"
+	"github.com/cryptoSelect/backendapi/utils/logger"
+	"github.com/cryptoSelect/backendapi/utils/mail"
+	publicModels "github.com/cryptoSelect/public/models"
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	verifyEmailTTL   = 24 * time.Hour
+	resetPasswordTTL = 30 * time.Minute
+)
+
+type TokenRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required,min=6"`
+}
+
+// mailLink 拼接邮件中的前端链接，如 <LinkBase>/verify-email?token=xxx
+func mailLink(path, token string) string {
+	base := strings.TrimRight(config.Cfg.Mail.LinkBase, "/")
+	return base + path + "?token=" + url.QueryEscape(token)
+}
+
+// sendUserMail 按用户偏好语言发送邮件，key 为 i18n 中 mail.<key>.subject / mail.<key>.body 的前缀
+func sendUserMail(userID uint, to, key string, args ...interface{}) error {
+	lang := preference.Language(userID)
+	return mail.Send(to, i18n.T(lang, "mail."+key+".subject"), i18n.T(lang, "mail."+key+".body", args...))
+}
+
+// sendVerificationEmail 生成验证令牌并发送验证邮件
+func sendVerificationEmail(user *publicModels.UserInfo) error {
+	token, err := createUserToken(user.ID, models.TokenPurposeVerifyEmail, user.Email, verifyEmailTTL)
+	if err != nil {
+		return err
+	}
+	return sendUserMail(user.ID, user.Email, "verify_email", mailLink("/verify-email", token))
+}
+
+// sendVerificationEmailAsync 异步发送验证邮件，失败只记录日志
+func sendVerificationEmailAsync(user *publicModels.UserInfo) {
+	go func() {
+		if err := sendVerificationEmail(user); err != nil {
+			logger.Log.Error("send verification email failed", map[string]interface{}{"user_id": user.ID, "error": err.Error()})
+		}
+	}()
+}
+
+// VerifyEmail 使用邮件中的令牌验证邮箱
+func VerifyEmail(c *gin.Context) {
+	var req TokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Fail(c, response.CodeInvalidRequest, nil)
+		return
+	}
+	token, err := consumeUserToken(models.TokenPurposeVerifyEmail, req.Token)
+	if errors.Is(err, ErrTokenInvalid) {
+		response.Fail(c, response.CodeInvalidOrExpiredToken, nil)
+		return
+	}
+	if err != nil {
+		response.ServerError(c, "consume verify email token failed", err, nil)
+		return
+	}
+	user, err := GetUserByID(token.UserID)
+	if err != nil || user.Email != token.Email {
+		// 邮箱已变更，旧令牌不再有效
+		response.Fail(c, response.CodeInvalidOrExpiredToken, nil)
+		return
+	}
+	if err := markEmailVerified(token.UserID); err != nil {
+		response.ServerError(c, "mark email verified failed", err, map[string]interface{}{"user_id": token.UserID})
+		return
+	}
+	logger.Log.Info("email verified", map[string]interface{}{"user_id": token.UserID})
+	response.OK(c, gin.H{"ok": true})
+}
+
+// ResendVerification 重新发送验证邮件（需登录）
+func ResendVerification(c *gin.Context) {
+	userID := c.MustGet(ContextUserIDKey).(uint)
+	if EmailVerified(userID) {
+		response.Fail(c, response.CodeEmailAlreadyVerified, nil)
+		return
+	}
+	if TelegramOnly(userID) {
+		response.Fail(c, response.CodeEmailNotSet, nil)
+		return
+	}
+	user, err := GetUserByID(userID)
+	if err != nil {
+		response.ServerError(c, "load user failed", err, map[string]interface{}{"user_id": userID})
+		return
+	}
+	if err := sendVerificationEmail(user); err != nil {
+		logger.Log.Error("send verification email failed", map[string]interface{}{"user_id": userID, "error": err.Error()})
+		response.Fail(c, response.CodeSendMailFailed, nil)
+		return
+	}
+	response.OK(c, gin.H{"ok": true})
+}
+
+// ForgotPassword 发送重置密码邮件；无论邮箱是否注册都返回成功，避免暴露注册信息
+func ForgotPassword(c *gin.Context) {
+	var req ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Fail(c, response.CodeInvalidRequest, nil)
+		return
+	}
+	if user, err := UserLogin(req.Email); err == nil && !ReservedEmail(user.Email) {
+		go func() {
+			token, err := createUserToken(user.ID, models.TokenPurposeResetPassword, user.Email, resetPasswordTTL)
+			if err == nil {
+				err = sendUserMail(user.ID, user.Email, "reset_password", mailLink("/reset-password", token))
+			}
+			if err != nil {
+				logger.Log.Error("send reset password email failed", map[string]interface{}{"user_id": user.ID, "error": err.Error()})
+			}
+		}()
+	}
+	response.OK(c, gin.H{"ok": true})
+}
+
+// ResetPassword 使用重置令牌设置新密码，并注销该用户全部会话
+func ResetPassword(c *gin.Context) {
+	var req ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Fail(c, response.CodeInvalidRequest, nil)
+		return
+	}
+	token, err := consumeUserToken(models.TokenPurposeResetPassword, req.Token)
+	if errors.Is(err, ErrTokenInvalid) {
+		response.Fail(c, response.CodeInvalidOrExpiredToken, nil)
+		return
+	}
+	if err != nil {
+		response.ServerError(c, "consume reset password token failed", err, nil)
+		return
+	}
+	hashed, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		response.ServerError(c, "hash password failed", err, nil)
+		return
+	}
+	user, err := GetUserByID(token.UserID)
+	if err != nil {
+		response.Fail(c, response.CodeInvalidOrExpiredToken, nil)
+		return
+	}
+	if err := UpdateUserPassword(token.UserID, string(hashed)); err != nil {
+		response.ServerError(c, "reset password failed", err, map[string]interface{}{"user_id": token.UserID})
+		return
+	}
+	// 能收到重置邮件即证明拥有令牌发送到的邮箱；此后邮箱已变更时不能据此验证新邮箱
+	if user.Email == token.Email {
+		if err := markEmailVerified(token.UserID); err != nil {
+			logger.Log.Error("mark email verified after reset failed", map[string]interface{}{"user_id": token.UserID, "error": err.Error()})
+		}
+	}
+	if _, err := RevokeUserSessions(token.UserID, ""); err != nil {
+		logger.Log.Error("revoke sessions after reset failed", map[string]interface{}{"user_id": token.UserID, "error": err.Error()})
+	}
+	notifyTelegram(token.UserID, "account.password_reset")
+	logger.Log.Info("password reset", map[string]interface{}{"user_id": token.UserID})
+	response.OK(c, gin.H{"ok": true})
+}
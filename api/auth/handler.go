@@ -1,11 +1,13 @@
 package auth
 
 import (
-	"net/http"
 	"strings"
 	"time"
 
 	"github.com/cryptoSelect/backendapi/config"
+	"github.com/cryptoSelect/backendapi/ratelimit"
+	"github.com/cryptoSelect/backendapi/response"
+	"github.com/cryptoSelect/backendapi/utils/logger"
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 	"golang.org/x/crypto/bcrypt"
@@ -14,6 +16,8 @@ import (
 const (
 	ContextUserIDKey    = "user_id"
 	ContextUserEmailKey = "user_email"
+	ContextSessionIDKey = "session_id"
+	ContextUserRoleKey  = "user_role"
 )
 
 type LoginRequest struct {
@@ -27,20 +31,20 @@ type RegisterRequest struct {
 }
 
 type AuthResponse struct {
-	Token         string `json:"token"`
+	Token         string `json:"token"`         // access token，短期有效
+	RefreshToken  string `json:"refresh_token"` // 用于 /api/auth/refresh 换取新 token，每次使用后轮换
+	ExpiresIn     int64  `json:"expires_in"`    // access token 有效期（秒）
 	Email         string `json:"email"`
 	TelegramBound bool   `json:"telegram_bound"` // 是否已绑定 Telegram，前端据此决定是否展示绑定入口
-}
-
-type Response struct {
-	Error string      `json:"error"`
-	Code  int         `json:"code"`
-	Data  interface{} `json:"data"`
+	EmailVerified bool   `json:"email_verified"` // 邮箱是否已验证，未验证时不能创建订阅
+	TelegramOnly  bool   `json:"telegram_only"`  // 通过 Telegram 登录创建、尚未设置邮箱密码，此时 email 为空
+	Role          string `json:"role"`           // 角色：user / admin
 }
 
 type claims struct {
 	UserID uint   `json:"user_id"`
 	Email  string `json:"email"`
+	Role   string `json:"role"`
 	jwt.RegisteredClaims
 }
 
@@ -48,75 +52,104 @@ type claims struct {
 func Login(c *gin.Context) {
 	var req LoginRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, Response{Error: "invalid_request", Code: 400, Data: nil})
+		response.FailStatus(c, response.CodeInvalidRequest, nil)
 		return
 	}
 	secret := config.Cfg.JWTSecret
 	if secret == "" {
-		c.JSON(http.StatusOK, Response{Error: "server_error", Code: 500, Data: nil})
+		response.ServerError(c, "jwt secret not configured", nil, nil)
+		return
+	}
+
+	// 连续失败达到阈值后锁定该邮箱，锁定时长指数增长；不存在的邮箱同样计数，避免暴露注册信息
+	lockKey := ratelimit.LockKey("login", req.Email)
+	if locked, retryAfter := ratelimit.Locked(lockKey); locked {
+		response.Fail(c, response.CodeAccountLocked, gin.H{"retry_after": retryAfter})
 		return
 	}
 
-	// 只按邮箱查用户；库里存的是 bcrypt 哈希，不能把明文密码放进 SQL
+	// 只按邮箱查用户；库里存的是 bcrypt 哈希，不能把明文密码放进 SQL。
+	// Telegram 登录账号的占位邮箱不能用于登录（设置的邮箱确认前密码已写入）
 	user, err := UserLogin(req.Email)
+	if err == nil && ReservedEmail(user.Email) {
+		err = ErrUserNotFound
+	}
 	if err != nil {
-		c.JSON(http.StatusOK, Response{Error: "email_or_password_wrong", Code: 401, Data: nil})
+		ratelimit.Fail(lockKey, c.ClientIP())
+		response.Fail(c, response.CodeEmailOrPasswordWrong, nil)
 		return
 	}
 	if err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
-		c.JSON(http.StatusOK, Response{Error: "email_or_password_wrong", Code: 401, Data: nil})
+		ratelimit.Fail(lockKey, c.ClientIP())
+		response.Fail(c, response.CodeEmailOrPasswordWrong, nil)
 		return
 	}
-
-	token, err := issueToken(secret, user.ID, user.Email)
-	if err != nil {
-		c.JSON(http.StatusOK, Response{Error: "server_error", Code: 500, Data: nil})
+	// 开启两步验证时，失败计数在第二步通过后才清除，避免重新登录绕过验证码错误的锁定
+	if !TwoFactorEnabled(user.ID) {
+		ratelimit.Reset(lockKey)
+	}
+	if Disabled(user.ID) {
+		response.Fail(c, response.CodeAccountDisabled, nil)
 		return
 	}
-	c.JSON(http.StatusOK, Response{Error: "", Code: 200, Data: AuthResponse{Token: token, Email: user.Email, TelegramBound: strings.TrimSpace(user.TelegramID) != ""}})
+
+	// 开启两步验证时返回登录挑战，否则直接签发 token
+	completeLogin(c, user)
 }
 
 // Register 注册新用户
 func Register(c *gin.Context) {
 	var req RegisterRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusOK, Response{Error: "invalid_request", Code: 400, Data: nil})
+		response.Fail(c, response.CodeInvalidRequest, nil)
 		return
 	}
 	secret := config.Cfg.JWTSecret
 	if secret == "" {
-		c.JSON(http.StatusOK, Response{Error: "server_error", Code: 500, Data: nil})
+		response.ServerError(c, "jwt secret not configured", nil, nil)
+		return
+	}
+	email := NormalizeEmail(req.Email)
+	if ReservedEmail(email) {
+		response.Fail(c, response.CodeEmailReserved, nil)
 		return
 	}
-	email := strings.TrimSpace(strings.ToLower(req.Email))
 	if EmailExists(email) {
-		c.JSON(http.StatusOK, Response{Error: "email_already_registered", Code: 400, Data: nil})
+		response.Fail(c, response.CodeEmailAlreadyRegistered, nil)
 		return
 	}
 	hashed, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
 	if err != nil {
-		c.JSON(http.StatusOK, Response{Error: "server_error", Code: 500, Data: nil})
+		response.ServerError(c, "hash password failed", err, nil)
 		return
 	}
 	user, err := CreateUserWithEmail(email, string(hashed), "")
 	if err != nil {
-		c.JSON(http.StatusOK, Response{Error: "server_error", Code: 500, Data: nil})
+		response.ServerError(c, "register create user failed", err, map[string]interface{}{"email": email})
 		return
 	}
-	token, err := issueToken(secret, user.ID, user.Email)
+	if _, err := ensureAccount(user.ID); err != nil {
+		response.ServerError(c, "register create account failed", err, map[string]interface{}{"user_id": user.ID})
+		return
+	}
+	sendVerificationEmailAsync(user)
+	resp, err := startSession(c, user)
 	if err != nil {
-		c.JSON(http.StatusOK, Response{Error: "server_error", Code: 500, Data: nil})
+		response.ServerError(c, "start session failed", err, map[string]interface{}{"user_id": user.ID})
 		return
 	}
-	c.JSON(http.StatusOK, Response{Error: "", Code: 200, Data: AuthResponse{Token: token, Email: user.Email, TelegramBound: false}})
+	response.OK(c, resp)
 }
 
-func issueToken(secret string, userID uint, email string) (string, error) {
-	exp := time.Now().Add(7 * 24 * time.Hour)
+// issueToken 签发 access token，jti 为会话 ID，RequireAuth 据此校验会话是否已注销
+func issueToken(secret string, userID uint, email, role, sessionID string) (string, error) {
+	exp := time.Now().Add(accessTokenTTL())
 	claims := claims{
 		UserID: userID,
 		Email:  email,
+		Role:   role,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        sessionID,
 			ExpiresAt: jwt.NewNumericDate(exp),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
@@ -125,42 +158,65 @@ func issueToken(secret string, userID uint, email string) (string, error) {
 	return token.SignedString([]byte(secret))
 }
 
-// RequireAuth 从 Authorization: Bearer <token> 解析 JWT，将 user_id、user_email 写入 context；未登录返回 401
-func RequireAuth(c *gin.Context) {
+// bearerClaims 从 Authorization: Bearer <token> 解析 JWT 并校验会话未注销；失败时返回错误码
+func bearerClaims(c *gin.Context) (*claims, response.ErrorCode) {
 	auth := c.GetHeader("Authorization")
 	if auth == "" {
-		c.JSON(http.StatusOK, Response{Error: "unauthorized", Code: 401, Data: nil})
-		c.Abort()
-		return
+		return nil, response.CodeUnauthorized
 	}
 	parts := strings.SplitN(auth, " ", 2)
 	if len(parts) != 2 || !strings.EqualFold(parts[0], "Bearer") {
-		c.JSON(http.StatusOK, Response{Error: "unauthorized", Code: 401, Data: nil})
-		c.Abort()
-		return
+		return nil, response.CodeUnauthorized
 	}
 	tokenString := strings.TrimSpace(parts[1])
 	if tokenString == "" {
-		c.JSON(http.StatusOK, Response{Error: "unauthorized", Code: 401, Data: nil})
-		c.Abort()
-		return
+		return nil, response.CodeUnauthorized
 	}
 	secret := config.Cfg.JWTSecret
 	if secret == "" {
-		c.JSON(http.StatusOK, Response{Error: "server auth not configured", Code: 500, Data: nil})
-		c.Abort()
-		return
+		logger.Log.Error("jwt secret not configured", nil)
+		return nil, response.CodeServerError
 	}
 	var cl claims
 	token, err := jwt.ParseWithClaims(tokenString, &cl, func(*jwt.Token) (interface{}, error) {
 		return []byte(secret), nil
 	})
 	if err != nil || !token.Valid {
-		c.JSON(http.StatusOK, Response{Error: "unauthorized", Code: 401, Data: nil})
-		c.Abort()
+		return nil, response.CodeUnauthorized
+	}
+	if !sessionActive(cl.ID, cl.UserID) {
+		return nil, response.CodeSessionRevoked
+	}
+	return &cl, ""
+}
+
+// RequireAuth 从 Authorization: Bearer <token> 解析 JWT 并校验会话未注销，将 user_id、user_email、user_role、session_id 写入 context；未登录返回 401
+func RequireAuth(c *gin.Context) {
+	cl, code := bearerClaims(c)
+	if cl == nil {
+		response.Abort(c, code, nil)
 		return
 	}
+	setClaims(c, cl)
+	c.Next()
+}
+
+// setClaims 将登录 token 中的用户信息写入 context
+func setClaims(c *gin.Context, cl *claims) {
 	c.Set(ContextUserIDKey, cl.UserID)
 	c.Set(ContextUserEmailKey, cl.Email)
-	c.Next()
+	c.Set(ContextSessionIDKey, cl.ID)
+	c.Set(ContextUserRoleKey, cl.Role)
+}
+
+// RequireRole 要求当前用户具有指定角色，需放在 RequireAuth 之后；角色以数据库为准，降级后无需等待 token 过期
+func RequireRole(role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.MustGet(ContextUserIDKey).(uint)
+		if UserRole(userID) != role {
+			response.Abort(c, response.CodeForbidden, nil)
+			return
+		}
+		c.Next()
+	}
 }
@@ -0,0 +1,29 @@
+package auth
+
+import (
+	"time"
+
+	"github.com/cryptoSelect/backendapi/utils/logger"
+)
+
+// RunMaintenance 按 interval 周期清理过期的登录会话与登录挑战，并彻底删除超过宽限期的已注销账号。
+// 过期记录在读取时已被忽略，清理只为控制表大小，间隔可以比 RunSweeper 长
+func RunMaintenance(interval time.Duration) {
+	for {
+		time.Sleep(interval)
+		now := time.Now()
+		if n, err := deleteExpiredSessions(now); err != nil {
+			logger.Log.Error("session sweep failed", map[string]interface{}{"error": err.Error()})
+		} else if n > 0 {
+			logger.Log.Debug("session sweep", map[string]interface{}{"deleted": n})
+		}
+		if n, err := deleteExpiredChallenges(now); err != nil {
+			logger.Log.Error("login challenge sweep failed", map[string]interface{}{"error": err.Error()})
+		} else if n > 0 {
+			logger.Log.Debug("login challenge sweep", map[string]interface{}{"deleted": n})
+		}
+		if _, err := purgeDeletedAccounts(now); err != nil {
+			logger.Log.Error("account purge failed", map[string]interface{}{"error": err.Error()})
+		}
+	}
+}
@@ -1,12 +1,35 @@
 package auth
 
 import (
+	"errors"
 	"strings"
+	"time"
 
+	"github.com/cryptoSelect/backendapi/models"
+	"github.com/cryptoSelect/backendapi/utils/dberr"
 	"github.com/cryptoSelect/public/database"
 	publicModels "github.com/cryptoSelect/public/models"
+	"gorm.io/gorm"
 )
 
+var (
+	// ErrUserNotFound 用户不存在
+	ErrUserNotFound = errors.New("user not found")
+	// ErrTelegramAlreadyBound 该 Telegram 账号已绑定其他用户
+	ErrTelegramAlreadyBound = errors.New("telegram account already bound to another user")
+	// ErrTelegramOnlyAccount 账号仅能通过 Telegram 登录，解绑前需先设置邮箱密码
+	ErrTelegramOnlyAccount = errors.New("telegram only account cannot unbind")
+	// ErrEmailTaken 邮箱已被其他账号使用
+	ErrEmailTaken = errors.New("email already registered")
+)
+
+// TelegramAccount 发起绑定的 Telegram 账号，来自 /start 消息的 From
+type TelegramAccount struct {
+	ID        string `json:"telegram_id"`
+	Username  string `json:"username"`
+	FirstName string `json:"first_name"`
+}
+
 func CreateUser(user *publicModels.UserInfo) error {
 	if err := database.DB.Create(user).Error; err != nil {
 		return err
@@ -36,7 +59,7 @@ func EmailExists(email string) bool {
 // UserLogin 按邮箱查询用户，仅用于登录时取回用户（含密码哈希）；密码校验在 handler 里用 bcrypt.CompareHashAndPassword
 func UserLogin(email string) (*publicModels.UserInfo, error) {
 	var user publicModels.UserInfo
-	if err := database.DB.Where("email = ?", strings.TrimSpace(strings.ToLower(email))).First(&user).Error; err != nil {
+	if err := database.DB.Where("email = ?", NormalizeEmail(email)).First(&user).Error; err != nil {
 		return nil, err
 	}
 	return &user, nil
@@ -55,3 +78,139 @@ func GetUserTelegramID(userID uint) string {
 	}
 	return strings.TrimSpace(user.TelegramID)
 }
+
+// GetUserIDByTelegramID 按 telegram_id 查询已绑定的用户 ID，未绑定时返回 ErrUserNotFound
+func GetUserIDByTelegramID(telegramID string) (uint, error) {
+	telegramID = strings.TrimSpace(telegramID)
+	if telegramID == "" {
+		return 0, ErrUserNotFound
+	}
+	var user publicModels.UserInfo
+	err := database.DB.Select("id").Where("telegram_id = ?", telegramID).First(&user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, ErrUserNotFound
+	}
+	if err != nil {
+		return 0, err
+	}
+	return user.ID, nil
+}
+
+// EnsureTelegramIDUnique 为 user_info.telegram_id 建立部分唯一索引（忽略空值），保证一个 Telegram 账号只绑定一个用户
+func EnsureTelegramIDUnique() error {
+	return database.DB.Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_user_info_telegram_id ON user_info (telegram_id) WHERE telegram_id IS NOT NULL AND telegram_id <> ''").Error
+}
+
+// TelegramBoundToOther 判断 telegramID 是否已绑定到 userID 以外的用户
+func TelegramBoundToOther(userID uint, telegramID string) (bool, error) {
+	var count int64
+	err := database.DB.Model(&publicModels.UserInfo{}).
+		Where("telegram_id = ? AND id <> ?", strings.TrimSpace(telegramID), userID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// BindTelegram 在事务 tx 内将 Telegram 账号绑定到用户，记录资料与历史，返回被替换的旧 telegram_id（没有则为空）；
+// 该 Telegram 已绑定其他用户（含并发绑定触发的唯一约束冲突）时返回 ErrTelegramAlreadyBound
+func BindTelegram(tx *gorm.DB, userID uint, acc TelegramAccount) (string, error) {
+	acc.ID = strings.TrimSpace(acc.ID)
+	var user publicModels.UserInfo
+	if err := tx.Select("id", "telegram_id").Where("id = ?", userID).First(&user).Error; err != nil {
+		return "", err
+	}
+	var count int64
+	if err := tx.Model(&publicModels.UserInfo{}).Where("telegram_id = ? AND id <> ?", acc.ID, userID).Count(&count).Error; err != nil {
+		return "", err
+	}
+	if count > 0 {
+		return "", ErrTelegramAlreadyBound
+	}
+	oldTelegramID := strings.TrimSpace(user.TelegramID)
+	if oldTelegramID == acc.ID {
+		oldTelegramID = ""
+	}
+	if err := tx.Model(&publicModels.UserInfo{}).Where("id = ?", userID).Update("telegram_id", acc.ID).Error; err != nil {
+		if dberr.IsUniqueViolation(err) {
+			return "", ErrTelegramAlreadyBound
+		}
+		return "", err
+	}
+	profile := models.TelegramProfile{UserID: userID}
+	if err := tx.Where(models.TelegramProfile{UserID: userID}).
+		Assign(models.TelegramProfile{TelegramID: acc.ID, Username: acc.Username, FirstName: acc.FirstName, BoundAt: time.Now()}).
+		FirstOrCreate(&profile).Error; err != nil {
+		return "", err
+	}
+	if oldTelegramID != "" {
+		if err := tx.Create(&models.TelegramBindLog{UserID: userID, TelegramID: oldTelegramID, Action: models.TelegramBindActionReplaced}).Error; err != nil {
+			return "", err
+		}
+	}
+	if err := tx.Create(&models.TelegramBindLog{UserID: userID, TelegramID: acc.ID, Username: acc.Username, Action: models.TelegramBindActionBind}).Error; err != nil {
+		return "", err
+	}
+	return oldTelegramID, nil
+}
+
+// UnbindTelegram 解除用户的 Telegram 绑定，返回原 telegram_id；未绑定时返回空。
+// 仅通过 Telegram 登录的账号返回 ErrTelegramOnlyAccount，避免解绑后无法登录
+func UnbindTelegram(userID uint) (string, error) {
+	if TelegramOnly(userID) {
+		return "", ErrTelegramOnlyAccount
+	}
+	return unbindTelegram(userID, models.TelegramBindActionUnbind)
+}
+
+// ForceUnbindTelegram 管理员强制解除绑定，不检查是否仅 Telegram 登录的账号
+func ForceUnbindTelegram(userID uint) (string, error) {
+	return unbindTelegram(userID, models.TelegramBindActionAdmin)
+}
+
+func unbindTelegram(userID uint, action string) (string, error) {
+	var oldTelegramID string
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var user publicModels.UserInfo
+		if err := tx.Select("id", "telegram_id").Where("id = ?", userID).First(&user).Error; err != nil {
+			return err
+		}
+		oldTelegramID = strings.TrimSpace(user.TelegramID)
+		if oldTelegramID == "" {
+			return nil
+		}
+		if err := tx.Model(&publicModels.UserInfo{}).Where("id = ?", userID).Update("telegram_id", "").Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&models.TelegramProfile{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.TelegramBindLog{UserID: userID, TelegramID: oldTelegramID, Action: action}).Error
+	})
+	return oldTelegramID, err
+}
+
+// GetTelegramProfile 获取用户绑定的 Telegram 资料，未绑定时返回 nil
+func GetTelegramProfile(userID uint) *models.TelegramProfile {
+	var profile models.TelegramProfile
+	if database.DB.Where("user_id = ?", userID).First(&profile).Error != nil {
+		return nil
+	}
+	return &profile
+}
+
+// GetUserByID 按 ID 查询用户
+func GetUserByID(userID uint) (*publicModels.UserInfo, error) {
+	var user publicModels.UserInfo
+	err := database.DB.Where("id = ?", userID).First(&user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// UpdateUserPassword 更新用户密码（hashedPassword 为 bcrypt 哈希）
+func UpdateUserPassword(userID uint, hashedPassword string) error {
+	return database.DB.Model(&publicModels.UserInfo{}).Where("id = ?", userID).Update("password", hashedPassword).Error
+}
@@ -1,15 +1,42 @@
 package auth
 
 import (
+	"github.com/cryptoSelect/backendapi/ratelimit"
 	"github.com/gin-gonic/gin"
 )
 
 func SetupAuthRoutes(router *gin.RouterGroup) {
-	router.POST("/login", Login)
-	router.POST("/register", Register)
+	// 限流：未登录接口按 IP，带邮箱的接口再按邮箱，登录后接口按用户
+	ipLimit, accountLimit := ratelimit.IPLimit(), ratelimit.AccountLimit()
+	byUser := ratelimit.ByContextKey(ContextUserIDKey)
+	byEmail := ratelimit.ByJSONField("email")
+
+	router.POST("/login", ratelimit.Middleware("login", ipLimit, ratelimit.ByIP), ratelimit.Middleware("login", accountLimit, byEmail), Login)
+	router.POST("/register", ratelimit.Middleware("register", ipLimit, ratelimit.ByIP), Register)
+
+	// 会话：refresh token 轮换换取新 access token；注销当前会话或全部设备
+	router.POST("/refresh", ratelimit.Middleware("refresh", ipLimit, ratelimit.ByIP), Refresh)
+	router.POST("/logout", RequireAuth, Logout)
+	router.POST("/logout/all", RequireAuth, LogoutAll)
+
+	// 邮箱验证与找回密码
+	router.POST("/verify-email", ratelimit.Middleware("verify_email", ipLimit, ratelimit.ByIP), VerifyEmail)
+	router.POST("/verify-email/resend", RequireAuth, ratelimit.Middleware("verify_email_resend", accountLimit, byUser), ResendVerification)
+	router.POST("/password/forgot", ratelimit.Middleware("password_forgot", ipLimit, ratelimit.ByIP), ratelimit.Middleware("password_forgot", accountLimit, byEmail), ForgotPassword)
+	router.POST("/password/reset", ratelimit.Middleware("password_reset", ipLimit, ratelimit.ByIP), ResetPassword)
+	// 修改邮箱：新邮箱收到的确认链接
+	router.POST("/email/confirm", ratelimit.Middleware("email_confirm", ipLimit, ratelimit.ByIP), ConfirmEmailChange)
+
+	// 两步验证登录：密码校验通过后用挑战 token + 验证码换取 token；验证码也可发送到绑定的 Telegram
+	router.POST("/2fa/verify", ratelimit.Middleware("2fa_verify", ipLimit, ratelimit.ByIP), VerifyTwoFactor)
+	router.POST("/2fa/telegram", ratelimit.Middleware("2fa_telegram", ipLimit, ratelimit.ByIP), SendTwoFactorTelegramCode)
 
 	// Telegram 绑定：需先登录，弹窗获取链接 -> 打开 Telegram 发送 /start -> Bot 回调 confirm，成功后发「绑定成功」消息
-	router.POST("/tg/bind/start", RequireAuth, StartTelegramBind)
+	router.POST("/tg/bind/start", RequireAuth, ratelimit.Middleware("tg_bind_start", accountLimit, byUser), StartTelegramBind)
 	router.GET("/tg/bind/status", TelegramBindStatus)
 	router.POST("/tg/bind/confirm", ConfirmTelegramBind)
+	router.DELETE("/tg/bind", RequireAuth, UnbindTelegramHandler)
+
+	// Telegram 登录：校验 Login Widget 签名，未绑定账号的 Telegram 自动创建账号
+	router.POST("/tg/login", ratelimit.Middleware("tg_login", ipLimit, ratelimit.ByIP), TelegramLogin)
 }
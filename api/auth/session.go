@@ -0,0 +1,312 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/cryptoSelect/backendapi/config"
+	"github.com/cryptoSelect/backendapi/models"
+	"github.com/cryptoSelect/backendapi/response"
+	"github.com/cryptoSelect/backendapi/utils/logger"
+	"github.com/cryptoSelect/public/database"
+	publicModels "github.com/cryptoSelect/public/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrSessionNotFound = errors.New("session not found")
+	ErrRefreshInvalid  = errors.New("refresh token invalid or expired")
+	ErrRefreshReused   = errors.New("refresh token reused")
+	ErrAccountDisabled = errors.New("account disabled")
+)
+
+// lastSeenInterval last_seen_at 的最小更新间隔，避免每个请求都写库
+const lastSeenInterval = time.Minute
+
+func accessTokenTTL() time.Duration {
+	if m := config.Cfg.Auth.AccessTokenTTLMinutes; m > 0 {
+		return time.Duration(m) * time.Minute
+	}
+	return 15 * time.Minute
+}
+
+func refreshTokenTTL() time.Duration {
+	if d := config.Cfg.Auth.RefreshTokenTTLDays; d > 0 {
+		return time.Duration(d) * 24 * time.Hour
+	}
+	return 30 * 24 * time.Hour
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// startSession 为用户创建会话，签发 access token 与 refresh token
+func startSession(c *gin.Context, user *publicModels.UserInfo) (AuthResponse, error) {
+	secret := config.Cfg.JWTSecret
+	sessionID, err := randomHex(16)
+	if err != nil {
+		return AuthResponse{}, err
+	}
+	refresh, err := randomHex(32)
+	if err != nil {
+		return AuthResponse{}, err
+	}
+	now := time.Now()
+	session := models.Session{
+		ID:               sessionID,
+		UserID:           user.ID,
+		RefreshTokenHash: hashToken(refresh),
+		UserAgent:        truncate(c.Request.UserAgent(), 512),
+		IP:               c.ClientIP(),
+		LastSeenAt:       now,
+		ExpiresAt:        now.Add(refreshTokenTTL()),
+	}
+	if err := database.DB.Create(&session).Error; err != nil {
+		return AuthResponse{}, err
+	}
+	role := UserRole(user.ID)
+	token, err := issueToken(secret, user.ID, user.Email, role, sessionID)
+	if err != nil {
+		return AuthResponse{}, err
+	}
+	return AuthResponse{
+		Token:         token,
+		RefreshToken:  refresh,
+		ExpiresIn:     int64(accessTokenTTL().Seconds()),
+		Email:         displayEmail(user),
+		TelegramBound: user.TelegramID != "",
+		EmailVerified: EmailVerified(user.ID),
+		TelegramOnly:  TelegramOnly(user.ID),
+		Role:          role,
+	}, nil
+}
+
+// rotateSession 校验 refresh token 并轮换：旧 token 立即失效；旧 token 被再次使用时视为泄露，注销整个会话
+func rotateSession(c *gin.Context, refresh string) (AuthResponse, error) {
+	now := time.Now()
+	hash := hashToken(refresh)
+	var session models.Session
+	err := database.DB.Where("refresh_token_hash = ?", hash).First(&session).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		var reused models.Session
+		if database.DB.Where("prev_refresh_hash = ? AND revoked_at IS NULL", hash).First(&reused).Error == nil {
+			_ = revokeSession(reused.UserID, reused.ID)
+			logger.Log.Warn("auth refresh token reused, session revoked", map[string]interface{}{"user_id": reused.UserID, "session_id": reused.ID, "ip": c.ClientIP()})
+			return AuthResponse{}, ErrRefreshReused
+		}
+		return AuthResponse{}, ErrRefreshInvalid
+	}
+	if err != nil {
+		return AuthResponse{}, err
+	}
+	if session.RevokedAt != nil || now.After(session.ExpiresAt) {
+		return AuthResponse{}, ErrRefreshInvalid
+	}
+
+	var user publicModels.UserInfo
+	if err := database.DB.Where("id = ?", session.UserID).First(&user).Error; err != nil {
+		return AuthResponse{}, ErrRefreshInvalid
+	}
+	if Disabled(user.ID) {
+		_ = revokeSession(user.ID, session.ID)
+		return AuthResponse{}, ErrAccountDisabled
+	}
+	next, err := randomHex(32)
+	if err != nil {
+		return AuthResponse{}, err
+	}
+	res := database.DB.Model(&models.Session{}).
+		Where("id = ? AND refresh_token_hash = ?", session.ID, hash).
+		Updates(map[string]interface{}{
+			"refresh_token_hash": hashToken(next),
+			"prev_refresh_hash":  hash,
+			"last_seen_at":       now,
+			"ip":                 c.ClientIP(),
+			"expires_at":         now.Add(refreshTokenTTL()),
+		})
+	if res.Error != nil {
+		return AuthResponse{}, res.Error
+	}
+	if res.RowsAffected == 0 {
+		// 并发刷新：另一请求已轮换
+		return AuthResponse{}, ErrRefreshInvalid
+	}
+	role := UserRole(user.ID)
+	token, err := issueToken(config.Cfg.JWTSecret, user.ID, user.Email, role, session.ID)
+	if err != nil {
+		return AuthResponse{}, err
+	}
+	return AuthResponse{
+		Token:         token,
+		RefreshToken:  next,
+		ExpiresIn:     int64(accessTokenTTL().Seconds()),
+		Email:         displayEmail(&user),
+		TelegramBound: user.TelegramID != "",
+		EmailVerified: EmailVerified(user.ID),
+		TelegramOnly:  TelegramOnly(user.ID),
+		Role:          role,
+	}, nil
+}
+
+// sessionActive 检查会话未注销且未过期，并按 lastSeenInterval 更新最近活跃时间
+func sessionActive(sessionID string, userID uint) bool {
+	if sessionID == "" {
+		return false
+	}
+	var session models.Session
+	if database.DB.Where("id = ? AND user_id = ?", sessionID, userID).First(&session).Error != nil {
+		return false
+	}
+	now := time.Now()
+	if session.RevokedAt != nil || now.After(session.ExpiresAt) {
+		return false
+	}
+	if now.Sub(session.LastSeenAt) > lastSeenInterval {
+		database.DB.Model(&models.Session{}).Where("id = ?", sessionID).Update("last_seen_at", now)
+	}
+	return true
+}
+
+// revokeSession 注销用户的某个会话
+func revokeSession(userID uint, sessionID string) error {
+	res := database.DB.Model(&models.Session{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", sessionID, userID).
+		Update("revoked_at", time.Now())
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrSessionNotFound
+	}
+	return nil
+}
+
+// RevokeUserSessions 注销用户全部会话，exceptSessionID 非空时保留该会话，返回注销数量
+func RevokeUserSessions(userID uint, exceptSessionID string) (int64, error) {
+	query := database.DB.Model(&models.Session{}).Where("user_id = ? AND revoked_at IS NULL", userID)
+	if exceptSessionID != "" {
+		query = query.Where("id <> ?", exceptSessionID)
+	}
+	res := query.Update("revoked_at", time.Now())
+	return res.RowsAffected, res.Error
+}
+
+// RevokeSession 注销用户的某个会话（供 /api/user/sessions 使用）
+func RevokeSession(userID uint, sessionID string) error {
+	return revokeSession(userID, sessionID)
+}
+
+// SessionItem 会话列表项
+type SessionItem struct {
+	ID         string    `json:"id"`
+	UserAgent  string    `json:"user_agent"`
+	IP         string    `json:"ip"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	Current    bool      `json:"current"` // 是否为发起请求的会话
+}
+
+// ListSessions 获取用户未注销且未过期的会话，按最近活跃倒序
+func ListSessions(userID uint, currentSessionID string) ([]SessionItem, error) {
+	var rows []models.Session
+	err := database.DB.Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Order("last_seen_at DESC").Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	items := make([]SessionItem, 0, len(rows))
+	for _, s := range rows {
+		items = append(items, SessionItem{
+			ID:         s.ID,
+			UserAgent:  s.UserAgent,
+			IP:         s.IP,
+			CreatedAt:  s.CreatedAt,
+			LastSeenAt: s.LastSeenAt,
+			ExpiresAt:  s.ExpiresAt,
+			Current:    s.ID == currentSessionID,
+		})
+	}
+	return items, nil
+}
+
+// deleteExpiredSessions 清理过期或已注销超过 refresh 有效期的会话
+func deleteExpiredSessions(now time.Time) (int64, error) {
+	res := database.DB.Where("expires_at <= ? OR revoked_at <= ?", now, now.Add(-refreshTokenTTL())).Delete(&models.Session{})
+	return res.RowsAffected, res.Error
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// Refresh 用 refresh token 换取新的 access token 与 refresh token（旧 refresh token 失效）
+func Refresh(c *gin.Context) {
+	var req RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Fail(c, response.CodeInvalidRequest, nil)
+		return
+	}
+	if config.Cfg.JWTSecret == "" {
+		response.ServerError(c, "jwt secret not configured", nil, nil)
+		return
+	}
+	resp, err := rotateSession(c, req.RefreshToken)
+	if errors.Is(err, ErrRefreshInvalid) || errors.Is(err, ErrRefreshReused) {
+		response.Fail(c, response.CodeInvalidRefreshToken, nil)
+		return
+	}
+	if errors.Is(err, ErrAccountDisabled) {
+		response.Fail(c, response.CodeAccountDisabled, nil)
+		return
+	}
+	if err != nil {
+		response.ServerError(c, "auth refresh failed", err, nil)
+		return
+	}
+	response.OK(c, resp)
+}
+
+// Logout 注销当前会话（需登录）
+func Logout(c *gin.Context) {
+	userID := c.MustGet(ContextUserIDKey).(uint)
+	sessionID := c.GetString(ContextSessionIDKey)
+	if err := revokeSession(userID, sessionID); err != nil && !errors.Is(err, ErrSessionNotFound) {
+		response.ServerError(c, "logout failed", err, map[string]interface{}{"user_id": userID})
+		return
+	}
+	response.OK(c, gin.H{"ok": true})
+}
+
+// LogoutAll 注销当前用户的全部会话，即"退出所有设备"（需登录）
+func LogoutAll(c *gin.Context) {
+	userID := c.MustGet(ContextUserIDKey).(uint)
+	n, err := RevokeUserSessions(userID, "")
+	if err != nil {
+		response.ServerError(c, "logout all failed", err, map[string]interface{}{"user_id": userID})
+		return
+	}
+	logger.Log.Info("auth logout all", map[string]interface{}{"user_id": userID, "revoked": n})
+	response.OK(c, gin.H{"ok": true, "revoked": n})
+}
+
+func truncate(s string, n int) string {
+	if len(s) > n {
+		return s[:n]
+	}
+	return s
+}
@@ -1,10 +1,10 @@
 package auth
 
 import (
-	"bytes"
 	"crypto/rand"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"io"
 	"net/http"
 	"strings"
@@ -12,25 +12,22 @@ import (
 	"time"
 
 	"github.com/cryptoSelect/backendapi/config"
+	"github.com/cryptoSelect/backendapi/preference"
+	"github.com/cryptoSelect/backendapi/response"
+	"github.com/cryptoSelect/backendapi/utils/i18n"
 	"github.com/cryptoSelect/backendapi/utils/logger"
+	"github.com/cryptoSelect/backendapi/utils/telegram"
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 // 说明：
 // - 用户先注册，登录后再绑定 Telegram。StartTelegramBind 需登录，token 与 user_id 关联。
 // - Bot 收到 /start 后调用 ConfirmTelegramBind，后端根据 token 找到 user_id，将 telegram_id 写入该用户。
 // - 多人同时绑定时，每人有独立 token，Bot 收到的每条 /start 对应唯一 user_id。
-// - 当前实现使用内存保存绑定状态：适合单实例/本地；多实例部署请替换为 Redis/DB。
-
-type tgBindRecord struct {
-	UserID     uint   // 发起绑定的用户 ID，用于区分不同用户
-	TelegramID string // Bot 确认后写入
-	ExpiresAt  time.Time
-}
+// - 绑定状态保存在 BindStore 中：默认内存实现，main 中替换为数据库实现以支持多实例部署；过期 token 由 RunSweeper 定期清理。
 
 var (
-	tgBindMu         sync.Mutex
-	tgBindByToken    = map[string]tgBindRecord{}
 	tgBotNameCache   string
 	tgBotNameCacheMu sync.Mutex
 )
@@ -46,7 +43,7 @@ type TgBindStartResp struct {
 func StartTelegramBind(c *gin.Context) {
 	userIDVal, ok := c.Get(ContextUserIDKey)
 	if !ok {
-		c.JSON(http.StatusOK, Response{Error: "unauthorized", Code: 401, Data: nil})
+		response.Fail(c, response.CodeUnauthorized, nil)
 		return
 	}
 	userID := userIDVal.(uint)
@@ -54,27 +51,26 @@ func StartTelegramBind(c *gin.Context) {
 	ttl := 5 * time.Minute
 	token, err := newBindToken()
 	if err != nil {
-		c.JSON(http.StatusOK, Response{Error: "failed to generate token", Code: 500, Data: nil})
+		response.ServerError(c, "tg bind token generate failed", err, map[string]interface{}{"user_id": userID})
 		return
 	}
 
-	now := time.Now()
-	tgBindMu.Lock()
-	cleanupExpiredLocked(now)
-	tgBindByToken[token] = tgBindRecord{UserID: userID, TelegramID: "", ExpiresAt: now.Add(ttl)}
-	tgBindMu.Unlock()
+	if err := bindStore.Create(BindRecord{Token: token, UserID: userID, ExpiresAt: time.Now().Add(ttl)}); err != nil {
+		response.ServerError(c, "tg bind store create failed", err, map[string]interface{}{"user_id": userID})
+		return
+	}
 
 	bot := resolveBotName()
 	startURL := ""
 	if bot != "" {
 		startURL = "https://t.me/" + bot + "?start=" + token
 	}
-	c.JSON(http.StatusOK, Response{Error: "", Code: 200, Data: TgBindStartResp{
+	response.OK(c, TgBindStartResp{
 		Token:     token,
 		ExpiresIn: int64(ttl.Seconds()),
 		BotName:   bot,
 		StartURL:  startURL,
-	}})
+	})
 }
 
 type TgBindStatusResp struct {
@@ -86,105 +82,163 @@ type TgBindStatusResp struct {
 func TelegramBindStatus(c *gin.Context) {
 	token := c.Query("token")
 	if token == "" {
-		c.JSON(http.StatusOK, Response{Error: "token required", Code: 400, Data: nil})
+		response.Fail(c, response.CodeInvalidRequest, gin.H{"param": "token", "message": "token required"})
 		return
 	}
 
-	now := time.Now()
-	tgBindMu.Lock()
-	cleanupExpiredLocked(now)
-	rec, ok := tgBindByToken[token]
-	tgBindMu.Unlock()
-
-	if !ok {
-		c.JSON(http.StatusOK, Response{Error: "token not found or expired", Code: 404, Data: TgBindStatusResp{Bound: false}})
+	rec, err := bindStore.Get(token, time.Now())
+	if errors.Is(err, ErrBindTokenNotFound) {
+		response.Fail(c, response.CodeBindTokenNotFound, TgBindStatusResp{Bound: false})
+		return
+	}
+	if err != nil {
+		response.ServerError(c, "tg bind store get failed", err, nil)
 		return
 	}
 	if rec.TelegramID == "" {
-		c.JSON(http.StatusOK, Response{Error: "", Code: 200, Data: TgBindStatusResp{Bound: false}})
+		response.OK(c, TgBindStatusResp{Bound: false})
 		return
 	}
-	c.JSON(http.StatusOK, Response{Error: "", Code: 200, Data: TgBindStatusResp{Bound: true, TelegramID: rec.TelegramID}})
+	response.OK(c, TgBindStatusResp{Bound: true, TelegramID: rec.TelegramID})
 }
 
 type TgBindConfirmReq struct {
 	Token      string `json:"token" binding:"required"`
 	TelegramID string `json:"telegram_id" binding:"required"`
+	Username   string `json:"username"`
+	FirstName  string `json:"first_name"`
 }
 
-// ConfirmTelegramBind Bot 回调：根据 token 找到 user_id，将 telegram_id 写入该用户
-func ConfirmTelegramBind(c *gin.Context) {
-	var req TgBindConfirmReq
-	if err := c.ShouldBindJSON(&req); err != nil {
-		logger.Log.Error("tg confirm invalid request", map[string]interface{}{"error": err.Error()})
-		c.JSON(http.StatusOK, Response{Error: "invalid request", Code: 400, Data: nil})
-		return
-	}
-
+// ConfirmBind 根据 token 找到 user_id，将 Telegram 账号绑定到该用户并发送「绑定成功」；
+// 该 Telegram 已绑定其他用户时返回 ErrTelegramAlreadyBound 且不消耗 token，替换旧绑定时通知旧 chat。
+// 同进程内的 tgBot 直接调用；独立部署的 Bot 通过签名的 ConfirmTelegramBind 回调
+func ConfirmBind(token string, acc TelegramAccount) error {
+	token = strings.TrimSpace(token)
+	acc.ID = strings.TrimSpace(acc.ID)
 	now := time.Now()
-	tgBindMu.Lock()
-	cleanupExpiredLocked(now)
-	rec, ok := tgBindByToken[req.Token]
-	if !ok {
-		tgBindMu.Unlock()
-		tokenPreview := req.Token
-		if len(tokenPreview) > 8 {
-			tokenPreview = tokenPreview[:8] + "..."
+	rec, err := bindStore.Get(token, now)
+	if err == nil && rec.TelegramID != "" {
+		err = ErrBindTokenUsed
+	}
+	if err == nil {
+		var bound bool
+		if bound, err = TelegramBoundToOther(rec.UserID, acc.ID); err == nil && bound {
+			err = ErrTelegramAlreadyBound
 		}
-		logger.Log.Error("tg confirm token not found", map[string]interface{}{"token": tokenPreview})
-		c.JSON(http.StatusOK, Response{Error: "token not found or expired", Code: 404, Data: nil})
-		return
+	}
+	// token 确认与用户绑定在同一事务内，绑定失败（如并发绑定同一 Telegram）时不消耗 token
+	var oldTelegramID string
+	if err == nil {
+		rec, err = bindStore.Confirm(token, acc.ID, now, func(tx *gorm.DB, rec BindRecord) error {
+			var err error
+			oldTelegramID, err = BindTelegram(tx, rec.UserID, acc)
+			return err
+		})
+	}
+	if errors.Is(err, ErrBindTokenNotFound) || errors.Is(err, ErrBindTokenUsed) || errors.Is(err, ErrTelegramAlreadyBound) {
+		logger.Log.Error("tg confirm rejected", map[string]interface{}{"token": tokenPreview(token), "telegram_id": acc.ID, "reason": err.Error()})
+		return err
+	}
+	if err != nil {
+		logger.Log.Error("tg confirm bind failed", map[string]interface{}{"token": tokenPreview(token), "error": err.Error()})
+		return err
 	}
 	userID := rec.UserID
-	rec.TelegramID = req.TelegramID
-	tgBindByToken[req.Token] = rec
-	tgBindMu.Unlock()
+	logger.Log.Info("tg confirm db updated", map[string]interface{}{"user_id": userID, "telegram_id": acc.ID, "replaced": oldTelegramID})
 
-	if err := UpdateUserTelegramID(userID, req.TelegramID); err != nil {
-		logger.Log.Error("tg confirm update user failed", map[string]interface{}{"user_id": userID, "error": err.Error()})
-		c.JSON(http.StatusOK, Response{Error: "failed to update user", Code: 500, Data: nil})
-		return
+	if oldTelegramID != "" {
+		sendTelegramMessage(oldTelegramID, i18n.T(preference.Language(userID), "bind.replaced"))
 	}
-	logger.Log.Info("tg confirm db updated", map[string]interface{}{"user_id": userID, "telegram_id": req.TelegramID})
-
 	// 向用户发送 Telegram 消息：绑定成功
-	sendTelegramMessage(req.TelegramID, "绑定成功")
-
-	c.JSON(http.StatusOK, Response{Error: "", Code: 200, Data: gin.H{"ok": true}})
+	sendTelegramMessage(acc.ID, i18n.T(preference.Language(userID), "bind.success"))
+	return nil
 }
 
-func cleanupExpiredLocked(now time.Time) {
-	for k, v := range tgBindByToken {
-		if now.After(v.ExpiresAt) {
-			delete(tgBindByToken, k)
-		}
+// UnbindTelegramChat 解除用户的 Telegram 绑定，并向原 chat 发送告别消息；未绑定时返回空 telegram_id
+func UnbindTelegramChat(userID uint) (string, error) {
+	oldTelegramID, err := UnbindTelegram(userID)
+	if errors.Is(err, ErrTelegramOnlyAccount) {
+		return "", err
 	}
+	if err != nil {
+		logger.Log.Error("tg unbind failed", map[string]interface{}{"user_id": userID, "error": err.Error()})
+		return "", err
+	}
+	if oldTelegramID != "" {
+		logger.Log.Info("tg unbind", map[string]interface{}{"user_id": userID, "telegram_id": oldTelegramID})
+		sendTelegramMessage(oldTelegramID, i18n.T(preference.Language(userID), "bind.unbound"))
+	}
+	return oldTelegramID, nil
 }
 
-// sendTelegramMessage 通过 Bot 向用户发送消息（chat_id 即 telegram_id）
-func sendTelegramMessage(chatID, text string) {
-	token := strings.TrimSpace(config.Cfg.TelegramBotToken)
-	if token == "" || strings.TrimSpace(chatID) == "" {
-		logger.Log.Error("tg sendMessage skip", map[string]interface{}{"reason": "token or chatID empty"})
+// UnbindTelegramHandler 解除当前用户的 Telegram 绑定（需登录）
+func UnbindTelegramHandler(c *gin.Context) {
+	userID := c.MustGet(ContextUserIDKey).(uint)
+	oldTelegramID, err := UnbindTelegramChat(userID)
+	if errors.Is(err, ErrTelegramOnlyAccount) {
+		response.Fail(c, response.CodeTelegramOnlyAccount, nil)
 		return
 	}
-	url := "https://api.telegram.org/bot" + token + "/sendMessage"
-	body, _ := json.Marshal(map[string]string{"chat_id": chatID, "text": text})
-	req, err := http.NewRequest("POST", url, bytes.NewReader(body))
 	if err != nil {
-		logger.Log.Error("tg sendMessage newRequest failed", map[string]interface{}{"error": err.Error()})
+		response.ServerError(c, "tg unbind failed", err, map[string]interface{}{"user_id": userID})
 		return
 	}
-	req.Header.Set("Content-Type", "application/json")
-	resp, err := http.DefaultClient.Do(req)
+	if oldTelegramID == "" {
+		response.Fail(c, response.CodeTelegramNotBound, nil)
+		return
+	}
+	response.OK(c, gin.H{"ok": true})
+}
+
+// ConfirmTelegramBind Bot 回调（需 HMAC 签名，见 VerifyBindCallback）：根据 token 找到 user_id，将 telegram_id 写入该用户
+func ConfirmTelegramBind(c *gin.Context) {
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, 4096))
 	if err != nil {
-		logger.Log.Error("tg sendMessage request failed", map[string]interface{}{"error": err.Error()})
+		response.Fail(c, response.CodeInvalidRequest, nil)
 		return
 	}
-	defer resp.Body.Close()
-	respBody, _ := io.ReadAll(resp.Body)
-	if resp.StatusCode != http.StatusOK {
-		logger.Log.Error("tg sendMessage non-200", map[string]interface{}{"status": resp.StatusCode, "body": string(respBody)})
+	if err := VerifyBindCallback(c.Request.Header, body, time.Now()); err != nil {
+		if !callbackRejected(err) {
+			response.ServerError(c, "tg confirm record nonce failed", err, nil)
+			return
+		}
+		logger.Log.Warn("tg confirm rejected unsigned callback", map[string]interface{}{"ip": c.ClientIP(), "reason": err.Error()})
+		response.Fail(c, response.CodeInvalidSignature, nil)
+		return
+	}
+	var req TgBindConfirmReq
+	if err := json.Unmarshal(body, &req); err != nil || req.Token == "" || req.TelegramID == "" {
+		logger.Log.Error("tg confirm invalid request", map[string]interface{}{"ip": c.ClientIP()})
+		response.Fail(c, response.CodeInvalidRequest, nil)
+		return
+	}
+
+	err = ConfirmBind(req.Token, TelegramAccount{ID: req.TelegramID, Username: req.Username, FirstName: req.FirstName})
+	switch {
+	case errors.Is(err, ErrBindTokenNotFound):
+		response.Fail(c, response.CodeBindTokenNotFound, nil)
+	case errors.Is(err, ErrBindTokenUsed):
+		response.Fail(c, response.CodeBindTokenUsed, nil)
+	case errors.Is(err, ErrTelegramAlreadyBound):
+		response.Fail(c, response.CodeTelegramAlreadyBound, nil)
+	case err != nil:
+		response.ServerError(c, "tg confirm bind failed", err, map[string]interface{}{"token": tokenPreview(req.Token)})
+	default:
+		response.OK(c, gin.H{"ok": true})
+	}
+}
+
+func tokenPreview(token string) string {
+	if len(token) > 8 {
+		return token[:8] + "..."
+	}
+	return token
+}
+
+// sendTelegramMessage 通过 Bot 向用户发送消息（chat_id 即 telegram_id）
+func sendTelegramMessage(chatID, text string) {
+	if err := telegram.SendMessage(chatID, text); err != nil {
+		logger.Log.Error("tg sendMessage failed", map[string]interface{}{"chat_id": chatID, "error": err.Error()})
 		return
 	}
 	logger.Log.Info("tg sendMessage success", map[string]interface{}{"chat_id": chatID})
@@ -214,8 +268,7 @@ func resolveBotName() string {
 		return tgBotNameCache
 	}
 	// 调用 Telegram getMe 获取 bot 用户名
-	url := "https://api.telegram.org/bot" + token + "/getMe"
-	resp, err := http.Get(url)
+	resp, err := http.Get(telegram.MethodURL(token, "getMe"))
 	if err != nil {
 		return ""
 	}
@@ -0,0 +1,162 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cryptoSelect/backendapi/config"
+	"github.com/cryptoSelect/backendapi/models"
+	"github.com/cryptoSelect/backendapi/response"
+	"github.com/cryptoSelect/backendapi/utils/logger"
+	"github.com/cryptoSelect/public/database"
+	publicModels "github.com/cryptoSelect/public/models"
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// telegramLoginMaxAge Login Widget 数据的最长有效期
+const telegramLoginMaxAge = 24 * time.Hour
+
+// telegramPlaceholderDomain Telegram 登录创建的账号使用的占位邮箱域名（RFC 2606 保留域名，不可投递）
+const telegramPlaceholderDomain = "telegram.invalid"
+
+var (
+	errTelegramHash    = errors.New("telegram login hash mismatch")
+	errTelegramExpired = errors.New("telegram login data expired")
+)
+
+// TelegramLoginRequest Telegram Login Widget 回调的用户数据
+type TelegramLoginRequest struct {
+	ID        int64  `json:"id" binding:"required"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+	Username  string `json:"username"`
+	PhotoURL  string `json:"photo_url"`
+	AuthDate  int64  `json:"auth_date" binding:"required"`
+	Hash      string `json:"hash" binding:"required"`
+}
+
+// verifyTelegramLogin 按官方算法校验：hash = hex(HMAC-SHA256(SHA256(bot_token), data_check_string))
+func verifyTelegramLogin(req TelegramLoginRequest, botToken string, now time.Time) error {
+	fields := map[string]string{
+		"id":        strconv.FormatInt(req.ID, 10),
+		"auth_date": strconv.FormatInt(req.AuthDate, 10),
+	}
+	for k, v := range map[string]string{"first_name": req.FirstName, "last_name": req.LastName, "username": req.Username, "photo_url": req.PhotoURL} {
+		if v != "" {
+			fields[k] = v
+		}
+	}
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	lines := make([]string, 0, len(keys))
+	for _, k := range keys {
+		lines = append(lines, k+"="+fields[k])
+	}
+	secret := sha256.Sum256([]byte(botToken))
+	mac := hmac.New(sha256.New, secret[:])
+	mac.Write([]byte(strings.Join(lines, "\n")))
+	if !hmac.Equal([]byte(hex.EncodeToString(mac.Sum(nil))), []byte(strings.ToLower(req.Hash))) {
+		return errTelegramHash
+	}
+	if now.Sub(time.Unix(req.AuthDate, 0)) > telegramLoginMaxAge {
+		return errTelegramExpired
+	}
+	return nil
+}
+
+// TelegramPlaceholderEmail Telegram 登录创建账号时的占位邮箱
+func TelegramPlaceholderEmail(telegramID string) string {
+	return "tg-" + telegramID + "@" + telegramPlaceholderDomain
+}
+
+// findOrCreateTelegramUser 按 telegram_id 查找用户，不存在时创建仅 Telegram 登录的账号
+func findOrCreateTelegramUser(acc TelegramAccount) (*publicModels.UserInfo, bool, error) {
+	var user publicModels.UserInfo
+	err := database.DB.Where("telegram_id = ?", acc.ID).First(&user).Error
+	if err == nil {
+		return &user, false, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, false, err
+	}
+	// 随机密码仅为满足非空约束，该账号无法用密码登录，直到用户设置邮箱密码
+	random, err := randomHex(32)
+	if err != nil {
+		return nil, false, err
+	}
+	hashed, err := bcrypt.GenerateFromPassword([]byte(random), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, false, err
+	}
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		user = publicModels.UserInfo{Email: TelegramPlaceholderEmail(acc.ID), Password: string(hashed), TelegramID: acc.ID}
+		if err := tx.Create(&user).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(&models.UserAccount{UserID: user.ID, TelegramOnly: true}).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(&models.TelegramProfile{UserID: user.ID, TelegramID: acc.ID, Username: acc.Username, FirstName: acc.FirstName, BoundAt: time.Now()}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.TelegramBindLog{UserID: user.ID, TelegramID: acc.ID, Username: acc.Username, Action: models.TelegramBindActionBind}).Error
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return &user, true, nil
+}
+
+// TelegramLogin 使用 Telegram Login Widget 数据登录；该 Telegram 未绑定任何账号时自动创建账号，签发与邮箱登录相同的 token
+func TelegramLogin(c *gin.Context) {
+	var req TelegramLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Fail(c, response.CodeInvalidRequest, nil)
+		return
+	}
+	botToken := strings.TrimSpace(config.Cfg.TelegramBotToken)
+	if botToken == "" || config.Cfg.JWTSecret == "" {
+		response.ServerError(c, "tg login not configured: TelegramBotToken or JWTSecret missing", nil, nil)
+		return
+	}
+	if err := verifyTelegramLogin(req, botToken, time.Now()); err != nil {
+		logger.Log.Warn("tg login rejected", map[string]interface{}{"telegram_id": req.ID, "ip": c.ClientIP(), "reason": err.Error()})
+		response.Fail(c, response.CodeInvalidTelegramLogin, nil)
+		return
+	}
+	acc := TelegramAccount{ID: fmt.Sprintf("%d", req.ID), Username: req.Username, FirstName: req.FirstName}
+	user, created, err := findOrCreateTelegramUser(acc)
+	if err != nil {
+		response.ServerError(c, "tg login find or create user failed", err, map[string]interface{}{"telegram_id": acc.ID})
+		return
+	}
+	if created {
+		logger.Log.Info("tg login created user", map[string]interface{}{"user_id": user.ID, "telegram_id": acc.ID})
+	}
+	if Disabled(user.ID) {
+		response.Fail(c, response.CodeAccountDisabled, nil)
+		return
+	}
+	// 开启两步验证时返回登录挑战，否则直接签发 token
+	completeLogin(c, user)
+}
+
+// displayEmail 返回给前端的邮箱，Telegram 登录创建账号的占位邮箱不对外展示
+func displayEmail(user *publicModels.UserInfo) string {
+	if strings.HasSuffix(user.Email, "@"+telegramPlaceholderDomain) {
+		return ""
+	}
+	return user.Email
+}
@@ -0,0 +1,411 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/cryptoSelect/backendapi/models"
+	"github.com/cryptoSelect/backendapi/preference"
+	"github.com/cryptoSelect/backendapi/ratelimit"
+	"github.com/cryptoSelect/backendapi/response"
+	"github.com/cryptoSelect/backendapi/utils/i18n"
+	"github.com/cryptoSelect/backendapi/utils/logger"
+	"github.com/cryptoSelect/backendapi/utils/totp"
+	"github.com/cryptoSelect/public/database"
+	publicModels "github.com/cryptoSelect/public/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	totpIssuer           = "CryptoSelect"
+	challengeTTL         = 5 * time.Minute
+	maxChallengeAttempts = 5
+	recoveryCodeCount    = 10
+	telegramCodeInterval = time.Minute // Telegram 验证码最短重发间隔
+)
+
+// 两步验证方式
+const (
+	TwoFactorMethodTOTP     = "totp"
+	TwoFactorMethodRecovery = "recovery"
+	TwoFactorMethodTelegram = "telegram"
+)
+
+var (
+	ErrTwoFactorNotEnabled     = errors.New("two factor not enabled")
+	ErrTwoFactorAlreadyEnabled = errors.New("two factor already enabled")
+	ErrTwoFactorNotSetup       = errors.New("two factor not set up")
+	ErrInvalidCode             = errors.New("invalid two factor code")
+	ErrChallengeInvalid        = errors.New("login challenge invalid or expired")
+	ErrTelegramCodeTooSoon     = errors.New("telegram code requested too soon")
+)
+
+// TwoFactorChallenge 开启两步验证的账号密码校验通过后返回，需携带 challenge_token 与验证码调用 /api/auth/2fa/verify
+type TwoFactorChallenge struct {
+	TwoFactorRequired bool     `json:"two_factor_required"`
+	ChallengeToken    string   `json:"challenge_token"`
+	ExpiresIn         int64    `json:"expires_in"` // 秒
+	Methods           []string `json:"methods"`    // 可用的验证方式：totp / recovery / telegram
+}
+
+// TwoFactorInfo 两步验证状态
+type TwoFactorInfo struct {
+	Enabled           bool  `json:"enabled"`
+	RecoveryCodesLeft int64 `json:"recovery_codes_left"`
+}
+
+func loadTwoFactor(userID uint) (*models.TwoFactor, error) {
+	var tf models.TwoFactor
+	err := database.DB.Where("user_id = ?", userID).First(&tf).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrTwoFactorNotSetup
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &tf, nil
+}
+
+// TwoFactorEnabled 用户是否已启用两步验证
+func TwoFactorEnabled(userID uint) bool {
+	tf, err := loadTwoFactor(userID)
+	return err == nil && tf.EnabledAt != nil
+}
+
+// TwoFactorStatus 两步验证状态与剩余恢复码数量
+func TwoFactorStatus(userID uint) (TwoFactorInfo, error) {
+	info := TwoFactorInfo{Enabled: TwoFactorEnabled(userID)}
+	if !info.Enabled {
+		return info, nil
+	}
+	err := database.DB.Model(&models.RecoveryCode{}).Where("user_id = ? AND used_at IS NULL", userID).Count(&info.RecoveryCodesLeft).Error
+	return info, err
+}
+
+// SetupTwoFactor 生成新的 TOTP 密钥（未确认前不生效），返回密钥与 otpauth URI
+func SetupTwoFactor(userID uint) (string, string, error) {
+	if TwoFactorEnabled(userID) {
+		return "", "", ErrTwoFactorAlreadyEnabled
+	}
+	user, err := GetUserByID(userID)
+	if err != nil {
+		return "", "", err
+	}
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return "", "", err
+	}
+	tf := models.TwoFactor{UserID: userID}
+	if err := database.DB.Where(models.TwoFactor{UserID: userID}).
+		Assign(map[string]interface{}{"secret": secret, "last_used_step": 0, "enabled_at": nil}).
+		FirstOrCreate(&tf).Error; err != nil {
+		return "", "", err
+	}
+	account := displayEmail(user)
+	if account == "" {
+		account = fmt.Sprintf("user%d", userID)
+	}
+	return secret, totp.URI(totpIssuer, account, secret), nil
+}
+
+// EnableTwoFactor 用首个验证码确认密钥并启用两步验证，返回恢复码（仅此一次）
+func EnableTwoFactor(userID uint, code string) ([]string, error) {
+	tf, err := loadTwoFactor(userID)
+	if err != nil {
+		return nil, err
+	}
+	if tf.EnabledAt != nil {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+	if !checkTOTP(tf, code) {
+		return nil, ErrInvalidCode
+	}
+	if err := database.DB.Model(&models.TwoFactor{}).Where("id = ?", tf.ID).Update("enabled_at", time.Now()).Error; err != nil {
+		return nil, err
+	}
+	return generateRecoveryCodes(userID)
+}
+
+// DisableTwoFactor 使用验证码或恢复码关闭两步验证
+func DisableTwoFactor(userID uint, code string) error {
+	if err := verifySecondFactor(userID, code); err != nil {
+		return err
+	}
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&models.TwoFactor{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error
+	})
+}
+
+// RegenerateRecoveryCodes 使用验证码重新生成恢复码，旧恢复码全部作废
+func RegenerateRecoveryCodes(userID uint, code string) ([]string, error) {
+	tf, err := loadTwoFactor(userID)
+	if err != nil || tf.EnabledAt == nil {
+		return nil, ErrTwoFactorNotEnabled
+	}
+	if !checkTOTP(tf, code) {
+		return nil, ErrInvalidCode
+	}
+	return generateRecoveryCodes(userID)
+}
+
+// verifySecondFactor 校验已启用两步验证用户的 TOTP 验证码或恢复码
+func verifySecondFactor(userID uint, code string) error {
+	tf, err := loadTwoFactor(userID)
+	if err != nil || tf.EnabledAt == nil {
+		return ErrTwoFactorNotEnabled
+	}
+	if checkTOTP(tf, code) || useRecoveryCode(userID, code) {
+		return nil
+	}
+	return ErrInvalidCode
+}
+
+// checkTOTP 校验 TOTP 验证码，允许前后一个步长误差；同一时间步的验证码只能使用一次
+func checkTOTP(tf *models.TwoFactor, code string) bool {
+	step, ok := totp.Validate(tf.Secret, code, time.Now(), 1)
+	if !ok || step <= tf.LastUsedStep {
+		return false
+	}
+	res := database.DB.Model(&models.TwoFactor{}).
+		Where("id = ? AND last_used_step < ?", tf.ID, step).
+		Update("last_used_step", step)
+	return res.Error == nil && res.RowsAffected == 1
+}
+
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+}
+
+// generateRecoveryCodes 生成新的恢复码并替换旧的，格式 xxxxx-xxxxx
+func generateRecoveryCodes(userID uint) ([]string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	rows := make([]models.RecoveryCode, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		raw, err := randomHex(5)
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, raw[:5]+"-"+raw[5:])
+		rows = append(rows, models.RecoveryCode{UserID: userID, CodeHash: hashToken(raw)})
+	}
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&rows).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// useRecoveryCode 使用一个恢复码，成功后立即失效
+func useRecoveryCode(userID uint, code string) bool {
+	code = normalizeRecoveryCode(code)
+	if code == "" {
+		return false
+	}
+	res := database.DB.Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, hashToken(code)).
+		Update("used_at", time.Now())
+	return res.Error == nil && res.RowsAffected == 1
+}
+
+// completeLogin 第一步认证通过后：未开启两步验证直接签发 token，否则返回登录挑战
+func completeLogin(c *gin.Context, user *publicModels.UserInfo) {
+	if !TwoFactorEnabled(user.ID) {
+		resp, err := startSession(c, user)
+		if err != nil {
+			response.ServerError(c, "start session failed", err, map[string]interface{}{"user_id": user.ID})
+			return
+		}
+		response.OK(c, resp)
+		return
+	}
+	raw, err := randomHex(32)
+	if err == nil {
+		err = database.DB.Create(&models.LoginChallenge{TokenHash: hashToken(raw), UserID: user.ID, ExpiresAt: time.Now().Add(challengeTTL)}).Error
+	}
+	if err != nil {
+		response.ServerError(c, "create login challenge failed", err, map[string]interface{}{"user_id": user.ID})
+		return
+	}
+	methods := []string{TwoFactorMethodTOTP, TwoFactorMethodRecovery}
+	if strings.TrimSpace(user.TelegramID) != "" {
+		methods = append(methods, TwoFactorMethodTelegram)
+	}
+	response.OK(c, TwoFactorChallenge{
+		TwoFactorRequired: true,
+		ChallengeToken:    raw,
+		ExpiresIn:         int64(challengeTTL.Seconds()),
+		Methods:           methods,
+	})
+}
+
+func loadChallenge(raw string, now time.Time) (*models.LoginChallenge, error) {
+	var ch models.LoginChallenge
+	err := database.DB.Where("token_hash = ? AND used_at IS NULL AND expires_at > ? AND attempts < ?", hashToken(raw), now, maxChallengeAttempts).First(&ch).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrChallengeInvalid
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &ch, nil
+}
+
+// claimChallengeAttempt 原子地占用一次校验机会，并发请求不能超出 maxChallengeAttempts；挑战已使用、过期或次数用尽时返回 ErrChallengeInvalid
+func claimChallengeAttempt(ch *models.LoginChallenge, now time.Time) error {
+	res := database.DB.Model(&models.LoginChallenge{}).
+		Where("token_hash = ? AND used_at IS NULL AND expires_at > ? AND attempts < ?", ch.TokenHash, now, maxChallengeAttempts).
+		Update("attempts", gorm.Expr("attempts + 1"))
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected != 1 {
+		return ErrChallengeInvalid
+	}
+	return nil
+}
+
+// checkChallengeCode 按 method 校验登录挑战的验证码
+func checkChallengeCode(ch *models.LoginChallenge, method, code string) bool {
+	switch method {
+	case TwoFactorMethodRecovery:
+		return useRecoveryCode(ch.UserID, code)
+	case TwoFactorMethodTelegram:
+		return ch.TelegramCodeHash != "" && subtle.ConstantTimeCompare([]byte(hashToken(strings.TrimSpace(code))), []byte(ch.TelegramCodeHash)) == 1
+	default:
+		tf, err := loadTwoFactor(ch.UserID)
+		return err == nil && tf.EnabledAt != nil && checkTOTP(tf, code)
+	}
+}
+
+// deleteExpiredChallenges 删除已过期的登录挑战，由 RunMaintenance 调用
+func deleteExpiredChallenges(now time.Time) (int64, error) {
+	res := database.DB.Where("expires_at < ?", now).Delete(&models.LoginChallenge{})
+	return res.RowsAffected, res.Error
+}
+
+type TwoFactorVerifyRequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	Code           string `json:"code" binding:"required"`
+	Method         string `json:"method"` // totp（默认）/ recovery / telegram
+}
+
+type ChallengeRequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+}
+
+// VerifyTwoFactor 登录第二步：用挑战 token 与验证码换取正式 token；失败次数过多时挑战作废，
+// 验证码错误同样计入账号的登录失败次数，与密码错误共用锁定
+func VerifyTwoFactor(c *gin.Context) {
+	var req TwoFactorVerifyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Fail(c, response.CodeInvalidRequest, nil)
+		return
+	}
+	now := time.Now()
+	ch, err := loadChallenge(req.ChallengeToken, now)
+	if errors.Is(err, ErrChallengeInvalid) {
+		response.Fail(c, response.CodeInvalidChallenge, nil)
+		return
+	}
+	if err != nil {
+		response.ServerError(c, "load login challenge failed", err, nil)
+		return
+	}
+	user, err := GetUserByID(ch.UserID)
+	if err != nil {
+		response.ServerError(c, "load challenge user failed", err, map[string]interface{}{"user_id": ch.UserID})
+		return
+	}
+	lockKey := ratelimit.LockKey("login", user.Email)
+	if locked, retryAfter := ratelimit.Locked(lockKey); locked {
+		response.Fail(c, response.CodeAccountLocked, gin.H{"retry_after": retryAfter})
+		return
+	}
+	// 先占用一次校验机会再比对验证码，并发请求无法绕过次数上限
+	err = claimChallengeAttempt(ch, now)
+	if errors.Is(err, ErrChallengeInvalid) {
+		response.Fail(c, response.CodeInvalidChallenge, nil)
+		return
+	}
+	if err != nil {
+		response.ServerError(c, "claim login challenge attempt failed", err, map[string]interface{}{"user_id": ch.UserID})
+		return
+	}
+	if !checkChallengeCode(ch, req.Method, req.Code) {
+		ratelimit.Fail(lockKey, c.ClientIP())
+		logger.Log.Warn("two factor code rejected", map[string]interface{}{"user_id": ch.UserID, "method": req.Method, "ip": c.ClientIP(), "attempts": ch.Attempts + 1})
+		response.Fail(c, response.CodeInvalidCode, nil)
+		return
+	}
+	res := database.DB.Model(&models.LoginChallenge{}).Where("token_hash = ? AND used_at IS NULL", ch.TokenHash).Update("used_at", now)
+	if res.Error != nil || res.RowsAffected == 0 {
+		response.Fail(c, response.CodeInvalidChallenge, nil)
+		return
+	}
+	ratelimit.Reset(lockKey)
+	if Disabled(user.ID) {
+		response.Fail(c, response.CodeAccountDisabled, nil)
+		return
+	}
+	resp, err := startSession(c, user)
+	if err != nil {
+		response.ServerError(c, "start session failed", err, map[string]interface{}{"user_id": user.ID})
+		return
+	}
+	response.OK(c, resp)
+}
+
+// SendTwoFactorTelegramCode 将登录验证码发送到用户绑定的 Telegram，替代验证器 App
+func SendTwoFactorTelegramCode(c *gin.Context) {
+	var req ChallengeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Fail(c, response.CodeInvalidRequest, nil)
+		return
+	}
+	now := time.Now()
+	ch, err := loadChallenge(req.ChallengeToken, now)
+	if errors.Is(err, ErrChallengeInvalid) {
+		response.Fail(c, response.CodeInvalidChallenge, nil)
+		return
+	}
+	if err != nil {
+		response.ServerError(c, "load login challenge failed", err, nil)
+		return
+	}
+	if ch.TelegramSentAt != nil && now.Sub(*ch.TelegramSentAt) < telegramCodeInterval {
+		response.Fail(c, response.CodeTooManyRequests, gin.H{"retry_after": int((telegramCodeInterval - now.Sub(*ch.TelegramSentAt)).Seconds()) + 1})
+		return
+	}
+	telegramID := GetUserTelegramID(ch.UserID)
+	if telegramID == "" {
+		response.Fail(c, response.CodeTelegramNotBound, nil)
+		return
+	}
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		response.ServerError(c, "generate telegram code failed", err, nil)
+		return
+	}
+	code := fmt.Sprintf("%06d", n.Int64())
+	if err := database.DB.Model(&models.LoginChallenge{}).Where("token_hash = ?", ch.TokenHash).
+		Updates(map[string]interface{}{"telegram_code_hash": hashToken(code), "telegram_sent_at": now}).Error; err != nil {
+		response.ServerError(c, "save telegram code failed", err, map[string]interface{}{"user_id": ch.UserID})
+		return
+	}
+	sendTelegramMessage(telegramID, i18n.T(preference.Language(ch.UserID), "bind.login_code", code))
+	response.OK(c, gin.H{"ok": true})
+}
@@ -3,16 +3,10 @@ package funds
 import (
 	"strings"
 
+	"github.com/cryptoSelect/backendapi/response"
 	"github.com/gin-gonic/gin"
 )
 
-// Response 统一响应结构
-type Response struct {
-	Error string      `json:"error"`
-	Code  int         `json:"code"`
-	Data  interface{} `json:"data"`
-}
-
 // HandleTradeInflowQuery 查询资金流向数据
 func HandleTradeInflowQuery(c *gin.Context) {
 	// 获取 Query 参数
@@ -20,11 +14,7 @@ func HandleTradeInflowQuery(c *gin.Context) {
 
 	// symbol参数为必传
 	if symbol == "" {
-		c.JSON(400, Response{
-			Error: "symbol parameter is required",
-			Code:  400,
-			Data:  ListData{Data: []map[string]interface{}{}, Count: 0},
-		})
+		response.FailStatus(c, response.CodeInvalidRequest, gin.H{"param": "symbol", "message": "symbol parameter is required"})
 		return
 	}
 
@@ -38,22 +28,9 @@ func HandleTradeInflowQuery(c *gin.Context) {
 
 	dataList, err := GetTradeInflowRecord(c, symbol)
 	if err != nil {
-		c.JSON(200, Response{
-			Error: err.Error(),
-			Code:  500,
-			Data:  ListData{Data: []map[string]interface{}{}, Count: 0}, // 返回空数组而不是nil
-		})
+		response.ServerError(c, "funds query failed", err, map[string]interface{}{"symbol": symbol})
 		return
 	}
 
-	// 确保即使没有数据也返回空数组
-	if dataList.Data == nil {
-		dataList.Data = []map[string]interface{}{}
-	}
-
-	c.JSON(200, Response{
-		Error: "",
-		Code:  200,
-		Data:  dataList,
-	})
+	response.OK(c, dataList)
 }
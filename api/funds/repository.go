@@ -1,6 +1,7 @@
 package funds
 
 import (
+	"github.com/cryptoSelect/backendapi/utils/sanitize"
 	"github.com/cryptoSelect/public/database"
 	publicModels "github.com/cryptoSelect/public/models"
 	"github.com/gin-gonic/gin"
@@ -8,8 +9,54 @@ import (
 
 // ListData 分页数据结构
 type ListData struct {
-	Data  []map[string]interface{} `json:"data"`
-	Count int                      `json:"count"`
+	Data  []TradeInflowItem `json:"data"`
+	Count int               `json:"count"`
+}
+
+// TradeInflowItem 资金流向记录，浮点数 NaN/Inf 置为 0
+type TradeInflowItem struct {
+	ID                        uint    `json:"id"`
+	Symbol                    string  `json:"symbol"`
+	TimeParticleEnum          int     `json:"time_particle_enum"`
+	Time                      string  `json:"time"`
+	Stop                      bool    `json:"stop"`
+	StopTradeInflow           float64 `json:"stop_trade_inflow"`
+	StopTradeAmount           float64 `json:"stop_trade_amount"`
+	StopTradeInflowChange     float64 `json:"stop_trade_inflow_change"`
+	StopTradeAmountChange     float64 `json:"stop_trade_amount_change"`
+	Contract                  bool    `json:"contract"`
+	ContractTradeInflow       float64 `json:"contract_trade_inflow"`
+	ContractTradeAmount       float64 `json:"contract_trade_amount"`
+	ContractTradeInflowChange float64 `json:"contract_trade_inflow_change"`
+	ContractTradeAmountChange float64 `json:"contract_trade_amount_change"`
+	StopTradeIn               float64 `json:"stop_trade_in"`
+	StopTradeOut              float64 `json:"stop_trade_out"`
+	ContractTradeIn           float64 `json:"contract_trade_in"`
+	ContractTradeOut          float64 `json:"contract_trade_out"`
+}
+
+// NewTradeInflowItem 记录转为接口返回格式
+func NewTradeInflowItem(r *publicModels.CoinTradeInflowDto) TradeInflowItem {
+	return TradeInflowItem{
+		ID:                        r.ID,
+		Symbol:                    r.Symbol,
+		TimeParticleEnum:          r.TimeParticleEnum,
+		Time:                      r.Time,
+		Stop:                      r.Stop,
+		StopTradeInflow:           sanitize.Float(r.StopTradeInflow),
+		StopTradeAmount:           sanitize.Float(r.StopTradeAmount),
+		StopTradeInflowChange:     sanitize.Float(r.StopTradeInflowChange),
+		StopTradeAmountChange:     sanitize.Float(r.StopTradeAmountChange),
+		Contract:                  r.Contract,
+		ContractTradeInflow:       sanitize.Float(r.ContractTradeInflow),
+		ContractTradeAmount:       sanitize.Float(r.ContractTradeAmount),
+		ContractTradeInflowChange: sanitize.Float(r.ContractTradeInflowChange),
+		ContractTradeAmountChange: sanitize.Float(r.ContractTradeAmountChange),
+		StopTradeIn:               sanitize.Float(r.StopTradeIn),
+		StopTradeOut:              sanitize.Float(r.StopTradeOut),
+		ContractTradeIn:           sanitize.Float(r.ContractTradeIn),
+		ContractTradeOut:          sanitize.Float(r.ContractTradeOut),
+	}
 }
 
 // GetTradeInflowRecord 查询资金流向记录
@@ -30,32 +77,13 @@ func GetTradeInflowRecord(c *gin.Context, symbol string) (data ListData, err err
 		return ListData{}, err
 	}
 
-	formatted := make([]map[string]interface{}, len(records))
-	for i, r := range records {
-		formatted[i] = map[string]interface{}{
-			"id":                           r.ID,
-			"symbol":                       r.Symbol,
-			"time_particle_enum":           r.TimeParticleEnum,
-			"time":                         r.Time,
-			"stop":                         r.Stop,
-			"stop_trade_inflow":            r.StopTradeInflow,
-			"stop_trade_amount":            r.StopTradeAmount,
-			"stop_trade_inflow_change":     r.StopTradeInflowChange,
-			"stop_trade_amount_change":     r.StopTradeAmountChange,
-			"contract":                     r.Contract,
-			"contract_trade_inflow":        r.ContractTradeInflow,
-			"contract_trade_amount":        r.ContractTradeAmount,
-			"contract_trade_inflow_change": r.ContractTradeInflowChange,
-			"contract_trade_amount_change": r.ContractTradeAmountChange,
-			"stop_trade_in":                r.StopTradeIn,
-			"stop_trade_out":               r.StopTradeOut,
-			"contract_trade_in":            r.ContractTradeIn,
-			"contract_trade_out":           r.ContractTradeOut,
-		}
+	items := make([]TradeInflowItem, len(records))
+	for i := range records {
+		items[i] = NewTradeInflowItem(&records[i])
 	}
 
 	return ListData{
-		Data:  formatted,
-		Count: len(formatted),
+		Data:  items,
+		Count: len(items),
 	}, nil
 }
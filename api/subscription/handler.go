@@ -1,37 +1,41 @@
 package subscription
 
 import (
-	"net/http"
 	"strings"
 
 	"github.com/cryptoSelect/backendapi/api/auth"
+	"github.com/cryptoSelect/backendapi/response"
 	"github.com/gin-gonic/gin"
 )
 
-type Response struct {
-	Error string      `json:"error"`
-	Code  int         `json:"code"`
-	Data  interface{} `json:"data"`
-}
-
 type CreateRequest struct {
 	Symbol string   `json:"symbol" binding:"required"`
 	Cycles []string `json:"cycles" binding:"required"` // 支持一次选择多个周期
+	Rules  []string `json:"rules"`                     // 可选，告警规则，如 "rsi >= 70"，应用到每个周期
 }
 
-// Create 创建订阅（需登录），symbol+cycles 与当前 user_id 写入 subscription 表，支持多周期
+// Create 创建订阅（需登录），symbol+cycles 与当前 user_id 写入 subscription 表，支持多周期；可同时附带规则
 func Create(c *gin.Context) {
 	userID, _ := c.Get(auth.ContextUserIDKey)
 	uid := userID.(uint)
 
 	var req CreateRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusOK, Response{Error: "symbol and cycles required", Code: 400, Data: nil})
+		response.Fail(c, response.CodeInvalidRequest, gin.H{"message": "symbol and cycles required"})
 		return
 	}
 	symbol := strings.TrimSpace(strings.ToUpper(req.Symbol))
 	if symbol == "" || len(req.Cycles) == 0 {
-		c.JSON(http.StatusOK, Response{Error: "symbol and at least one cycle required", Code: 400, Data: nil})
+		response.Fail(c, response.CodeInvalidRequest, gin.H{"message": "symbol and at least one cycle required"})
+		return
+	}
+	exprs, err := parseRules(req.Rules)
+	if err != nil {
+		response.Fail(c, response.CodeInvalidRule, gin.H{"message": err.Error()})
+		return
+	}
+	if len(exprs) > MaxRulesPerSubscription {
+		response.Fail(c, response.CodeTooManyRules, gin.H{"max": MaxRulesPerSubscription})
 		return
 	}
 	seen := make(map[string]bool)
@@ -41,15 +45,22 @@ func Create(c *gin.Context) {
 			continue
 		}
 		seen[cycle] = true
-		if err := CreateSubscription(uid, symbol, cycle); err != nil {
-			c.JSON(http.StatusOK, Response{Error: err.Error(), Code: 500, Data: nil})
+		sub, err := CreateSubscription(uid, symbol, cycle)
+		if err != nil {
+			response.ServerError(c, "create subscription failed", err, map[string]interface{}{"user_id": uid, "symbol": symbol, "cycle": cycle})
 			return
 		}
+		for _, expr := range exprs {
+			if _, err := AddRule(uid, sub.ID, expr); err != nil {
+				writeRuleError(c, err)
+				return
+			}
+		}
 	}
-	c.JSON(http.StatusOK, Response{Error: "", Code: 200, Data: gin.H{"ok": true}})
+	response.OK(c, gin.H{"ok": true})
 }
 
-// List 获取当前用户订阅（需登录），返回 [{symbol, cycle}, ...]。可选 query symbol 筛选指定代币
+// List 获取当前用户订阅（需登录），返回 [{id, symbol, cycle, rules}, ...]。可选 query symbol 筛选指定代币
 func List(c *gin.Context) {
 	userID, _ := c.Get(auth.ContextUserIDKey)
 	uid := userID.(uint)
@@ -62,25 +73,25 @@ func List(c *gin.Context) {
 		items, err = ListByUserID(uid)
 	}
 	if err != nil {
-		c.JSON(http.StatusOK, Response{Error: err.Error(), Code: 500, Data: nil})
+		response.ServerError(c, "list subscriptions failed", err, map[string]interface{}{"user_id": uid})
 		return
 	}
-	c.JSON(http.StatusOK, Response{Error: "", Code: 200, Data: gin.H{"data": items}})
+	response.OK(c, gin.H{"data": items})
 }
 
-// Delete 删除当前用户的某条订阅（需登录）
+// Delete 删除当前用户的某条订阅及其规则（需登录）
 func Delete(c *gin.Context) {
 	userID, _ := c.Get(auth.ContextUserIDKey)
 	uid := userID.(uint)
 	symbol := strings.TrimSpace(strings.ToUpper(c.Query("symbol")))
 	cycle := strings.TrimSpace(c.Query("cycle"))
 	if symbol == "" || cycle == "" {
-		c.JSON(http.StatusOK, Response{Error: "symbol and cycle required", Code: 400, Data: nil})
+		response.Fail(c, response.CodeInvalidRequest, gin.H{"message": "symbol and cycle required"})
 		return
 	}
 	if err := DeleteByUserIDSymbolCycle(uid, symbol, cycle); err != nil {
-		c.JSON(http.StatusOK, Response{Error: err.Error(), Code: 500, Data: nil})
+		response.ServerError(c, "delete subscription failed", err, map[string]interface{}{"user_id": uid, "symbol": symbol, "cycle": cycle})
 		return
 	}
-	c.JSON(http.StatusOK, Response{Error: "", Code: 200, Data: gin.H{"ok": true}})
+	response.OK(c, gin.H{"ok": true})
 }
@@ -1,24 +1,63 @@
 package subscription
 
 import (
+	"errors"
+
+	"github.com/cryptoSelect/backendapi/models"
+	"github.com/cryptoSelect/backendapi/utils/dberr"
 	"github.com/cryptoSelect/public/database"
 	publicModels "github.com/cryptoSelect/public/models"
+	"gorm.io/gorm"
+)
+
+// MaxRulesPerSubscription 单条订阅最多可配置的规则数
+const MaxRulesPerSubscription = 10
+
+var (
+	ErrSubscriptionNotFound = errors.New("subscription not found")
+	ErrRuleNotFound         = errors.New("rule not found")
+	ErrTooManyRules         = errors.New("too many rules for subscription")
+	ErrDuplicateRule        = errors.New("rule already exists for subscription")
 )
 
-func CreateSubscription(userID uint, symbol, cycle string) error {
+func CreateSubscription(userID uint, symbol, cycle string) (*publicModels.Subscription, error) {
 	sub := publicModels.Subscription{
 		Symbol: symbol,
 		Cycle:  cycle,
 		UserID: userID,
 	}
 	// FirstOrCreate 避免重复订阅
-	return database.DB.Where(publicModels.Subscription{UserID: userID, Symbol: symbol, Cycle: cycle}).FirstOrCreate(&sub).Error
+	if err := database.DB.Where(publicModels.Subscription{UserID: userID, Symbol: symbol, Cycle: cycle}).FirstOrCreate(&sub).Error; err != nil {
+		return nil, err
+	}
+	return &sub, nil
+}
+
+// GetSubscription 查询用户的某条订阅，不存在时返回 ErrSubscriptionNotFound
+func GetSubscription(userID uint, symbol, cycle string) (*publicModels.Subscription, error) {
+	var sub publicModels.Subscription
+	err := database.DB.Where("user_id = ? AND symbol = ? AND cycle = ?", userID, symbol, cycle).First(&sub).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrSubscriptionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &sub, nil
 }
 
 // SubItem 订阅项
 type SubItem struct {
-	Symbol string `json:"symbol"`
-	Cycle  string `json:"cycle"`
+	ID     uint       `json:"id"`
+	Symbol string     `json:"symbol"`
+	Cycle  string     `json:"cycle"`
+	Rules  []RuleItem `json:"rules"`
+}
+
+// RuleItem 订阅规则
+type RuleItem struct {
+	ID   uint   `json:"id"`
+	Rule string `json:"rule"`
 }
 
 // ListByUserID 获取用户所有订阅（symbol+cycle）
@@ -40,16 +79,157 @@ func listByUserID(userID uint, symbol string) ([]SubItem, error) {
 	if err := query.Find(&subs).Error; err != nil {
 		return nil, err
 	}
+	ids := make([]uint, 0, len(subs))
+	for _, s := range subs {
+		ids = append(ids, s.ID)
+	}
+	rules, err := rulesBySubscription(userID, ids)
+	if err != nil {
+		return nil, err
+	}
 	items := make([]SubItem, 0, len(subs))
 	for _, s := range subs {
 		if s.Symbol != "" && s.Cycle != "" {
-			items = append(items, SubItem{Symbol: s.Symbol, Cycle: s.Cycle})
+			r := rules[s.ID]
+			if r == nil {
+				r = []RuleItem{}
+			}
+			items = append(items, SubItem{ID: s.ID, Symbol: s.Symbol, Cycle: s.Cycle, Rules: r})
 		}
 	}
 	return items, nil
 }
 
-// DeleteByUserIDSymbolCycle 删除用户的某条订阅
+// DeleteByUserIDSymbolCycle 删除用户的某条订阅及其规则
 func DeleteByUserIDSymbolCycle(userID uint, symbol, cycle string) error {
-	return database.DB.Where("user_id = ? AND symbol = ? AND cycle = ?", userID, symbol, cycle).Delete(&publicModels.Subscription{}).Error
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		var subs []publicModels.Subscription
+		if err := tx.Where("user_id = ? AND symbol = ? AND cycle = ?", userID, symbol, cycle).Find(&subs).Error; err != nil {
+			return err
+		}
+		for _, s := range subs {
+			if err := tx.Where("subscription_id = ?", s.ID).Delete(&models.SubscriptionRule{}).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Where("user_id = ? AND symbol = ? AND cycle = ?", userID, symbol, cycle).Delete(&publicModels.Subscription{}).Error
+	})
+}
+
+// DeleteByID 按 ID 删除任意用户的订阅及其规则（管理员使用），返回被删除的订阅
+func DeleteByID(id uint) (*publicModels.Subscription, error) {
+	var sub publicModels.Subscription
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("id = ?", id).First(&sub).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrSubscriptionNotFound
+			}
+			return err
+		}
+		if err := tx.Where("subscription_id = ?", id).Delete(&models.SubscriptionRule{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&publicModels.Subscription{}, id).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &sub, nil
+}
+
+func rulesBySubscription(userID uint, subIDs []uint) (map[uint][]RuleItem, error) {
+	out := make(map[uint][]RuleItem)
+	if len(subIDs) == 0 {
+		return out, nil
+	}
+	var rows []models.SubscriptionRule
+	if err := database.DB.Where("user_id = ? AND subscription_id IN ?", userID, subIDs).Order("id").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, r := range rows {
+		out[r.SubscriptionID] = append(out[r.SubscriptionID], RuleItem{ID: r.ID, Rule: r.Expr})
+	}
+	return out, nil
+}
+
+// ListRules 获取某条订阅的全部规则
+func ListRules(userID, subscriptionID uint) ([]RuleItem, error) {
+	rules, err := rulesBySubscription(userID, []uint{subscriptionID})
+	if err != nil {
+		return nil, err
+	}
+	if rules[subscriptionID] == nil {
+		return []RuleItem{}, nil
+	}
+	return rules[subscriptionID], nil
+}
+
+// findRule 按表达式查询订阅下的规则，不存在时返回 nil
+func findRule(subscriptionID uint, expr string) (*RuleItem, error) {
+	var existing models.SubscriptionRule
+	err := database.DB.Where("subscription_id = ? AND expr = ?", subscriptionID, expr).First(&existing).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &RuleItem{ID: existing.ID, Rule: existing.Expr}, nil
+}
+
+// AddRule 为订阅添加规则（expr 需已规范化），相同表达式不重复添加，返回已有的规则
+func AddRule(userID, subscriptionID uint, expr string) (*RuleItem, error) {
+	if item, err := findRule(subscriptionID, expr); item != nil || err != nil {
+		return item, err
+	}
+	var count int64
+	if err := database.DB.Model(&models.SubscriptionRule{}).Where("subscription_id = ?", subscriptionID).Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count >= MaxRulesPerSubscription {
+		return nil, ErrTooManyRules
+	}
+	row := models.SubscriptionRule{SubscriptionID: subscriptionID, UserID: userID, Expr: expr}
+	if err := database.DB.Create(&row).Error; err != nil {
+		// 并发添加了相同表达式，由唯一索引拦下
+		if dberr.IsUniqueViolation(err) {
+			return findRule(subscriptionID, expr)
+		}
+		return nil, err
+	}
+	return &RuleItem{ID: row.ID, Rule: row.Expr}, nil
+}
+
+// UpdateRule 修改用户的某条规则；同一订阅下已有相同表达式时返回 ErrDuplicateRule
+func UpdateRule(userID, ruleID uint, expr string) error {
+	res := database.DB.Model(&models.SubscriptionRule{}).Where("id = ? AND user_id = ?", ruleID, userID).Update("expr", expr)
+	if dberr.IsUniqueViolation(res.Error) {
+		return ErrDuplicateRule
+	}
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrRuleNotFound
+	}
+	return nil
+}
+
+// DedupeRules 删除同一订阅下表达式重复的规则（保留最早的一条），供建立唯一索引前调用
+func DedupeRules() (int64, error) {
+	res := database.DB.Exec("DELETE FROM subscription_rule a USING subscription_rule b " +
+		"WHERE a.subscription_id = b.subscription_id AND a.expr = b.expr AND a.id > b.id")
+	return res.RowsAffected, res.Error
+}
+
+// DeleteRule 删除用户的某条规则
+func DeleteRule(userID, ruleID uint) error {
+	res := database.DB.Where("id = ? AND user_id = ?", ruleID, userID).Delete(&models.SubscriptionRule{})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrRuleNotFound
+	}
+	return nil
 }
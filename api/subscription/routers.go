@@ -4,8 +4,16 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func SetupSubscriptionRoutes(router *gin.RouterGroup, requireAuth gin.HandlerFunc) {
+// SetupSubscriptionRoutes requireVerified 限制未验证邮箱的账号创建订阅
+func SetupSubscriptionRoutes(router *gin.RouterGroup, requireAuth, requireVerified gin.HandlerFunc) {
 	router.GET("/", requireAuth, List)
-	router.POST("/", requireAuth, Create)
+	router.POST("/", requireAuth, requireVerified, Create)
 	router.DELETE("/", requireAuth, Delete)
+
+	// 订阅规则：按 symbol+cycle 定位订阅，单条规则按 id 修改/删除
+	router.GET("/rules", requireAuth, RuleList)
+	router.POST("/rules", requireAuth, requireVerified, RuleCreate)
+	router.POST("/rules/validate", requireAuth, RuleValidate)
+	router.PUT("/rules/:id", requireAuth, RuleUpdate)
+	router.DELETE("/rules/:id", requireAuth, RuleDelete)
 }
@@ -0,0 +1,161 @@
+package subscription
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/cryptoSelect/backendapi/api/auth"
+	"github.com/cryptoSelect/backendapi/response"
+	"github.com/cryptoSelect/backendapi/rule"
+	"github.com/gin-gonic/gin"
+)
+
+type RuleRequest struct {
+	Symbol string `json:"symbol"`
+	Cycle  string `json:"cycle"`
+	Rule   string `json:"rule" binding:"required"`
+}
+
+// parseRules 校验并规范化规则表达式，去重后返回
+func parseRules(raw []string) ([]string, error) {
+	seen := make(map[string]bool)
+	out := make([]string, 0, len(raw))
+	for _, expr := range raw {
+		r, err := rule.Parse(expr)
+		if err != nil {
+			return nil, err
+		}
+		norm := r.String()
+		if seen[norm] {
+			continue
+		}
+		seen[norm] = true
+		out = append(out, norm)
+	}
+	return out, nil
+}
+
+// RuleValidate 校验规则表达式，返回规范化结果（需登录）
+func RuleValidate(c *gin.Context) {
+	var req RuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Fail(c, response.CodeInvalidRequest, gin.H{"message": "rule required"})
+		return
+	}
+	exprs, err := parseRules([]string{req.Rule})
+	if err != nil {
+		response.Fail(c, response.CodeInvalidRule, gin.H{"message": err.Error()})
+		return
+	}
+	response.OK(c, gin.H{"rule": exprs[0]})
+}
+
+// RuleList 获取某条订阅（query symbol+cycle）的规则（需登录）
+func RuleList(c *gin.Context) {
+	uid := c.MustGet(auth.ContextUserIDKey).(uint)
+	symbol := strings.TrimSpace(strings.ToUpper(c.Query("symbol")))
+	cycle := strings.TrimSpace(c.Query("cycle"))
+	if symbol == "" || cycle == "" {
+		response.Fail(c, response.CodeInvalidRequest, gin.H{"message": "symbol and cycle required"})
+		return
+	}
+	sub, err := GetSubscription(uid, symbol, cycle)
+	if err != nil {
+		writeRuleError(c, err)
+		return
+	}
+	items, err := ListRules(uid, sub.ID)
+	if err != nil {
+		response.ServerError(c, "list rules failed", err, map[string]interface{}{"subscription_id": sub.ID})
+		return
+	}
+	response.OK(c, gin.H{"data": items})
+}
+
+// RuleCreate 为已存在的订阅添加一条规则（需登录）
+func RuleCreate(c *gin.Context) {
+	uid := c.MustGet(auth.ContextUserIDKey).(uint)
+	var req RuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Fail(c, response.CodeInvalidRequest, gin.H{"message": "symbol, cycle and rule required"})
+		return
+	}
+	symbol := strings.TrimSpace(strings.ToUpper(req.Symbol))
+	cycle := strings.TrimSpace(req.Cycle)
+	if symbol == "" || cycle == "" {
+		response.Fail(c, response.CodeInvalidRequest, gin.H{"message": "symbol and cycle required"})
+		return
+	}
+	exprs, err := parseRules([]string{req.Rule})
+	if err != nil {
+		response.Fail(c, response.CodeInvalidRule, gin.H{"message": err.Error()})
+		return
+	}
+	sub, err := GetSubscription(uid, symbol, cycle)
+	if err != nil {
+		writeRuleError(c, err)
+		return
+	}
+	item, err := AddRule(uid, sub.ID, exprs[0])
+	if err != nil {
+		writeRuleError(c, err)
+		return
+	}
+	response.OK(c, item)
+}
+
+// RuleUpdate 修改某条规则的表达式（需登录）
+func RuleUpdate(c *gin.Context) {
+	uid := c.MustGet(auth.ContextUserIDKey).(uint)
+	ruleID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Fail(c, response.CodeInvalidID, nil)
+		return
+	}
+	var req RuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Fail(c, response.CodeInvalidRequest, gin.H{"message": "rule required"})
+		return
+	}
+	exprs, err := parseRules([]string{req.Rule})
+	if err != nil {
+		response.Fail(c, response.CodeInvalidRule, gin.H{"message": err.Error()})
+		return
+	}
+	if err := UpdateRule(uid, uint(ruleID), exprs[0]); err != nil {
+		writeRuleError(c, err)
+		return
+	}
+	response.OK(c, RuleItem{ID: uint(ruleID), Rule: exprs[0]})
+}
+
+// RuleDelete 删除某条规则（需登录）
+func RuleDelete(c *gin.Context) {
+	uid := c.MustGet(auth.ContextUserIDKey).(uint)
+	ruleID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Fail(c, response.CodeInvalidID, nil)
+		return
+	}
+	if err := DeleteRule(uid, uint(ruleID)); err != nil {
+		writeRuleError(c, err)
+		return
+	}
+	response.OK(c, gin.H{"ok": true})
+}
+
+func writeRuleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrSubscriptionNotFound):
+		response.Fail(c, response.CodeSubscriptionNotFound, nil)
+	case errors.Is(err, ErrRuleNotFound):
+		response.Fail(c, response.CodeRuleNotFound, nil)
+	case errors.Is(err, ErrDuplicateRule):
+		response.Fail(c, response.CodeDuplicateRule, nil)
+	case errors.Is(err, ErrTooManyRules):
+		response.Fail(c, response.CodeTooManyRules, gin.H{"max": MaxRulesPerSubscription})
+	default:
+		response.ServerError(c, "rule operation failed", err, nil)
+	}
+}
@@ -0,0 +1,329 @@
+package symbol
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/cryptoSelect/backendapi/config"
+	"github.com/cryptoSelect/backendapi/preference"
+	"github.com/cryptoSelect/backendapi/response"
+	"github.com/cryptoSelect/backendapi/screener"
+	"github.com/cryptoSelect/public/database"
+	publicModels "github.com/cryptoSelect/public/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// maxConfluencePageSize 共振筛选每页最多返回的币种数
+const maxConfluencePageSize = 100
+
+// ConfluenceQuery 多周期共振筛选条件
+type ConfluenceQuery struct {
+	Symbol   string                      // 可选，按名称过滤（规则同列表接口）
+	Cycles   []string                    // 参与共振的周期，按周期顺序
+	Filter   *screener.Filter            // 所有周期共用的条件
+	When     map[string]*screener.Filter // 各周期单独的条件
+	Same     *screener.Field             // 要求各周期该字段取值相同
+	MinMatch int                         // 至少满足条件的周期数
+	OrderBy  string                      // score（默认）或 symbol
+	Desc     bool
+	Page     int
+	PageSize int
+}
+
+// ConfluenceItem 一个币种的共振结果，Cycles 为各周期的记录并排展示
+type ConfluenceItem struct {
+	Symbol    string                     `json:"symbol"`
+	Score     int                        `json:"score"`                // 满足条件（且取值一致）的周期数
+	Matched   []string                   `json:"matched"`              // 满足条件的周期
+	SameValue interface{}                `json:"same_value,omitempty"` // 指定 same 时各周期一致的取值
+	Cycles    map[string]ConfluenceCycle `json:"cycles"`
+}
+
+// ConfluenceCycle 某个周期的记录及是否满足条件
+type ConfluenceCycle struct {
+	SymbolItem
+	Matched bool `json:"matched"`
+}
+
+// ConfluenceData 共振筛选结果
+type ConfluenceData struct {
+	Cycles []string         `json:"cycles"`
+	Data   []ConfluenceItem `json:"data"`
+	Count  int              `json:"count"`
+}
+
+// HandleConfluence 多周期共振筛选：cycles=1h,4h,1d；filter 对所有周期生效，when[15m]=rsi<30 只对该周期生效；
+// same=cross_type 要求各周期取值相同；min_match 默认为全部周期；order_by=score|symbol
+func HandleConfluence(c *gin.Context) {
+	var q ConfluenceQuery
+	for _, cy := range strings.Split(c.Query("cycles"), ",") {
+		if cy = strings.TrimSpace(cy); cy == "" {
+			continue
+		}
+		if !preference.ValidCycle(cy) {
+			response.Fail(c, response.CodeInvalidCycle, gin.H{"cycle": cy})
+			return
+		}
+		if !containsString(q.Cycles, cy) {
+			q.Cycles = append(q.Cycles, cy)
+		}
+	}
+	if len(q.Cycles) < 2 {
+		response.Fail(c, response.CodeInvalidCycles, gin.H{"message": "at least two cycles are required"})
+		return
+	}
+	sort.SliceStable(q.Cycles, func(i, j int) bool { return cycleRank(q.Cycles[i]) < cycleRank(q.Cycles[j]) })
+
+	var err error
+	if q.Filter, err = screener.Parse(c.Query("filter")); err != nil {
+		writeFilterError(c, "filter", err)
+		return
+	}
+	q.When = make(map[string]*screener.Filter)
+	for cy, expr := range c.QueryMap("when") {
+		if !containsString(q.Cycles, cy) {
+			response.Fail(c, response.CodeInvalidCycle, gin.H{"cycle": cy, "message": "when[] cycle is not in cycles"})
+			return
+		}
+		if q.When[cy], err = screener.Parse(expr); err != nil {
+			writeFilterError(c, "when["+cy+"]", err)
+			return
+		}
+	}
+	if name := c.Query("same"); name != "" {
+		field, ok := screener.LookupField(name)
+		if !ok {
+			response.Fail(c, response.CodeInvalidField, gin.H{"field": name})
+			return
+		}
+		q.Same = &field
+	}
+	q.MinMatch = len(q.Cycles)
+	if s := c.Query("min_match"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 || n > len(q.Cycles) {
+			response.Fail(c, response.CodeInvalidMinMatch, gin.H{"min": 1, "max": len(q.Cycles)})
+			return
+		}
+		q.MinMatch = n
+	}
+	q.Symbol = strings.ToUpper(strings.TrimSpace(c.Query("symbol")))
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if page <= 0 {
+		page = 1
+	}
+	pageSize, _ := strconv.Atoi(c.Query("page_size"))
+	if pageSize <= 0 {
+		pageSize = config.Cfg.Page.PageSize
+	}
+	if pageSize > maxConfluencePageSize {
+		pageSize = maxConfluencePageSize
+	}
+
+	q.Page, q.PageSize = page, pageSize
+	q.OrderBy = strings.ToLower(c.DefaultQuery("order_by", "score"))
+	q.Desc = strings.ToLower(c.DefaultQuery("order_type", "desc")) != "asc"
+
+	items, total, err := FindConfluence(q)
+	if err != nil {
+		response.ServerError(c, "confluence query failed", err, map[string]interface{}{"cycles": q.Cycles})
+		return
+	}
+	response.OK(c, ConfluenceData{Cycles: q.Cycles, Data: items, Count: int(total)})
+}
+
+// writeFilterError 筛选表达式错误，param 为出错的参数名；始终返回 HTTP 400，与引入统一响应前一致
+func writeFilterError(c *gin.Context, param string, err error) {
+	var syntaxErr *screener.SyntaxError
+	errors.As(err, &syntaxErr)
+	response.FailStatus(c, response.CodeInvalidFilter, gin.H{"param": param, "position": syntaxErr.Pos, "message": syntaxErr.Message})
+}
+
+// likeEscaper 转义 ILIKE 的通配符，使用户输入的 % 和 _ 按字面匹配
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// symbolScope 按名称过滤：USDT 结尾精确匹配，否则模糊匹配
+func symbolScope(symbol string) (string, interface{}) {
+	if len(symbol) >= 4 && strings.ToUpper(symbol[len(symbol)-4:]) == "USDT" {
+		return "symbol = ?", strings.ToUpper(symbol)
+	}
+	return "symbol ILIKE ?", "%" + likeEscaper.Replace(symbol) + "%"
+}
+
+// confluenceMatch 记录满足所在周期条件的 SQL：各周期 cycle = ? AND filter AND when[cycle] 取 OR，没有任何条件的周期视为全部满足
+func confluenceMatch(q ConfluenceQuery) (string, []interface{}) {
+	terms := make([]string, 0, len(q.Cycles))
+	var args []interface{}
+	for _, cy := range q.Cycles {
+		term := "cycle = ?"
+		args = append(args, cy)
+		for _, f := range []*screener.Filter{q.Filter, q.When[cy]} {
+			if f != nil {
+				sql, fargs := f.SQL()
+				term += " AND (" + sql + ")"
+				args = append(args, fargs...)
+			}
+		}
+		terms = append(terms, "("+term+")")
+	}
+	return "(" + strings.Join(terms, " OR ") + ")", args
+}
+
+// confluenceScores 按币种分组统计满足条件的周期数（score）；指定 same 时先按该字段取值分组，取记录最多的一组
+func confluenceScores(q ConfluenceQuery) *gorm.DB {
+	match, args := confluenceMatch(q)
+	query := database.DB.Model(&publicModels.SymbolRecord{}).Where("cycle IN ?", q.Cycles)
+	if q.Symbol != "" {
+		cond, arg := symbolScope(q.Symbol)
+		query = query.Where(cond, arg)
+	}
+	if q.Same == nil {
+		return query.Select("symbol, COUNT(*) FILTER (WHERE "+match+") AS score", args...).Group("symbol")
+	}
+	// NaN 不与任何值相同，与 largestSameGroup 一致
+	if q.Same.Kind == screener.Float {
+		match += " AND " + q.Same.Column + " <> 'NaN'"
+	}
+	groups := query.Select("symbol, COUNT(*) FILTER (WHERE "+match+") AS n", args...).Group("symbol, " + q.Same.Column)
+	return database.DB.Table("(?) AS g", groups).Select("symbol, MAX(n) AS score").Group("symbol")
+}
+
+// confluenceOrder order_by=score 时分数相同按币种升序
+func confluenceOrder(q ConfluenceQuery) string {
+	dir := "ASC"
+	if q.Desc {
+		dir = "DESC"
+	}
+	if q.OrderBy == "symbol" {
+		return "symbol " + dir
+	}
+	return "score " + dir + ", symbol ASC"
+}
+
+// FindConfluence 在数据库中按币种统计满足条件的周期数，对 score >= MinMatch 的币种排序分页，
+// 再取出当前页币种的各周期记录；返回当前页结果与总数
+func FindConfluence(q ConfluenceQuery) ([]ConfluenceItem, int64, error) {
+	scored := func() *gorm.DB {
+		return database.DB.Table("(?) AS s", confluenceScores(q)).Where("score >= ?", q.MinMatch)
+	}
+	var total int64
+	if err := scored().Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var page []struct {
+		Symbol string
+		Score  int
+	}
+	err := scored().Select("symbol, score").Order(confluenceOrder(q)).
+		Offset((q.Page - 1) * q.PageSize).Limit(q.PageSize).Scan(&page).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	items := make([]ConfluenceItem, 0, len(page))
+	if len(page) == 0 {
+		return items, total, nil
+	}
+
+	symbols := make([]string, 0, len(page))
+	for _, p := range page {
+		symbols = append(symbols, p.Symbol)
+	}
+	var records []publicModels.SymbolRecord
+	if err := database.DB.Where("symbol IN ? AND cycle IN ?", symbols, q.Cycles).Find(&records).Error; err != nil {
+		return nil, 0, err
+	}
+	match, args := confluenceMatch(q)
+	var ids []uint
+	err = database.DB.Model(&publicModels.SymbolRecord{}).Where("symbol IN ?", symbols).Where(match, args...).Pluck("id", &ids).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	matched := make(map[uint]bool, len(ids))
+	for _, id := range ids {
+		matched[id] = true
+	}
+
+	bySymbol := make(map[string]map[string]*publicModels.SymbolRecord)
+	for i := range records {
+		r := &records[i]
+		if bySymbol[r.Symbol] == nil {
+			bySymbol[r.Symbol] = make(map[string]*publicModels.SymbolRecord)
+		}
+		bySymbol[r.Symbol][r.Cycle] = r
+	}
+
+	// 保持 SQL 的排序；分数以数据库统计为准，Matched 与 SameValue 由当前页记录算出
+	for _, p := range page {
+		rows := bySymbol[p.Symbol]
+		var hits []*publicModels.SymbolRecord
+		for _, cy := range q.Cycles {
+			if r := rows[cy]; r != nil && matched[r.ID] {
+				hits = append(hits, r)
+			}
+		}
+		var sameValue interface{}
+		if q.Same != nil {
+			hits, sameValue = largestSameGroup(*q.Same, hits)
+		}
+		item := ConfluenceItem{
+			Symbol:    p.Symbol,
+			Score:     p.Score,
+			Matched:   make([]string, 0, len(hits)),
+			SameValue: sameValue,
+			Cycles:    make(map[string]ConfluenceCycle, len(rows)),
+		}
+		for _, r := range hits {
+			item.Matched = append(item.Matched, r.Cycle)
+		}
+		for cy, r := range rows {
+			item.Cycles[cy] = ConfluenceCycle{SymbolItem: NewSymbolItem(r), Matched: containsString(item.Matched, cy)}
+		}
+		items = append(items, item)
+	}
+	return items, total, nil
+}
+
+// largestSameGroup 按字段取值分组，返回记录最多的一组（数量相同时取短周期先出现的一组）；NaN 不与任何值相同
+func largestSameGroup(field screener.Field, hits []*publicModels.SymbolRecord) ([]*publicModels.SymbolRecord, interface{}) {
+	var best []*publicModels.SymbolRecord
+	var bestValue interface{}
+	for i, r := range hits {
+		v := field.Get(r)
+		if v != v {
+			continue
+		}
+		group := []*publicModels.SymbolRecord{r}
+		for _, other := range hits[i+1:] {
+			if field.Get(other) == v {
+				group = append(group, other)
+			}
+		}
+		if len(group) > len(best) {
+			best, bestValue = group, v
+		}
+	}
+	return best, bestValue
+}
+
+// cycleRank 周期的排序位置，与 cycleOrderSQL 一致
+func cycleRank(cycle string) int {
+	for i, cy := range preference.Cycles {
+		if cy == cycle {
+			return i
+		}
+	}
+	return len(preference.Cycles)
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
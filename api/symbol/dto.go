@@ -0,0 +1,73 @@
+package symbol
+
+import (
+	"time"
+
+	"github.com/cryptoSelect/backendapi/screener"
+	"github.com/cryptoSelect/backendapi/utils/sanitize"
+	publicModels "github.com/cryptoSelect/public/models"
+)
+
+// SymbolItem 标的列表默认返回的字段，JSON 字段名与 screener.Fields 一致（注册表中 Extra 的字段需通过 fields 参数获取）
+type SymbolItem struct {
+	ID             uint      `json:"id"`
+	Symbol         string    `json:"symbol"`
+	Cycle          string    `json:"cycle"`
+	Shape          int       `json:"shape"`
+	Rsi            float64   `json:"rsi"`
+	CrossType      int       `json:"cross_type"`
+	CrossTime      time.Time `json:"cross_time"`
+	Price          float64   `json:"price"`
+	Volume         float64   `json:"volume"`
+	TakerBuyVolume float64   `json:"taker_buy_volume"`
+	TakerBuyRatio  float64   `json:"taker_buy_ratio"`
+	Rate           float64   `json:"rate"`
+	RateCycle      int       `json:"rate_cycle"`
+	Change         float64   `json:"change"`
+	UpdatedAt      time.Time `json:"updated_at"`
+	VpSignal       string    `json:"vp_signal"`
+	SMCSignal      string    `json:"smc_signal"`
+	Fvg            string    `json:"fvg"`
+	Ob             string    `json:"ob"`
+}
+
+// NewSymbolItem 记录转为接口返回格式，NaN/Inf 置为 0
+func NewSymbolItem(r *publicModels.SymbolRecord) SymbolItem {
+	return SymbolItem{
+		ID:             r.ID,
+		Symbol:         r.Symbol,
+		Cycle:          r.Cycle,
+		Shape:          r.Shape,
+		Rsi:            sanitize.Float(r.Rsi),
+		CrossType:      r.CrossType,
+		CrossTime:      r.CrossTime,
+		Price:          sanitize.Float(r.Price),
+		Volume:         sanitize.Float(r.Volume),
+		TakerBuyVolume: sanitize.Float(r.TakerBuyVolume),
+		TakerBuyRatio:  sanitize.Float(r.TakerBuyRatio),
+		Rate:           sanitize.Float(r.Rate),
+		RateCycle:      r.RateCycle,
+		Change:         sanitize.Float(r.Change),
+		UpdatedAt:      r.UpdatedAt,
+		VpSignal:       r.VpSignal,
+		SMCSignal:      r.SMCSignal,
+		Fvg:            r.Fvg,
+		Ob:             r.Ob,
+	}
+}
+
+// buildItems 未指定字段时返回 []SymbolItem，否则按所选字段返回 []screener.Row
+func buildItems(records []publicModels.SymbolRecord, fields []screener.Field) interface{} {
+	if len(fields) == 0 {
+		items := make([]SymbolItem, len(records))
+		for i := range records {
+			items[i] = NewSymbolItem(&records[i])
+		}
+		return items
+	}
+	rows := make([]screener.Row, len(records))
+	for i := range records {
+		rows[i] = screener.Row{Fields: fields, Record: &records[i]}
+	}
+	return rows
+}
@@ -1,103 +1,132 @@
 package symbol
 
 import (
+	"errors"
 	"strconv"
 	"strings"
 
+	"github.com/cryptoSelect/backendapi/api/auth"
 	"github.com/cryptoSelect/backendapi/config"
+	"github.com/cryptoSelect/backendapi/preference"
+	"github.com/cryptoSelect/backendapi/response"
+	"github.com/cryptoSelect/backendapi/screener"
 	"github.com/gin-gonic/gin"
 )
 
-// 通用响应结构
-type Response struct {
-	Error string      `json:"error"`
-	Code  int         `json:"code"`
-	Data  interface{} `json:"data"`
-}
-
 // 列表数据负载
 type ListData struct {
-	Data  []map[string]interface{} `json:"data"`
-	Count int                      `json:"count"`
+	Data       interface{} `json:"data"`                  // []SymbolItem，指定 fields 时为所选字段
+	Count      *int64      `json:"count,omitempty"`       // count=false 时不返回
+	NextCursor string      `json:"next_cursor,omitempty"` // 游标分页时的下一页游标，为空表示没有更多
 }
 
+// maxPageSize page_size 参数上限
+const maxPageSize = 100
+
+// HandleSymbolQuery 标的列表。分页默认按 page 偏移；传 cursor 参数（第一页为空值）时使用游标分页，顺序稳定且深翻页不变慢。
+// page_size 指定每页条数（上限 maxPageSize），count=false 时不统计总数
 func HandleSymbolQuery(c *gin.Context) {
 	// 获取 Query 参数
-	symbol := c.Query("symbol")
-	cycle := c.Query("cycle")
+	q := ListQuery{
+		Symbol: c.Query("symbol"),
+		Cycle:  c.Query("cycle"),
+	}
 	shapeStr := c.Query("shape")
 	rsiStr := c.Query("rsi")
 	crossTypeStr := c.Query("cross_type")
 
+	// filter 表达式，如 rsi between 30 and 40 and cycle in (1h,4h)，与上面的固定参数同时生效
+	filter, err := screener.Parse(c.Query("filter"))
+	if err != nil {
+		writeFilterError(c, "filter", err)
+		return
+	}
+	q.Filter = filter
+
+	// fields=symbol,cycle,rsi 只返回所选字段，可减小响应体积
+	if spec := c.Query("fields"); spec != "" {
+		if q.Fields, err = screener.ParseFields(spec); err != nil {
+			var syntaxErr *screener.SyntaxError
+			errors.As(err, &syntaxErr)
+			response.Fail(c, response.CodeInvalidFields, gin.H{"position": syntaxErr.Pos, "message": syntaxErr.Message})
+			return
+		}
+	}
+
 	// 转换参数
-	var shape int
 	if shapeStr != "" {
-		shape, _ = strconv.Atoi(shapeStr)
+		q.Shape, _ = strconv.Atoi(shapeStr)
 	}
-
-	var rsi float64
-	rsiProvided := false
 	if rsiStr != "" {
-		rsi, _ = strconv.ParseFloat(rsiStr, 64)
-		rsiProvided = true
+		q.Rsi, _ = strconv.ParseFloat(rsiStr, 64)
+		q.RsiProvided = true
 	}
-
-	var crossType int
 	if crossTypeStr != "" {
-		crossType, _ = strconv.Atoi(crossTypeStr)
+		q.CrossType, _ = strconv.Atoi(crossTypeStr)
 	}
 
 	// 解析分页参数
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	if page <= 0 {
-		page = 1
+	q.Page, _ = strconv.Atoi(c.DefaultQuery("page", "1"))
+	if q.Page <= 0 {
+		q.Page = 1
 	}
-	pageSize := config.Cfg.Page.PageSize // 用户要求每页10条
-
-	// 解析排序参数
-	orderBy := strings.ToLower(c.DefaultQuery("order_by", "updated_at"))
-	orderType := strings.ToLower(c.DefaultQuery("order_type", "desc"))
-
-	// 校验和映射排序字段，防止 SQL 注入
-	allowedOrders := map[string]string{
-		"updated_at": "updated_at",
-		"rsi":        "rsi",
-		"change":     "change",
-		"symbol":     "symbol",
+	q.PageSize = config.Cfg.Page.PageSize // 用户要求每页10条
+
+	// 已登录（或 API key）用户按偏好的每页条数；未指定周期时只看偏好的默认周期
+	if userID, ok := c.Get(auth.ContextUserIDKey); ok {
+		prefs := preference.Get(userID.(uint))
+		if prefs.PageSize > 0 {
+			q.PageSize = prefs.PageSize
+		}
+		if q.Cycle == "" {
+			q.Cycles = prefs.DefaultCycles
+		}
 	}
-
-	if field, ok := allowedOrders[orderBy]; ok {
-		orderBy = field
-	} else {
-		orderBy = "updated_at"
+	if s := c.Query("page_size"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 || n > maxPageSize {
+			response.Fail(c, response.CodeInvalidPageSize, gin.H{"min": 1, "max": maxPageSize})
+			return
+		}
+		q.PageSize = n
 	}
-
-	if orderType != "asc" && orderType != "desc" {
-		orderType = "desc"
+	q.Cursor, q.CursorMode = c.GetQuery("cursor")
+	q.SkipCount = c.Query("count") == "false"
+
+	// 解析排序参数：sort=-taker_buy_ratio,rsi 多字段排序；兼容旧的 order_by + order_type（单字段，无效字段按 updated_at）。
+	// 字段来自 screener 字段注册表，防止 SQL 注入；nulls=first 时 NaN/NULL 排在最前，默认最后
+	nanFirst := strings.ToLower(c.Query("nulls")) == "first"
+	if spec := c.Query("sort"); spec != "" {
+		keys, err := screener.ParseSort(spec, nanFirst)
+		if err != nil {
+			var syntaxErr *screener.SyntaxError
+			errors.As(err, &syntaxErr)
+			response.Fail(c, response.CodeInvalidSort, gin.H{"position": syntaxErr.Pos, "message": syntaxErr.Message})
+			return
+		}
+		q.Order = keys
+	} else if orderBy := c.Query("order_by"); orderBy != "" {
+		field, ok := screener.LookupField(orderBy)
+		if !ok || field.NoSort {
+			field, _ = screener.LookupField("updated_at")
+		}
+		desc := strings.ToLower(c.DefaultQuery("order_type", "desc")) != "asc"
+		q.Order = []screener.OrderKey{{Field: field, Desc: desc, NaNFirst: nanFirst}}
 	}
 
-	if symbol != "" {
-		symbol = strings.ToUpper(strings.TrimSpace(symbol))
+	if q.Symbol != "" {
+		q.Symbol = strings.ToUpper(strings.TrimSpace(q.Symbol))
 	}
 
-	dataList, err := GetSymbolRecord(c, symbol, cycle, shape, rsi, rsiProvided, crossType, page, pageSize, orderBy, orderType)
-	if err != nil {
-		c.JSON(200, Response{
-			Error: err.Error(),
-			Code:  500,
-			Data:  ListData{Data: []map[string]interface{}{}, Count: 0}, // 返回空数组而不是nil
-		})
+	dataList, err := GetSymbolRecord(c, q)
+	if errors.Is(err, screener.ErrInvalidCursor) {
+		response.Fail(c, response.CodeInvalidCursor, nil)
 		return
 	}
-
-	// 确保即使没有数据也返回空数组
-	if dataList.Data == nil {
-		dataList.Data = []map[string]interface{}{}
+	if err != nil {
+		response.ServerError(c, "symbol query failed", err, nil)
+		return
 	}
 
-	c.JSON(200, Response{
-		Error: "",
-		Code:  200,
-		Data:  dataList,
-	})
+	response.OK(c, dataList)
 }
@@ -1,10 +1,7 @@
 package symbol
 
 import (
-	"fmt"
-	"math"
-	"strings"
-
+	"github.com/cryptoSelect/backendapi/screener"
 	"github.com/cryptoSelect/public/database"
 	publicModels "github.com/cryptoSelect/public/models"
 	"github.com/gin-gonic/gin"
@@ -13,113 +10,123 @@ import (
 // 周期排序顺序：币种不为空且未选周期时，按此顺序排序
 const cycleOrderSQL = "CASE cycle WHEN '5m' THEN 1 WHEN '15m' THEN 2 WHEN '30m' THEN 3 WHEN '1h' THEN 4 WHEN '4h' THEN 5 WHEN '1d' THEN 6 WHEN '1w' THEN 7 WHEN '1M' THEN 8 ELSE 99 END"
 
+// ListQuery 标的列表查询条件
+type ListQuery struct {
+	Symbol      string
+	Cycle       string
+	Cycles      []string // 未指定 Cycle 时按偏好的默认周期过滤
+	Shape       int
+	Rsi         float64
+	RsiProvided bool
+	CrossType   int
+	Filter      *screener.Filter
+	PageSize    int
+	Order       []screener.OrderKey // 为空时使用默认排序
+	Fields      []screener.Field    // 返回的字段，为空时返回 SymbolItem
+
+	// 分页：CursorMode 为 false 时按 Page 偏移分页（兼容旧版），否则从 Cursor（为空表示第一页）之后取下一页
+	Page       int
+	CursorMode bool
+	Cursor     string
+	SkipCount  bool // 不统计总数，省去 COUNT(*)
+}
+
 // 查询多条记录并分页
-func GetSymbolRecord(c *gin.Context, symbol string, cycle string, shape int, rsi float64, rsiProvided bool, crossType int, page int, pageSize int, orderBy, orderType string) (data ListData, err error) {
+func GetSymbolRecord(c *gin.Context, q ListQuery) (data ListData, err error) {
 	var records []publicModels.SymbolRecord
-	var total int64
 
 	query := database.DB.Model(&publicModels.SymbolRecord{})
 
 	// 添加过滤条件
-	if symbol != "" {
-		// 检查symbol后4位转大写是否为USDT
-		if len(symbol) >= 4 && strings.ToUpper(symbol[len(symbol)-4:]) == "USDT" {
-			// 如果是USDT结尾，按精确名称查询，获取所有周期
-			query = query.Where("symbol = ?", strings.ToUpper(symbol))
-		} else {
-			// 否则按模糊查询
-			query = query.Where("symbol ILIKE ?", "%"+symbol+"%")
-		}
+	if q.Symbol != "" {
+		// USDT 结尾按精确名称查询（获取所有周期），否则模糊查询
+		cond, arg := symbolScope(q.Symbol)
+		query = query.Where(cond, arg)
 	}
-	if cycle != "" {
+	if q.Cycle != "" {
 		// 如果指定了周期，仍然按周期过滤
-		query = query.Where("cycle = ?", cycle)
+		query = query.Where("cycle = ?", q.Cycle)
+	} else if len(q.Cycles) > 0 {
+		query = query.Where("cycle IN ?", q.Cycles)
 	}
-	if shape > 0 {
-		query = query.Where("shape = ?", shape)
+	if q.Shape > 0 {
+		query = query.Where("shape = ?", q.Shape)
 	}
 	// RSI 规则：
 	// - 仅当用户传了 rsi 参数时才过滤
 	// - rsi >= 50：查询 rsi >= 参数
 	// - rsi < 50：查询 rsi < 参数
-	if rsiProvided {
-		if rsi >= 50 {
-			query = query.Where("rsi >= ?", rsi)
+	if q.RsiProvided {
+		if q.Rsi >= 50 {
+			query = query.Where("rsi >= ?", q.Rsi)
 		} else {
-			query = query.Where("rsi < ?", rsi)
+			query = query.Where("rsi < ?", q.Rsi)
 		}
 	}
-	if crossType > 0 {
-		query = query.Where("cross_type = ?", crossType)
+	if q.CrossType > 0 {
+		query = query.Where("cross_type = ?", q.CrossType)
 	}
-
-	// 获取总数
-	err = query.Count(&total).Error
-	if err != nil {
-		return ListData{}, err
+	if q.Filter != nil {
+		sql, args := q.Filter.SQL()
+		query = query.Where(sql, args...)
 	}
 
-	// 添加排序
-	if symbol != "" && cycle == "" {
-		// 币种不为空且未选周期：先按周期顺序(5m,15m,30m,1h,...)，再按更新时间倒序
-		query = query.Order(cycleOrderSQL + ", updated_at DESC")
-	} else if symbol != "" && len(symbol) >= 4 && strings.ToUpper(symbol[len(symbol)-4:]) == "USDT" {
-		// 指定了周期时的 USDT 查询：按更新时间倒序
-		query = query.Order("updated_at DESC")
-	} else if orderBy != "" {
-		// 否则按用户指定的排序
-		orderDirection := "ASC"
-		if orderType == "desc" {
-			orderDirection = "DESC"
+	// 获取总数（游标条件之前统计，即全部满足筛选的记录数）
+	if !q.SkipCount {
+		var total int64
+		if err = query.Count(&total).Error; err != nil {
+			return ListData{}, err
 		}
-		query = query.Order(fmt.Sprintf("%s %s", orderBy, orderDirection))
-	} else {
-		// 默认按更新时间降序排序
-		query = query.Order("updated_at DESC")
+		data.Count = &total
 	}
 
-	// 分页查询
-	offset := (page - 1) * pageSize
-	err = query.Offset(offset).Limit(pageSize).Find(&records).Error
-	if err != nil {
-		return ListData{}, err
-	}
+	if q.CursorMode {
+		// 游标分页：按排序键 + id 定位，多取一条判断是否还有下一页
+		keys := q.Order
+		if len(keys) == 0 {
+			field, _ := screener.LookupField("updated_at")
+			keys = []screener.OrderKey{{Field: field, Desc: true}}
+		}
+		if q.Cursor != "" {
+			sql, args, err := screener.CursorSQL(keys, q.Cursor)
+			if err != nil {
+				return ListData{}, err
+			}
+			query = query.Where(sql, args...)
+		}
+		if err = query.Order(screener.OrderSQL(keys)).Limit(q.PageSize + 1).Find(&records).Error; err != nil {
+			return ListData{}, err
+		}
+		if len(records) > q.PageSize {
+			records = records[:q.PageSize]
+			data.NextCursor = screener.EncodeCursor(keys, &records[len(records)-1])
+		}
+	} else {
+		// 添加排序：指定了排序时始终按指定排序（含按币种查询时）
+		if len(q.Order) > 0 {
+			query = query.Order(screener.OrderSQL(q.Order))
+		} else if q.Symbol != "" && q.Cycle == "" {
+			// 币种不为空且未选周期：先按周期顺序(5m,15m,30m,1h,...)，再按更新时间倒序
+			query = query.Order(cycleOrderSQL + ", updated_at DESC")
+		} else {
+			// 默认按更新时间倒序
+			query = query.Order("updated_at DESC")
+		}
 
-	formatted := make([]map[string]interface{}, len(records))
-	for i, r := range records {
-		fmt.Println("rate_cycle:", r.RateCycle)
-		formatted[i] = map[string]interface{}{
-			"id":               r.ID,
-			"symbol":           r.Symbol,
-			"cycle":            r.Cycle,
-			"shape":            r.Shape,
-			"rsi":              sanitizeFloat(r.Rsi),
-			"cross_type":       r.CrossType,
-			"cross_time":       r.CrossTime,
-			"price":            sanitizeFloat(r.Price),
-			"volume":           sanitizeFloat(r.Volume),
-			"taker_buy_volume": sanitizeFloat(r.TakerBuyVolume),
-			"taker_buy_ratio":  sanitizeFloat(r.TakerBuyRatio),
-			"rate":             sanitizeFloat(r.Rate),
-			"rate_cycle":       r.RateCycle,
-			"change":           sanitizeFloat(r.Change),
-			"updated_at":       r.UpdatedAt,
-			"vp_signal":        r.VpSignal,
-			"smc_signal":       r.SMCSignal,
-			"fvg":              r.Fvg,
-			"ob":               r.Ob,
+		// 分页查询
+		offset := (q.Page - 1) * q.PageSize
+		if err = query.Offset(offset).Limit(q.PageSize).Find(&records).Error; err != nil {
+			return ListData{}, err
 		}
 	}
 
-	return ListData{
-		Data:  formatted,
-		Count: int(total),
-	}, nil
+	data.Data = buildItems(records, q.Fields)
+	return data, nil
 }
 
-func sanitizeFloat(v float64) float64 {
-	if math.IsNaN(v) || math.IsInf(v, 0) {
-		return 0
-	}
-	return v
+// LatestBySymbol 查询某个交易对全部周期的记录，按周期顺序排列
+func LatestBySymbol(symbol string) ([]publicModels.SymbolRecord, error) {
+	var records []publicModels.SymbolRecord
+	err := database.DB.Where("symbol = ?", symbol).Order(cycleOrderSQL).Find(&records).Error
+	return records, err
 }
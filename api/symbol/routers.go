@@ -9,4 +9,7 @@ func SetupSymbolRoutes(router *gin.RouterGroup) {
 
 	// get all roles
 	router.GET("/", HandleSymbolQuery)
+
+	// 多周期共振筛选
+	router.GET("/confluence", HandleConfluence)
 }
@@ -0,0 +1,121 @@
+package user
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/cryptoSelect/backendapi/api/auth"
+	"github.com/cryptoSelect/backendapi/api/subscription"
+	"github.com/cryptoSelect/backendapi/models"
+	"github.com/cryptoSelect/backendapi/preference"
+	"github.com/cryptoSelect/backendapi/response"
+	"github.com/gin-gonic/gin"
+)
+
+// ExportUser 导出数据中的用户信息（不含密码哈希）
+type ExportUser struct {
+	ID            uint      `json:"id"`
+	Email         string    `json:"email"`
+	TelegramID    string    `json:"telegram_id"`
+	EmailVerified bool      `json:"email_verified"`
+	TelegramOnly  bool      `json:"telegram_only"`
+	Role          string    `json:"role"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// ExportData 个人数据导出
+type ExportData struct {
+	ExportedAt       time.Time                `json:"exported_at"`
+	User             ExportUser               `json:"user"`
+	TelegramProfile  *models.TelegramProfile  `json:"telegram_profile"`
+	Subscriptions    []subscription.SubItem   `json:"subscriptions"`
+	TelegramBindLogs []models.TelegramBindLog `json:"telegram_bind_logs"`
+	Sessions         []auth.SessionItem       `json:"sessions"`
+	Preferences      preference.Preferences   `json:"preferences"`
+}
+
+// ExportAccount 以 JSON 附件导出当前用户的个人数据：账号信息、订阅、Telegram 绑定历史、登录会话与偏好设置
+func ExportAccount(c *gin.Context) {
+	userID := c.MustGet(auth.ContextUserIDKey).(uint)
+	user, err := auth.GetUserByID(userID)
+	if err != nil {
+		response.ServerError(c, "user export failed", err, map[string]interface{}{"user_id": userID})
+		return
+	}
+	subs, err := subscription.ListByUserID(userID)
+	if err != nil {
+		response.ServerError(c, "user export subscriptions failed", err, map[string]interface{}{"user_id": userID})
+		return
+	}
+	logs, err := auth.TelegramBindLogs(userID)
+	if err != nil {
+		response.ServerError(c, "user export bind logs failed", err, map[string]interface{}{"user_id": userID})
+		return
+	}
+	sessions, err := auth.ListSessions(userID, c.GetString(auth.ContextSessionIDKey))
+	if err != nil {
+		response.ServerError(c, "user export sessions failed", err, map[string]interface{}{"user_id": userID})
+		return
+	}
+	data := ExportData{
+		ExportedAt: time.Now(),
+		User: ExportUser{
+			ID:            user.ID,
+			Email:         user.Email,
+			TelegramID:    user.TelegramID,
+			EmailVerified: auth.EmailVerified(userID),
+			TelegramOnly:  auth.TelegramOnly(userID),
+			Role:          auth.UserRole(userID),
+			CreatedAt:     user.CreatedAt,
+			UpdatedAt:     user.UpdatedAt,
+		},
+		TelegramProfile:  auth.GetTelegramProfile(userID),
+		Subscriptions:    subs,
+		TelegramBindLogs: logs,
+		Sessions:         sessions,
+		Preferences:      preference.Get(userID),
+	}
+	if data.User.TelegramOnly {
+		data.User.Email = ""
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"cryptoselect-export-%d.json\"", userID))
+	response.OK(c, data)
+}
+
+type DeleteAccountRequest struct {
+	Password string `json:"password"` // 当前密码；仅 Telegram 登录的账号不填
+	Confirm  string `json:"confirm"`  // 仅 Telegram 登录的账号需填 "DELETE"
+	Code     string `json:"code"`     // 已开启两步验证时的验证码或恢复码
+}
+
+// DeleteAccount 注销当前账号：需再次确认密码，删除订阅、解除 Telegram 绑定并注销全部会话，宽限期后彻底删除数据
+func DeleteAccount(c *gin.Context) {
+	userID := c.MustGet(auth.ContextUserIDKey).(uint)
+	var req DeleteAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Fail(c, response.CodeInvalidRequest, nil)
+		return
+	}
+	err := auth.ConfirmDeletion(userID, req.Password, req.Confirm, req.Code)
+	switch {
+	case errors.Is(err, auth.ErrPasswordMismatch):
+		response.Fail(c, response.CodePasswordWrong, nil)
+		return
+	case errors.Is(err, auth.ErrConfirmRequired):
+		response.Fail(c, response.CodeConfirmRequired, gin.H{"confirm": auth.DeleteConfirmText})
+		return
+	case errors.Is(err, auth.ErrInvalidCode):
+		response.Fail(c, response.CodeInvalidCode, nil)
+		return
+	case err != nil:
+		response.ServerError(c, "user delete confirm failed", err, map[string]interface{}{"user_id": userID})
+		return
+	}
+	if err := auth.DeleteAccount(userID); err != nil {
+		response.ServerError(c, "user delete failed", err, map[string]interface{}{"user_id": userID})
+		return
+	}
+	response.OK(c, gin.H{"ok": true})
+}
@@ -0,0 +1,72 @@
+package user
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/cryptoSelect/backendapi/api/auth"
+	"github.com/cryptoSelect/backendapi/response"
+	"github.com/cryptoSelect/backendapi/utils/logger"
+	"github.com/gin-gonic/gin"
+)
+
+type APIKeyRequest struct {
+	Name               string   `json:"name" binding:"required,max=64"`
+	Scopes             []string `json:"scopes" binding:"required"`                     // read:symbol / read:funds / write:subscription
+	RateLimitPerMinute int      `json:"rate_limit_per_minute" binding:"min=0,max=600"` // 0 表示使用默认值
+}
+
+// ListAPIKeys 当前用户的 API key 列表（不含明文）
+func ListAPIKeys(c *gin.Context) {
+	uid := c.MustGet(auth.ContextUserIDKey).(uint)
+	items, err := auth.ListAPIKeys(uid)
+	if err != nil {
+		response.ServerError(c, "list api keys failed", err, map[string]interface{}{"user_id": uid})
+		return
+	}
+	response.OK(c, gin.H{"data": items})
+}
+
+// CreateAPIKey 创建 API key，返回完整 key（仅此一次）
+func CreateAPIKey(c *gin.Context) {
+	uid := c.MustGet(auth.ContextUserIDKey).(uint)
+	var req APIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil || strings.TrimSpace(req.Name) == "" {
+		response.Fail(c, response.CodeInvalidRequest, nil)
+		return
+	}
+	item, err := auth.CreateAPIKey(uid, strings.TrimSpace(req.Name), req.Scopes, req.RateLimitPerMinute)
+	switch {
+	case errors.Is(err, auth.ErrInvalidScope):
+		response.Fail(c, response.CodeInvalidScope, nil)
+	case errors.Is(err, auth.ErrTooManyAPIKeys):
+		response.Fail(c, response.CodeTooManyAPIKeys, nil)
+	case err != nil:
+		response.ServerError(c, "create api key failed", err, map[string]interface{}{"user_id": uid})
+	default:
+		logger.Log.Info("api key created", map[string]interface{}{"user_id": uid, "api_key_id": item.ID})
+		response.OK(c, item)
+	}
+}
+
+// RevokeAPIKey 吊销 API key
+func RevokeAPIKey(c *gin.Context) {
+	uid := c.MustGet(auth.ContextUserIDKey).(uint)
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || id == 0 {
+		response.Fail(c, response.CodeInvalidID, nil)
+		return
+	}
+	err = auth.RevokeAPIKey(uid, uint(id))
+	if errors.Is(err, auth.ErrAPIKeyNotFound) {
+		response.Fail(c, response.CodeAPIKeyNotFound, nil)
+		return
+	}
+	if err != nil {
+		response.ServerError(c, "revoke api key failed", err, map[string]interface{}{"user_id": uid, "id": id})
+		return
+	}
+	logger.Log.Info("api key revoked", map[string]interface{}{"user_id": uid, "api_key_id": id})
+	response.OK(c, gin.H{"ok": true})
+}
@@ -0,0 +1,107 @@
+package user
+
+import (
+	"errors"
+
+	"github.com/cryptoSelect/backendapi/api/auth"
+	"github.com/cryptoSelect/backendapi/response"
+	"github.com/cryptoSelect/backendapi/utils/logger"
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type CredentialsRequest struct {
+	Email    string `json:"email" binding:"required,email"`
+	Password string `json:"password" binding:"required,min=6"`
+}
+
+// AttachCredentials 为通过 Telegram 登录创建的账号设置密码，并向邮箱发送确认链接；确认（POST /api/auth/email/confirm）后可用邮箱登录
+func AttachCredentials(c *gin.Context) {
+	userID := c.MustGet(auth.ContextUserIDKey).(uint)
+	var req CredentialsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Fail(c, response.CodeInvalidRequest, nil)
+		return
+	}
+	if !auth.TelegramOnly(userID) {
+		response.Fail(c, response.CodeCredentialsAlreadySet, nil)
+		return
+	}
+	hashed, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		response.ServerError(c, "hash password failed", err, nil)
+		return
+	}
+	err = auth.RequestAttachCredentials(userID, req.Email, string(hashed))
+	switch {
+	case errors.Is(err, auth.ErrEmailReserved):
+		response.Fail(c, response.CodeEmailReserved, nil)
+		return
+	case errors.Is(err, auth.ErrEmailTaken):
+		response.Fail(c, response.CodeEmailAlreadyRegistered, nil)
+		return
+	case err != nil:
+		response.ServerError(c, "attach credentials failed", err, map[string]interface{}{"user_id": userID})
+		return
+	}
+	logger.Log.Info("attach credentials requested", map[string]interface{}{"user_id": userID})
+	response.OK(c, gin.H{"ok": true})
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required,min=6"`
+}
+
+type ChangeEmailRequest struct {
+	Email    string `json:"email" binding:"required,email"` // 新邮箱
+	Password string `json:"password" binding:"required"`    // 当前密码
+}
+
+// writeCredentialsError 修改密码/邮箱的错误映射为响应
+func writeCredentialsError(c *gin.Context, userID uint, err error) {
+	switch {
+	case errors.Is(err, auth.ErrCredentialsNotSet):
+		response.Fail(c, response.CodeCredentialsNotSet, nil)
+	case errors.Is(err, auth.ErrPasswordMismatch):
+		response.Fail(c, response.CodePasswordWrong, nil)
+	case errors.Is(err, auth.ErrEmailUnchanged):
+		response.Fail(c, response.CodeEmailUnchanged, nil)
+	case errors.Is(err, auth.ErrEmailReserved):
+		response.Fail(c, response.CodeEmailReserved, nil)
+	case errors.Is(err, auth.ErrEmailTaken):
+		response.Fail(c, response.CodeEmailAlreadyRegistered, nil)
+	default:
+		response.ServerError(c, "change credentials failed", err, map[string]interface{}{"user_id": userID})
+	}
+}
+
+// ChangePassword 校验当前密码后修改密码，当前会话保留，其他设备退出登录
+func ChangePassword(c *gin.Context) {
+	userID := c.MustGet(auth.ContextUserIDKey).(uint)
+	var req ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Fail(c, response.CodeInvalidRequest, nil)
+		return
+	}
+	if err := auth.ChangePassword(userID, req.CurrentPassword, req.NewPassword, c.GetString(auth.ContextSessionIDKey)); err != nil {
+		writeCredentialsError(c, userID, err)
+		return
+	}
+	response.OK(c, gin.H{"ok": true})
+}
+
+// ChangeEmail 校验当前密码后向新邮箱发送确认链接，确认（POST /api/auth/email/confirm）后才切换登录邮箱
+func ChangeEmail(c *gin.Context) {
+	userID := c.MustGet(auth.ContextUserIDKey).(uint)
+	var req ChangeEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Fail(c, response.CodeInvalidRequest, nil)
+		return
+	}
+	if err := auth.RequestEmailChange(userID, req.Password, req.Email); err != nil {
+		writeCredentialsError(c, userID, err)
+		return
+	}
+	response.OK(c, gin.H{"ok": true})
+}
@@ -1,21 +1,19 @@
 package user
 
 import (
-	"net/http"
-
 	"github.com/cryptoSelect/backendapi/api/auth"
+	"github.com/cryptoSelect/backendapi/response"
 	"github.com/gin-gonic/gin"
 )
 
-type Response struct {
-	Error string      `json:"error"`
-	Code  int         `json:"code"`
-	Data  interface{} `json:"data"`
-}
-
 type MeData struct {
-	Email         string `json:"email"`
-	TelegramBound bool   `json:"telegram_bound"` // 是否已绑定 Telegram
+	Email             string `json:"email"`
+	EmailVerified     bool   `json:"email_verified"`                // 邮箱是否已验证
+	TelegramOnly      bool   `json:"telegram_only"`                 // 通过 Telegram 登录创建、尚未设置邮箱密码，此时 email 为空
+	Role              string `json:"role"`                          // 角色：user / admin
+	TelegramBound     bool   `json:"telegram_bound"`                // 是否已绑定 Telegram
+	TelegramUsername  string `json:"telegram_username,omitempty"`   // 绑定时 /start 消息中的 Telegram 用户名
+	TelegramFirstName string `json:"telegram_first_name,omitempty"` // 绑定时 /start 消息中的 Telegram 名字
 }
 
 // Me 返回当前登录用户信息（需在 RequireAuth 之后调用）
@@ -23,9 +21,14 @@ func Me(c *gin.Context) {
 	email, _ := c.Get(auth.ContextUserEmailKey)
 	userID, _ := c.Get(auth.ContextUserIDKey)
 	tgID := auth.GetUserTelegramID(userID.(uint))
-	c.JSON(http.StatusOK, Response{
-		Error: "",
-		Code:  200,
-		Data:  MeData{Email: email.(string), TelegramBound: tgID != ""},
-	})
+	data := MeData{Email: email.(string), EmailVerified: auth.EmailVerified(userID.(uint)), TelegramBound: tgID != "", Role: auth.UserRole(userID.(uint))}
+	if auth.TelegramOnly(userID.(uint)) {
+		data.Email = ""
+		data.TelegramOnly = true
+	}
+	if profile := auth.GetTelegramProfile(userID.(uint)); profile != nil && tgID != "" {
+		data.TelegramUsername = profile.Username
+		data.TelegramFirstName = profile.FirstName
+	}
+	response.OK(c, data)
 }
@@ -0,0 +1,37 @@
+package user
+
+import (
+	"errors"
+
+	"github.com/cryptoSelect/backendapi/api/auth"
+	"github.com/cryptoSelect/backendapi/preference"
+	"github.com/cryptoSelect/backendapi/response"
+	"github.com/gin-gonic/gin"
+)
+
+// GetPreferences 获取当前用户偏好，未设置的字段返回默认值
+func GetPreferences(c *gin.Context) {
+	userID := c.MustGet(auth.ContextUserIDKey).(uint)
+	response.OK(c, preference.Get(userID))
+}
+
+// UpdatePreferences 部分更新偏好，未传的字段保持不变；校验失败返回出错字段
+func UpdatePreferences(c *gin.Context) {
+	userID := c.MustGet(auth.ContextUserIDKey).(uint)
+	var patch preference.Patch
+	if err := c.ShouldBindJSON(&patch); err != nil {
+		response.Fail(c, response.CodeInvalidRequest, nil)
+		return
+	}
+	prefs, err := preference.Update(userID, patch)
+	var verr *preference.ValidationError
+	if errors.As(err, &verr) {
+		response.Fail(c, response.CodeInvalidPreference, gin.H{"field": verr.Field, "message": verr.Message})
+		return
+	}
+	if err != nil {
+		response.ServerError(c, "user update preferences failed", err, map[string]interface{}{"user_id": userID})
+		return
+	}
+	response.OK(c, prefs)
+}
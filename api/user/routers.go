@@ -1,9 +1,49 @@
 package user
 
 import (
+	"github.com/cryptoSelect/backendapi/api/auth"
+	"github.com/cryptoSelect/backendapi/ratelimit"
 	"github.com/gin-gonic/gin"
 )
 
 func SetupUserRoutes(router *gin.RouterGroup, requireAuth gin.HandlerFunc) {
+	byUser := ratelimit.ByContextKey(auth.ContextUserIDKey)
+
 	router.GET("/me", requireAuth, Me)
+	// 个人数据导出与注销账号
+	router.GET("/export", requireAuth, ExportAccount)
+	router.DELETE("", requireAuth, DeleteAccount)
+	// Telegram 登录创建的账号补充设置邮箱和密码
+	router.POST("/credentials", requireAuth, AttachCredentials)
+	// 修改密码（其他设备退出登录）与修改邮箱（新邮箱确认后生效），均需当前密码并通知绑定的 Telegram
+	router.POST("/password", requireAuth, ratelimit.Middleware("password_change", ratelimit.AccountLimit(), byUser), ChangePassword)
+	router.POST("/email", requireAuth, ratelimit.Middleware("email_change", ratelimit.AccountLimit(), byUser), ChangeEmail)
+
+	// 偏好设置：语言、时区、分页、默认周期、免打扰、通知通道
+	router.GET("/preferences", requireAuth, GetPreferences)
+	router.PATCH("/preferences", requireAuth, UpdatePreferences)
+
+	// 登录会话（设备）列表与注销
+	router.GET("/sessions", requireAuth, ListSessions)
+	router.DELETE("/sessions/:id", requireAuth, RevokeSession)
+
+	// 两步验证（TOTP）
+	router.GET("/2fa", requireAuth, TwoFactorStatus)
+	router.POST("/2fa/setup", requireAuth, SetupTwoFactor)
+	router.POST("/2fa/enable", requireAuth, EnableTwoFactor)
+	router.POST("/2fa/disable", requireAuth, DisableTwoFactor)
+	router.POST("/2fa/recovery-codes", requireAuth, RegenerateRecoveryCodes)
+
+	// API key：程序化访问，仅能通过登录 token 管理
+	router.GET("/api-keys", requireAuth, ListAPIKeys)
+	router.POST("/api-keys", requireAuth, CreateAPIKey)
+	router.DELETE("/api-keys/:id", requireAuth, RevokeAPIKey)
+
+	// 外发 Webhook：订阅事件以 HMAC 签名 POST 到用户配置的地址
+	router.GET("/webhooks", requireAuth, ListWebhooks)
+	router.POST("/webhooks", requireAuth, CreateWebhook)
+	router.PUT("/webhooks/:id", requireAuth, UpdateWebhook)
+	router.DELETE("/webhooks/:id", requireAuth, DeleteWebhook)
+	router.GET("/webhooks/:id/deliveries", requireAuth, WebhookDeliveries)
+	router.POST("/webhooks/:id/test", requireAuth, TestWebhook)
 }
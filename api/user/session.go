@@ -0,0 +1,35 @@
+package user
+
+import (
+	"errors"
+
+	"github.com/cryptoSelect/backendapi/api/auth"
+	"github.com/cryptoSelect/backendapi/response"
+	"github.com/gin-gonic/gin"
+)
+
+// ListSessions 获取当前用户已登录的设备（User-Agent、IP、最近活跃时间）
+func ListSessions(c *gin.Context) {
+	uid := c.MustGet(auth.ContextUserIDKey).(uint)
+	items, err := auth.ListSessions(uid, c.GetString(auth.ContextSessionIDKey))
+	if err != nil {
+		response.ServerError(c, "list sessions failed", err, map[string]interface{}{"user_id": uid})
+		return
+	}
+	response.OK(c, gin.H{"data": items})
+}
+
+// RevokeSession 注销当前用户的某个会话（踢下线某台设备）
+func RevokeSession(c *gin.Context) {
+	uid := c.MustGet(auth.ContextUserIDKey).(uint)
+	err := auth.RevokeSession(uid, c.Param("id"))
+	if errors.Is(err, auth.ErrSessionNotFound) {
+		response.Fail(c, response.CodeSessionNotFound, nil)
+		return
+	}
+	if err != nil {
+		response.ServerError(c, "revoke session failed", err, map[string]interface{}{"user_id": uid})
+		return
+	}
+	response.OK(c, gin.H{"ok": true})
+}
@@ -0,0 +1,101 @@
+package user
+
+import (
+	"errors"
+
+	"github.com/cryptoSelect/backendapi/api/auth"
+	"github.com/cryptoSelect/backendapi/response"
+	"github.com/cryptoSelect/backendapi/utils/logger"
+	"github.com/gin-gonic/gin"
+)
+
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" binding:"required"` // 验证器 App 中的 6 位验证码；关闭时也可填恢复码
+}
+
+// writeTwoFactorError 两步验证错误映射为响应
+func writeTwoFactorError(c *gin.Context, userID uint, err error) {
+	switch {
+	case errors.Is(err, auth.ErrInvalidCode):
+		response.Fail(c, response.CodeInvalidCode, nil)
+	case errors.Is(err, auth.ErrTwoFactorAlreadyEnabled):
+		response.Fail(c, response.CodeTwoFactorAlreadyEnabled, nil)
+	case errors.Is(err, auth.ErrTwoFactorNotEnabled):
+		response.Fail(c, response.CodeTwoFactorNotEnabled, nil)
+	case errors.Is(err, auth.ErrTwoFactorNotSetup):
+		response.Fail(c, response.CodeTwoFactorNotSetup, nil)
+	default:
+		response.ServerError(c, "two factor failed", err, map[string]interface{}{"user_id": userID})
+	}
+}
+
+// TwoFactorStatus 两步验证状态
+func TwoFactorStatus(c *gin.Context) {
+	uid := c.MustGet(auth.ContextUserIDKey).(uint)
+	info, err := auth.TwoFactorStatus(uid)
+	if err != nil {
+		writeTwoFactorError(c, uid, err)
+		return
+	}
+	response.OK(c, info)
+}
+
+// SetupTwoFactor 生成 TOTP 密钥与 otpauth URI，需再调用 enable 用首个验证码确认
+func SetupTwoFactor(c *gin.Context) {
+	uid := c.MustGet(auth.ContextUserIDKey).(uint)
+	secret, uri, err := auth.SetupTwoFactor(uid)
+	if err != nil {
+		writeTwoFactorError(c, uid, err)
+		return
+	}
+	response.OK(c, gin.H{"secret": secret, "otpauth_uri": uri})
+}
+
+// EnableTwoFactor 确认验证码并启用两步验证，返回恢复码（仅此一次）
+func EnableTwoFactor(c *gin.Context) {
+	uid := c.MustGet(auth.ContextUserIDKey).(uint)
+	var req TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Fail(c, response.CodeInvalidRequest, nil)
+		return
+	}
+	codes, err := auth.EnableTwoFactor(uid, req.Code)
+	if err != nil {
+		writeTwoFactorError(c, uid, err)
+		return
+	}
+	logger.Log.Info("two factor enabled", map[string]interface{}{"user_id": uid})
+	response.OK(c, gin.H{"recovery_codes": codes})
+}
+
+// DisableTwoFactor 使用验证码或恢复码关闭两步验证
+func DisableTwoFactor(c *gin.Context) {
+	uid := c.MustGet(auth.ContextUserIDKey).(uint)
+	var req TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Fail(c, response.CodeInvalidRequest, nil)
+		return
+	}
+	if err := auth.DisableTwoFactor(uid, req.Code); err != nil {
+		writeTwoFactorError(c, uid, err)
+		return
+	}
+	logger.Log.Info("two factor disabled", map[string]interface{}{"user_id": uid})
+	response.OK(c, gin.H{"ok": true})
+}
+
+// RegenerateRecoveryCodes 重新生成恢复码，旧恢复码作废
+func RegenerateRecoveryCodes(c *gin.Context) {
+	uid := c.MustGet(auth.ContextUserIDKey).(uint)
+	var req TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Fail(c, response.CodeInvalidRequest, nil)
+		return
+	}
+	codes, err := auth.RegenerateRecoveryCodes(uid, req.Code)
+	if err != nil {
+		writeTwoFactorError(c, uid, err)
+		return
+	}
+	response.OK(c, gin.H{"recovery_codes": codes})
+}
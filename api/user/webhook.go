@@ -0,0 +1,198 @@
+package user
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cryptoSelect/backendapi/alert"
+	"github.com/cryptoSelect/backendapi/api/auth"
+	"github.com/cryptoSelect/backendapi/models"
+	"github.com/cryptoSelect/backendapi/response"
+	"github.com/cryptoSelect/backendapi/webhook"
+	"github.com/gin-gonic/gin"
+)
+
+type WebhookRequest struct {
+	URL     string `json:"url"`
+	Secret  string `json:"secret"`  // 为空时创建会自动生成
+	Enabled *bool  `json:"enabled"` // 为空时创建默认启用，修改时保持不变
+}
+
+type WebhookItem struct {
+	ID        uint      `json:"id"`
+	URL       string    `json:"url"`
+	Enabled   bool      `json:"enabled"`
+	Secret    string    `json:"secret,omitempty"` // 仅创建或更换密钥时返回完整密钥
+	SecretTip string    `json:"secret_tip"`       // 密钥末 4 位，便于核对
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func toWebhookItem(h *models.Webhook, withSecret bool) WebhookItem {
+	item := WebhookItem{ID: h.ID, URL: h.URL, Enabled: h.Enabled, CreatedAt: h.CreatedAt, UpdatedAt: h.UpdatedAt}
+	if len(h.Secret) > 4 {
+		item.SecretTip = "..." + h.Secret[len(h.Secret)-4:]
+	}
+	if withSecret {
+		item.Secret = h.Secret
+	}
+	return item
+}
+
+// writeURLError Webhook 地址不合法或指向内网地址
+func writeURLError(c *gin.Context, err error) {
+	response.Fail(c, response.CodeInvalidWebhookURL, gin.H{"message": err.Error()})
+}
+
+// ListWebhooks 获取当前用户的 Webhook 列表
+func ListWebhooks(c *gin.Context) {
+	uid := c.MustGet(auth.ContextUserIDKey).(uint)
+	hooks, err := webhook.ListByUser(uid)
+	if err != nil {
+		response.ServerError(c, "list webhooks failed", err, map[string]interface{}{"user_id": uid})
+		return
+	}
+	items := make([]WebhookItem, 0, len(hooks))
+	for i := range hooks {
+		items = append(items, toWebhookItem(&hooks[i], false))
+	}
+	response.OK(c, gin.H{"data": items})
+}
+
+// CreateWebhook 创建 Webhook，返回完整签名密钥（仅此一次）
+func CreateWebhook(c *gin.Context) {
+	uid := c.MustGet(auth.ContextUserIDKey).(uint)
+	var req WebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Fail(c, response.CodeInvalidRequest, nil)
+		return
+	}
+	if err := webhook.ValidateURL(req.URL); err != nil {
+		writeURLError(c, err)
+		return
+	}
+	secret := strings.TrimSpace(req.Secret)
+	if secret == "" {
+		var err error
+		if secret, err = webhook.NewSecret(); err != nil {
+			response.ServerError(c, "webhook secret generate failed", err, map[string]interface{}{"user_id": uid})
+			return
+		}
+	}
+	hook := models.Webhook{UserID: uid, URL: strings.TrimSpace(req.URL), Secret: secret, Enabled: req.Enabled == nil || *req.Enabled}
+	if err := webhook.Create(&hook); err != nil {
+		writeWebhookError(c, err)
+		return
+	}
+	response.OK(c, toWebhookItem(&hook, true))
+}
+
+// UpdateWebhook 修改 Webhook 的 url / secret / enabled，未传的字段保持不变
+func UpdateWebhook(c *gin.Context) {
+	hook, ok := loadWebhook(c)
+	if !ok {
+		return
+	}
+	var req WebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Fail(c, response.CodeInvalidRequest, nil)
+		return
+	}
+	if req.URL != "" {
+		if err := webhook.ValidateURL(req.URL); err != nil {
+			writeURLError(c, err)
+			return
+		}
+		hook.URL = strings.TrimSpace(req.URL)
+	}
+	secretChanged := strings.TrimSpace(req.Secret) != ""
+	if secretChanged {
+		hook.Secret = strings.TrimSpace(req.Secret)
+	}
+	if req.Enabled != nil {
+		hook.Enabled = *req.Enabled
+	}
+	if err := webhook.Save(hook); err != nil {
+		writeWebhookError(c, err)
+		return
+	}
+	response.OK(c, toWebhookItem(hook, secretChanged))
+}
+
+// DeleteWebhook 删除 Webhook 及其投递日志
+func DeleteWebhook(c *gin.Context) {
+	uid := c.MustGet(auth.ContextUserIDKey).(uint)
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Fail(c, response.CodeInvalidID, nil)
+		return
+	}
+	if err := webhook.Delete(uid, uint(id)); err != nil {
+		writeWebhookError(c, err)
+		return
+	}
+	response.OK(c, gin.H{"ok": true})
+}
+
+// WebhookDeliveries 查询 Webhook 最近的投递日志，query limit 默认 50，最大 200
+func WebhookDeliveries(c *gin.Context) {
+	hook, ok := loadWebhook(c)
+	if !ok {
+		return
+	}
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+	rows, err := webhook.Deliveries(hook.ID, limit)
+	if err != nil {
+		response.ServerError(c, "list webhook deliveries failed", err, map[string]interface{}{"webhook_id": hook.ID})
+		return
+	}
+	response.OK(c, gin.H{"data": rows})
+}
+
+// TestWebhook 立即向 Webhook 发送一条测试事件（单次尝试），返回投递结果
+func TestWebhook(c *gin.Context) {
+	hook, ok := loadWebhook(c)
+	if !ok {
+		return
+	}
+	ev := alert.TestEvent(time.Now())
+	opts := webhook.DefaultOptions()
+	opts.MaxAttempts = 1
+	row, err := webhook.Deliver(hook, ev.Key, alert.EventType(ev), ev, opts)
+	if row == nil && err != nil {
+		response.ServerError(c, "webhook test delivery failed", err, map[string]interface{}{"webhook_id": hook.ID})
+		return
+	}
+	response.OK(c, row)
+}
+
+func loadWebhook(c *gin.Context) (*models.Webhook, bool) {
+	uid := c.MustGet(auth.ContextUserIDKey).(uint)
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Fail(c, response.CodeInvalidID, nil)
+		return nil, false
+	}
+	hook, err := webhook.Get(uid, uint(id))
+	if err != nil {
+		writeWebhookError(c, err)
+		return nil, false
+	}
+	return hook, true
+}
+
+func writeWebhookError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, webhook.ErrNotFound):
+		response.Fail(c, response.CodeWebhookNotFound, nil)
+	case errors.Is(err, webhook.ErrTooMany):
+		response.Fail(c, response.CodeTooManyWebhooks, nil)
+	default:
+		response.ServerError(c, "webhook operation failed", err, nil)
+	}
+}
@@ -24,18 +24,60 @@ type ServerConfig struct {
 	Database  DBConfig   `json:"Database"`
 	Page      PageConfig `json:"Page"`
 	JWTSecret string     `json:"JWTSecret"` // JWT 签发与校验密钥，生产环境务必修改
+	Auth      AuthConfig `json:"Auth"`
 	// TelegramBotName 用于生成绑定链接：https://t.me/<bot>?start=<token>，填 Bot 用户名（不含 @）
 	TelegramBotName string `json:"TelegramBotName"`
 	// TelegramBotToken Bot API token，用于 getMe 与 tgBot 接收 /start
 	TelegramBotToken string `json:"TelegramBotToken"`
-	// BackendAPIBase tgBot 回调 ConfirmTelegramBind 的地址，如 http://localhost:8080
+	// BackendAPIBase tgBot 回调 ConfirmTelegramBind 的地址，如 http://localhost:8080（仅 TelegramBindMode 为 http 时使用）
 	BackendAPIBase string `json:"BackendAPIBase"`
+	// TelegramBindMode tgBot 确认绑定的方式：inprocess（默认，同进程直接调用）或 http（签名回调 BackendAPIBase）
+	TelegramBindMode string `json:"TelegramBindMode"`
+	// TelegramCallbackSecret 绑定回调 HMAC 签名密钥，未配置时 /api/auth/tg/bind/confirm 拒绝所有请求
+	TelegramCallbackSecret string `json:"TelegramCallbackSecret"`
+	// TelegramUpdateMode Bot 接收更新的方式：polling（默认，getUpdates 长轮询）或 webhook（启动时 setWebhook）
+	TelegramUpdateMode string `json:"TelegramUpdateMode"`
+	// TelegramWebhookURL webhook 模式下注册给 Telegram 的公网地址，如 https://api.example.com/api/tg/webhook
+	TelegramWebhookURL string `json:"TelegramWebhookURL"`
+	// TelegramWebhookSecret setWebhook 的 secret_token，Telegram 推送时放在 X-Telegram-Bot-Api-Secret-Token 头
+	TelegramWebhookSecret string `json:"TelegramWebhookSecret"`
+	// TelegramAPIBase Bot API 地址，默认 https://api.telegram.org；本地测试可指向假服务
+	TelegramAPIBase string          `json:"TelegramAPIBase"`
+	Alert           AlertConfig     `json:"Alert"`
+	Webhook         WebhookConfig   `json:"Webhook"`
+	Mail            MailConfig      `json:"Mail"`
+	RateLimit       RateLimitConfig `json:"RateLimit"`
 }
 
 type PageConfig struct {
 	PageSize int `json:"PageSize"`
 }
 
+// AuthConfig 登录会话配置
+type AuthConfig struct {
+	AccessTokenTTLMinutes int `json:"AccessTokenTTLMinutes"` // access token 有效期，默认 15 分钟
+	RefreshTokenTTLDays   int `json:"RefreshTokenTTLDays"`   // refresh token 有效期，默认 30 天
+	// AdminEmails 启动时设为管理员的邮箱，用于初始化管理员账号
+	AdminEmails []string `json:"AdminEmails"`
+	// DeletionGraceDays 注销账号后保留数据的天数，到期由后台任务彻底删除，默认 30 天
+	DeletionGraceDays int `json:"DeletionGraceDays"`
+}
+
+// AlertConfig 订阅告警推送配置
+type AlertConfig struct {
+	Enabled         bool    `json:"Enabled"`
+	IntervalSeconds int     `json:"IntervalSeconds"` // 扫描 symbol_records 的间隔，默认 60 秒
+	RsiOverbought   float64 `json:"RsiOverbought"`   // RSI 上穿该值时推送，默认 70
+	RsiOversold     float64 `json:"RsiOversold"`     // RSI 下穿该值时推送，默认 30
+}
+
+// WebhookConfig 用户外发 Webhook 投递配置
+type WebhookConfig struct {
+	MaxAttempts    int `json:"MaxAttempts"`    // 最大尝试次数，默认 3
+	BackoffSeconds int `json:"BackoffSeconds"` // 首次重试等待秒数，之后翻倍，默认 1
+	TimeoutSeconds int `json:"TimeoutSeconds"` // 单次请求超时，默认 10
+}
+
 func LoadConfig(configNmae string) {
 	wd, _ := os.Getwd()
 	configPath := filepath.Join(wd, "config", configNmae)
@@ -55,3 +97,28 @@ func LoadConfig(configNmae string) {
 func Init() {
 	LoadConfig("config.json")
 }
+
+// MailConfig 邮件发送配置（邮箱验证、找回密码）
+type MailConfig struct {
+	Driver   string `json:"Driver"` // smtp 或 log（默认，写日志/文件，本地使用）
+	SMTPHost string `json:"SMTPHost"`
+	SMTPPort int    `json:"SMTPPort"` // 默认 587
+	Username string `json:"Username"`
+	Password string `json:"Password"`
+	From     string `json:"From"`
+	LogFile  string `json:"LogFile"`  // log 驱动写入的文件，为空时写日志
+	LinkBase string `json:"LinkBase"` // 邮件中链接的前端地址，如 https://app.example.com
+}
+
+// RateLimitConfig 认证接口限流与登录失败锁定配置
+type RateLimitConfig struct {
+	Backend            string `json:"Backend"`            // memory（默认，单实例）或 postgres（多实例共享）
+	IPPerMinute        int    `json:"IPPerMinute"`        // 每个 IP 每分钟请求数，默认 20
+	IPBurst            int    `json:"IPBurst"`            // 每个 IP 突发请求数，默认 10
+	AccountPerMinute   int    `json:"AccountPerMinute"`   // 每个账号每分钟请求数，默认 5
+	AccountBurst       int    `json:"AccountBurst"`       // 每个账号突发请求数，默认 5
+	APIKeyPerMinute    int    `json:"APIKeyPerMinute"`    // 每个 API key 默认每分钟请求数，默认 60
+	LockoutMaxFailures int    `json:"LockoutMaxFailures"` // 连续登录失败多少次后锁定，默认 5
+	LockoutBaseSeconds int    `json:"LockoutBaseSeconds"` // 首次锁定秒数，之后每次失败翻倍，默认 60
+	LockoutMaxSeconds  int    `json:"LockoutMaxSeconds"`  // 最长锁定秒数，默认 3600
+}
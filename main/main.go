@@ -1,17 +1,27 @@
 package main
 
 import (
+	"time"
+
+	"github.com/cryptoSelect/backendapi/alert"
+	"github.com/cryptoSelect/backendapi/api/admin"
 	"github.com/cryptoSelect/backendapi/api/auth"
 	"github.com/cryptoSelect/backendapi/api/funds"
 	"github.com/cryptoSelect/backendapi/api/subscription"
 	"github.com/cryptoSelect/backendapi/api/symbol"
 	"github.com/cryptoSelect/backendapi/api/user"
 	"github.com/cryptoSelect/backendapi/config"
+	"github.com/cryptoSelect/backendapi/models"
+	"github.com/cryptoSelect/backendapi/openapi"
+	"github.com/cryptoSelect/backendapi/ratelimit"
+	"github.com/cryptoSelect/backendapi/response"
 	"github.com/cryptoSelect/backendapi/tgBot"
 	"github.com/cryptoSelect/backendapi/utils/logger"
+	"github.com/cryptoSelect/backendapi/utils/mail"
+	"github.com/cryptoSelect/backendapi/webhook"
 
 	"github.com/cryptoSelect/public/database"
-	"github.com/cryptoSelect/public/models"
+	publicModels "github.com/cryptoSelect/public/models"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 )
@@ -29,29 +39,127 @@ func main() {
 	)
 
 	// 迁移用户与订阅表（与 public 库同库）
-	if err := database.AutoMigrate(&models.UserInfo{}, &models.Subscription{}); err != nil {
+	if err := database.AutoMigrate(&publicModels.UserInfo{}, &publicModels.Subscription{}); err != nil {
 		logger.Log.Error("migrate user/subscription failed", map[string]interface{}{"error": err.Error()})
 	}
 
+	// subscription_rule 建立 (subscription_id, expr) 唯一索引前删除历史重复规则
+	if database.HasTable(&models.SubscriptionRule{}) {
+		if n, err := subscription.DedupeRules(); err != nil {
+			logger.Log.Error("dedupe subscription rules failed", map[string]interface{}{"error": err.Error()})
+		} else if n > 0 {
+			logger.Log.Info("dedupe subscription rules", map[string]interface{}{"deleted": n})
+		}
+	}
+
+	// 迁移本服务自有表；user_account 首次创建时，已有用户视为邮箱已验证
+	firstAccountMigration := !database.HasTable(&models.UserAccount{})
+	if err := database.AutoMigrate(
+		&models.AlertSnapshot{},
+		&models.AlertDelivery{},
+		&models.AlertPending{},
+		&models.SubscriptionRule{},
+		&models.Webhook{},
+		&models.WebhookDelivery{},
+		&models.TelegramBindToken{},
+		&models.TelegramBindNonce{},
+		&models.TelegramProfile{},
+		&models.TelegramBindLog{},
+		&models.Session{},
+		&models.UserAccount{},
+		&models.UserToken{},
+		&models.RateLimitBucket{},
+		&models.LoginLockout{},
+		&models.AdminAuditLog{},
+		&models.APIKey{},
+		&models.TwoFactor{},
+		&models.RecoveryCode{},
+		&models.LoginChallenge{},
+		&models.UserPreference{},
+	); err != nil {
+		logger.Log.Error("migrate backend tables failed", map[string]interface{}{"error": err.Error()})
+	} else if firstAccountMigration {
+		if err := auth.BackfillVerifiedAccounts(); err != nil {
+			logger.Log.Error("backfill user_account failed", map[string]interface{}{"error": err.Error()})
+		}
+	}
+	// 一个 Telegram 账号只能绑定一个用户；已有重复数据时建索引失败，需人工处理
+	if err := auth.EnsureTelegramIDUnique(); err != nil {
+		logger.Log.Error("create telegram_id unique index failed", map[string]interface{}{"error": err.Error()})
+	}
+
+	// 配置中的管理员邮箱
+	auth.PromoteAdmins(config.Cfg.Auth.AdminEmails)
+
+	// 认证接口限流与登录锁定：memory 单实例，postgres 多实例共享
+	ratelimit.Init(config.Cfg.RateLimit)
+	go ratelimit.RunSweeper(time.Minute)
+
+	// 邮件发送（邮箱验证、找回密码）
+	if err := mail.Init(config.Cfg.Mail); err != nil {
+		logger.Log.Error("mail init failed", map[string]interface{}{"error": err.Error()})
+		panic("mail init failed: " + err.Error())
+	}
+
 	// 根据配置设置 GIN 模式
 	if config.Cfg.Mode == "prod" {
 		gin.SetMode(gin.ReleaseMode)
 	} else {
 		gin.SetMode(gin.DebugMode)
 	}
-	// init-gin
+
+	// Telegram 绑定 token 存数据库，多实例共享；过期 token 与 nonce 定期清理
+	auth.SetBindStore(auth.NewDBBindStore())
+	go auth.RunSweeper(time.Minute)
+	// 过期会话、登录挑战与超过宽限期的注销账号
+	go auth.RunMaintenance(10 * time.Minute)
+
+	// Telegram Bot：收到 /start <token> 时确认绑定，默认同进程调用，http 模式下签名回调 ConfirmTelegramBind
+	confirm := tgBot.Confirmer(auth.ConfirmBind)
+	if config.Cfg.TelegramBindMode == "http" {
+		confirm = tgBot.HTTPConfirmer(config.Cfg.BackendAPIBase, config.Cfg.TelegramCallbackSecret)
+	}
+	bot := tgBot.New(confirm)
+
+	r := setupRouter(bot)
+
+	// 长轮询，或 webhook 模式下启动时 setWebhook
+	go bot.Run()
+
+	// 订阅告警：检测被订阅 symbol+cycle 的信号变化，推送到用户绑定的 Telegram
+	go alert.Run()
+	// Webhook 失败投递的排队重试
+	go webhook.RunRetries(5 * time.Second)
+
+	logger.Log.Info("Starting API server", map[string]interface{}{"port": ":8080", "mode": config.Cfg.Mode})
+	if err := r.Run(":8080"); err != nil {
+		logger.Log.Error("API Server failed to start", map[string]interface{}{"error": err.Error()})
+		panic("API Server failed to start: " + err.Error())
+	}
+}
+
+// setupRouter 注册全部路由；每个路由都需在 openapi.Operations 中登记，由 main_test.go 检查
+func setupRouter(bot *tgBot.Bot) *gin.Engine {
 	r := gin.Default()
 	r.Use(cors.Default())
 
 	// api
 	api := r.Group("/api")
+	// 开发模式下按 OpenAPI 描述校验请求，尽早发现前端与接口描述不一致
+	if config.Cfg.Mode != "prod" {
+		api.Use(openapi.Validate)
+	}
+	// 接口文档：/api/openapi.json 与查看页面 /api/docs
+	openapi.SetupDocRoutes(api)
+	// 错误码目录：/api/errors
+	response.SetupCatalogRoutes(api)
 
-	// SymbolRoutes
-	SymbolRoutes := api.Group("/symbol")
+	// SymbolRoutes（公开；携带 API key 时需 read:symbol）
+	SymbolRoutes := api.Group("/symbol", auth.OptionalAuthOrAPIKey(models.ScopeReadSymbol))
 	symbol.SetupSymbolRoutes(SymbolRoutes)
 
-	// FundsRoutes
-	FundsRoutes := api.Group("/funds")
+	// FundsRoutes（公开；携带 API key 时需 read:funds）
+	FundsRoutes := api.Group("/funds", auth.OptionalAuthOrAPIKey(models.ScopeReadFunds))
 	funds.SetupFundsRoutes(FundsRoutes)
 
 	// AuthRoutes（登录/注册）
@@ -62,16 +170,17 @@ func main() {
 	UserRoutes := api.Group("/user")
 	user.SetupUserRoutes(UserRoutes, auth.RequireAuth)
 
-	// SubscriptionRoutes（需登录）
+	// SubscriptionRoutes（需登录，或带 write:subscription 的 API key）
 	SubRoutes := api.Group("/subscription")
-	subscription.SetupSubscriptionRoutes(SubRoutes, auth.RequireAuth)
+	subscription.SetupSubscriptionRoutes(SubRoutes, auth.RequireAuthOrAPIKey(models.ScopeWriteSubscription), auth.RequireVerified)
 
-	// Telegram Bot 长轮询，收到 /start <token> 时回调 ConfirmTelegramBind
-	go tgBot.Run(config.Cfg.BackendAPIBase)
+	// AdminRoutes（需管理员）
+	AdminRoutes := api.Group("/admin")
+	admin.SetupAdminRoutes(AdminRoutes, auth.RequireAuth, auth.RequireRole(models.RoleAdmin))
 
-	logger.Log.Info("Starting API server", map[string]interface{}{"port": ":8080", "mode": config.Cfg.Mode})
-	if err := r.Run(":8080"); err != nil {
-		logger.Log.Error("API Server failed to start", map[string]interface{}{"error": err.Error()})
-		panic("API Server failed to start: " + err.Error())
-	}
+	// TgRoutes（Telegram webhook 推送）
+	TgRoutes := api.Group("/tg")
+	tgBot.SetupBotRoutes(TgRoutes, bot)
+
+	return r
 }
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cryptoSelect/backendapi/config"
+	"github.com/cryptoSelect/backendapi/openapi"
+	"github.com/cryptoSelect/backendapi/tgBot"
+	"github.com/cryptoSelect/backendapi/utils/logger"
+	"github.com/gin-gonic/gin"
+)
+
+func testRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+	logger.Init("test")
+	gin.SetMode(gin.TestMode)
+	old := config.Cfg
+	config.Cfg = &config.ServerConfig{Mode: "test", TelegramWebhookSecret: "tg-secret"}
+	t.Cleanup(func() { config.Cfg = old })
+	return setupRouter(tgBot.New(nil))
+}
+
+// 每个注册的路由都需在 openapi.Operations 中登记，反之亦然
+func TestRoutesMatchOpenAPI(t *testing.T) {
+	undocumented, unregistered := openapi.CheckRoutes(testRouter(t).Routes())
+	if len(undocumented) > 0 {
+		t.Errorf("routes missing from openapi/operations.go: %v", undocumented)
+	}
+	if len(unregistered) > 0 {
+		t.Errorf("operations without a route: %v", unregistered)
+	}
+}
+
+// Telegram 推送不经过开发模式的请求校验，由 HandleWebhook 自行校验 secret_token 并返回原始响应
+func TestTelegramWebhookSkipsValidation(t *testing.T) {
+	r := testRouter(t)
+	req := httptest.NewRequest("POST", "/api/tg/webhook", strings.NewReader(""))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusUnauthorized || strings.Contains(w.Body.String(), "invalid_request") {
+		t.Fatalf("got %d %s, want the webhook handler's 401", w.Code, w.Body.String())
+	}
+}
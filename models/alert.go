@@ -0,0 +1,51 @@
+// Package models 本服务自有的数据表（用户、订阅、行情表定义在 cryptoSelect/public）
+package models
+
+import "time"
+
+// AlertSnapshot 告警调度器最近一次处理的 SymbolRecord 快照，按 symbol+cycle 唯一，用于检测信号变化
+type AlertSnapshot struct {
+	ID              uint      `gorm:"primaryKey;comment:主键ID"`
+	Symbol          string    `gorm:"uniqueIndex:idx_alert_snapshot_symbol_cycle;not null;comment:交易对"`
+	Cycle           string    `gorm:"uniqueIndex:idx_alert_snapshot_symbol_cycle;not null;comment:周期"`
+	Record          string    `gorm:"type:text;not null;comment:SymbolRecord JSON"`
+	RecordUpdatedAt time.Time `gorm:"comment:快照对应记录的更新时间"`
+	UpdatedAt       time.Time `gorm:"comment:更新时间"`
+}
+
+func (AlertSnapshot) TableName() string {
+	return "alert_snapshot"
+}
+
+// AlertDelivery 已推送的告警事件，(user_id, channel, event_key) 唯一，避免重启后重复推送
+type AlertDelivery struct {
+	ID        uint      `gorm:"primaryKey;comment:主键ID"`
+	UserID    uint      `gorm:"uniqueIndex:idx_alert_delivery_user_channel_event;not null;comment:用户ID"`
+	Channel   string    `gorm:"uniqueIndex:idx_alert_delivery_user_channel_event;not null;comment:推送通道(telegram)"`
+	EventKey  string    `gorm:"uniqueIndex:idx_alert_delivery_user_channel_event;not null;comment:事件唯一键"`
+	Symbol    string    `gorm:"index;comment:交易对"`
+	Cycle     string    `gorm:"comment:周期"`
+	CreatedAt time.Time `gorm:"comment:创建时间"`
+}
+
+func (AlertDelivery) TableName() string {
+	return "alert_delivery"
+}
+
+// AlertPending 推迟（免打扰）或推送失败、等待重试的告警，(user_id, channel, event_key) 唯一
+type AlertPending struct {
+	ID             uint      `gorm:"primaryKey;comment:主键ID"`
+	UserID         uint      `gorm:"uniqueIndex:idx_alert_pending_user_channel_event;not null;comment:用户ID"`
+	SubscriptionID uint      `gorm:"not null;comment:订阅ID，重试时据此重新读取订阅用户"`
+	Channel        string    `gorm:"uniqueIndex:idx_alert_pending_user_channel_event;not null;comment:推送通道"`
+	EventKey       string    `gorm:"uniqueIndex:idx_alert_pending_user_channel_event;not null;comment:事件唯一键"`
+	Event          string    `gorm:"type:text;not null;comment:事件 JSON"`
+	Attempts       int       `gorm:"not null;default:0;comment:失败次数(推迟不计)"`
+	NextAttemptAt  time.Time `gorm:"index;not null;comment:下次尝试时间"`
+	LastError      string    `gorm:"type:text;comment:最近一次失败原因"`
+	CreatedAt      time.Time `gorm:"comment:创建时间"`
+}
+
+func (AlertPending) TableName() string {
+	return "alert_pending"
+}
@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// API key 权限范围
+const (
+	ScopeReadSymbol        = "read:symbol"
+	ScopeReadFunds         = "read:funds"
+	ScopeWriteSubscription = "write:subscription"
+)
+
+// APIKey 用户创建的 API key，只保存哈希，明文仅在创建时返回一次
+type APIKey struct {
+	ID                 uint       `gorm:"primaryKey;comment:主键ID"`
+	UserID             uint       `gorm:"index;not null;comment:用户ID"`
+	Name               string     `gorm:"size:64;not null;comment:名称"`
+	Prefix             string     `gorm:"size:16;not null;comment:明文前缀，便于用户识别"`
+	KeyHash            string     `gorm:"uniqueIndex;size:64;not null;comment:key 的 SHA-256"`
+	Scopes             string     `gorm:"size:255;not null;comment:权限范围，逗号分隔"`
+	RateLimitPerMinute int        `gorm:"not null;default:0;comment:每分钟请求数，0 表示使用默认值"`
+	LastUsedAt         *time.Time `gorm:"comment:最近使用时间"`
+	RevokedAt          *time.Time `gorm:"comment:吊销时间"`
+	CreatedAt          time.Time  `gorm:"comment:创建时间"`
+}
+
+func (APIKey) TableName() string {
+	return "api_key"
+}
@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// AdminAuditLog 管理员操作审计日志
+type AdminAuditLog struct {
+	ID         uint      `json:"id" gorm:"primaryKey;comment:主键ID"`
+	ActorID    uint      `json:"actor_id" gorm:"index;not null;comment:操作的管理员用户ID"`
+	Action     string    `json:"action" gorm:"size:64;not null;comment:动作，如 user.disable"`
+	TargetType string    `json:"target_type" gorm:"size:32;comment:对象类型(user/subscription)"`
+	TargetID   uint      `json:"target_id" gorm:"index;comment:对象ID"`
+	Detail     string    `json:"detail" gorm:"type:text;comment:操作详情(JSON)"`
+	IP         string    `json:"ip" gorm:"size:64;comment:操作 IP"`
+	CreatedAt  time.Time `json:"created_at" gorm:"index;comment:创建时间"`
+}
+
+func (AdminAuditLog) TableName() string {
+	return "admin_audit_log"
+}
@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// RateLimitBucket 令牌桶状态，多实例部署时共享限流计数
+type RateLimitBucket struct {
+	Key        string    `gorm:"primaryKey;size:255;comment:限流键，如 login:ip:1.2.3.4"`
+	Tokens     float64   `gorm:"not null;comment:剩余令牌数"`
+	RefilledAt time.Time `gorm:"index;not null;comment:上次补充令牌时间"`
+}
+
+func (RateLimitBucket) TableName() string {
+	return "rate_limit_bucket"
+}
+
+// LoginLockout 登录失败计数与锁定状态
+type LoginLockout struct {
+	Key           string     `gorm:"primaryKey;size:255;comment:锁定键，如 login:user@example.com"`
+	Failures      int        `gorm:"not null;default:0;comment:连续失败次数"`
+	LastFailureAt time.Time  `gorm:"index;not null;comment:最近失败时间"`
+	LockedUntil   *time.Time `gorm:"comment:锁定截止时间"`
+}
+
+func (LoginLockout) TableName() string {
+	return "login_lockout"
+}
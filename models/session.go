@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// Session 登录会话：access token 的 jti 即会话 ID，refresh token 只保存哈希，每次刷新轮换
+type Session struct {
+	ID               string     `gorm:"primaryKey;size:32;comment:会话ID(access token jti)"`
+	UserID           uint       `gorm:"index;not null;comment:用户ID"`
+	RefreshTokenHash string     `gorm:"uniqueIndex;size:64;not null;comment:当前 refresh token 的 SHA-256"`
+	PrevRefreshHash  string     `gorm:"index;size:64;comment:上一个 refresh token 的 SHA-256，用于检测重放"`
+	UserAgent        string     `gorm:"size:512;comment:登录设备 User-Agent"`
+	IP               string     `gorm:"size:64;comment:登录 IP"`
+	CreatedAt        time.Time  `gorm:"comment:创建时间"`
+	LastSeenAt       time.Time  `gorm:"comment:最近活跃时间"`
+	ExpiresAt        time.Time  `gorm:"index;comment:refresh token 过期时间"`
+	RevokedAt        *time.Time `gorm:"comment:注销时间"`
+}
+
+func (Session) TableName() string {
+	return "session"
+}
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// SubscriptionRule 订阅下的告警规则，一条订阅可有多条，如 "rsi >= 70"；(subscription_id, expr) 唯一
+type SubscriptionRule struct {
+	ID             uint      `gorm:"primaryKey;comment:主键ID"`
+	SubscriptionID uint      `gorm:"uniqueIndex:idx_subscription_rule_subscription_expr;not null;comment:订阅ID"`
+	UserID         uint      `gorm:"index;not null;comment:用户ID"`
+	Expr           string    `gorm:"uniqueIndex:idx_subscription_rule_subscription_expr;not null;comment:规则表达式(规范化后)"`
+	CreatedAt      time.Time `gorm:"comment:创建时间"`
+	UpdatedAt      time.Time `gorm:"comment:更新时间"`
+}
+
+func (SubscriptionRule) TableName() string {
+	return "subscription_rule"
+}
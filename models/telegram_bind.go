@@ -0,0 +1,66 @@
+package models
+
+import "time"
+
+// TelegramBindToken Telegram 绑定 token：用户发起绑定时创建，Bot 收到 /start <token> 后写入 telegram_id
+type TelegramBindToken struct {
+	Token      string    `gorm:"primaryKey;size:64;comment:一次性绑定 token"`
+	UserID     uint      `gorm:"index;not null;comment:发起绑定的用户ID"`
+	TelegramID string    `gorm:"column:telegram_id;comment:Bot 确认后写入的 Telegram 用户ID"`
+	ExpiresAt  time.Time `gorm:"index;not null;comment:过期时间"`
+	CreatedAt  time.Time `gorm:"comment:创建时间"`
+	UpdatedAt  time.Time `gorm:"comment:更新时间"`
+}
+
+func (TelegramBindToken) TableName() string {
+	return "telegram_bind_token"
+}
+
+// TelegramBindNonce 已使用的绑定回调 nonce，主键唯一约束保证多实例间同一 nonce 只能使用一次
+type TelegramBindNonce struct {
+	Nonce     string    `gorm:"primaryKey;size:64;comment:回调 nonce"`
+	ExpiresAt time.Time `gorm:"index;not null;comment:过期时间，过期后由清理任务删除"`
+	CreatedAt time.Time `gorm:"comment:创建时间"`
+}
+
+func (TelegramBindNonce) TableName() string {
+	return "telegram_bind_nonce"
+}
+
+// TelegramProfile 用户绑定的 Telegram 账号资料，来自 /start 消息的 From
+type TelegramProfile struct {
+	ID         uint      `json:"id" gorm:"primaryKey;comment:主键ID"`
+	UserID     uint      `json:"user_id" gorm:"uniqueIndex;not null;comment:用户ID"`
+	TelegramID string    `json:"telegram_id" gorm:"column:telegram_id;not null;comment:Telegram 用户ID"`
+	Username   string    `json:"username" gorm:"comment:Telegram 用户名(不含 @)"`
+	FirstName  string    `json:"first_name" gorm:"comment:Telegram 名字"`
+	BoundAt    time.Time `json:"bound_at" gorm:"comment:绑定时间"`
+	UpdatedAt  time.Time `json:"updated_at" gorm:"comment:更新时间"`
+}
+
+func (TelegramProfile) TableName() string {
+	return "telegram_profile"
+}
+
+// 绑定历史动作
+const (
+	TelegramBindActionBind     = "bind"     // 绑定
+	TelegramBindActionUnbind   = "unbind"   // 用户主动解绑
+	TelegramBindActionReplaced = "replaced" // 被新的 Telegram 账号替换
+	TelegramBindActionAdmin    = "admin"    // 管理员强制解绑
+	TelegramBindActionDeleted  = "deleted"  // 注销账号时解绑
+)
+
+// TelegramBindLog Telegram 绑定历史
+type TelegramBindLog struct {
+	ID         uint      `json:"id" gorm:"primaryKey;comment:主键ID"`
+	UserID     uint      `json:"user_id" gorm:"index;not null;comment:用户ID"`
+	TelegramID string    `json:"telegram_id" gorm:"column:telegram_id;not null;comment:Telegram 用户ID"`
+	Username   string    `json:"username" gorm:"comment:Telegram 用户名"`
+	Action     string    `json:"action" gorm:"not null;comment:动作(bind/unbind/replaced/admin/deleted)"`
+	CreatedAt  time.Time `json:"created_at" gorm:"comment:创建时间"`
+}
+
+func (TelegramBindLog) TableName() string {
+	return "telegram_bind_log"
+}
@@ -0,0 +1,47 @@
+package models
+
+import "time"
+
+// TwoFactor 用户的 TOTP 两步验证配置；EnabledAt 为空表示已生成密钥但尚未用首个验证码确认
+type TwoFactor struct {
+	ID           uint       `gorm:"primaryKey;comment:主键ID"`
+	UserID       uint       `gorm:"uniqueIndex;not null;comment:用户ID"`
+	Secret       string     `gorm:"size:64;not null;comment:TOTP 密钥(base32)"`
+	LastUsedStep int64      `gorm:"not null;default:0;comment:最近一次通过校验的时间步，防止验证码重放"`
+	EnabledAt    *time.Time `gorm:"comment:启用时间"`
+	CreatedAt    time.Time  `gorm:"comment:创建时间"`
+	UpdatedAt    time.Time  `gorm:"comment:更新时间"`
+}
+
+func (TwoFactor) TableName() string {
+	return "two_factor"
+}
+
+// RecoveryCode 两步验证恢复码，只保存哈希，每个只能使用一次
+type RecoveryCode struct {
+	ID        uint       `gorm:"primaryKey;comment:主键ID"`
+	UserID    uint       `gorm:"index;not null;comment:用户ID"`
+	CodeHash  string     `gorm:"size:64;not null;comment:恢复码 SHA-256"`
+	UsedAt    *time.Time `gorm:"comment:使用时间"`
+	CreatedAt time.Time  `gorm:"comment:创建时间"`
+}
+
+func (RecoveryCode) TableName() string {
+	return "recovery_code"
+}
+
+// LoginChallenge 密码校验通过后、两步验证完成前的登录挑战；挑战 token 只保存哈希
+type LoginChallenge struct {
+	TokenHash        string     `gorm:"primaryKey;size:64;comment:挑战 token 的 SHA-256"`
+	UserID           uint       `gorm:"index;not null;comment:用户ID"`
+	Attempts         int        `gorm:"not null;default:0;comment:验证失败次数"`
+	TelegramCodeHash string     `gorm:"size:64;comment:通过 Telegram 发送的验证码哈希"`
+	TelegramSentAt   *time.Time `gorm:"comment:Telegram 验证码发送时间"`
+	ExpiresAt        time.Time  `gorm:"index;not null;comment:过期时间"`
+	UsedAt           *time.Time `gorm:"comment:使用时间"`
+	CreatedAt        time.Time  `gorm:"comment:创建时间"`
+}
+
+func (LoginChallenge) TableName() string {
+	return "login_challenge"
+}
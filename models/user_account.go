@@ -0,0 +1,49 @@
+package models
+
+import "time"
+
+// UserAccount 用户账号状态扩展表（user_info 定义在 public 库，本服务新增的账号字段放在这里），按 user_id 一对一
+type UserAccount struct {
+	ID              uint       `gorm:"primaryKey;comment:主键ID"`
+	UserID          uint       `gorm:"uniqueIndex;not null;comment:用户ID"`
+	EmailVerifiedAt *time.Time `gorm:"comment:邮箱验证时间，为空表示未验证"`
+	TelegramOnly    bool       `gorm:"not null;default:false;comment:通过 Telegram 登录创建、尚未设置邮箱密码"`
+	Role            string     `gorm:"size:16;not null;default:user;comment:角色(user/admin)"`
+	DisabledAt      *time.Time `gorm:"comment:停用时间，为空表示正常"`
+	DeletedAt       *time.Time `gorm:"index;comment:用户注销时间，宽限期后彻底删除"`
+	CreatedAt       time.Time  `gorm:"comment:创建时间"`
+	UpdatedAt       time.Time  `gorm:"comment:更新时间"`
+}
+
+func (UserAccount) TableName() string {
+	return "user_account"
+}
+
+// 用户角色
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
+// 一次性令牌用途
+const (
+	TokenPurposeVerifyEmail   = "verify_email"
+	TokenPurposeResetPassword = "reset_password"
+	TokenPurposeChangeEmail   = "change_email"
+)
+
+// UserToken 邮件中发送的一次性令牌，只保存哈希，使用后写入 used_at
+type UserToken struct {
+	ID        uint       `gorm:"primaryKey;comment:主键ID"`
+	UserID    uint       `gorm:"index;not null;comment:用户ID"`
+	Purpose   string     `gorm:"size:32;not null;comment:用途(verify_email/reset_password/change_email)"`
+	TokenHash string     `gorm:"uniqueIndex;size:64;not null;comment:令牌 SHA-256"`
+	Email     string     `gorm:"comment:令牌发送到的邮箱（修改邮箱时为新邮箱）"`
+	ExpiresAt time.Time  `gorm:"index;not null;comment:过期时间"`
+	UsedAt    *time.Time `gorm:"comment:使用时间"`
+	CreatedAt time.Time  `gorm:"comment:创建时间"`
+}
+
+func (UserToken) TableName() string {
+	return "user_token"
+}
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// UserPreference 用户偏好设置，未设置的字段使用默认值
+type UserPreference struct {
+	ID            uint      `gorm:"primaryKey;comment:主键ID"`
+	UserID        uint      `gorm:"uniqueIndex;not null;comment:用户ID"`
+	Language      string    `gorm:"size:8;comment:语言(zh/en)，为空使用默认 zh"`
+	Timezone      string    `gorm:"size:64;comment:IANA 时区，如 Asia/Shanghai，为空使用默认"`
+	PageSize      int       `gorm:"not null;default:0;comment:标的列表每页条数，0 使用系统默认"`
+	DefaultCycles string    `gorm:"size:64;comment:默认周期，逗号分隔，如 1h,4h"`
+	QuietStart    string    `gorm:"size:5;comment:免打扰开始时间 HH:MM，为空表示关闭"`
+	QuietEnd      string    `gorm:"size:5;comment:免打扰结束时间 HH:MM"`
+	TelegramMuted bool      `gorm:"not null;default:false;comment:关闭 Telegram 通知"`
+	WebhookMuted  bool      `gorm:"not null;default:false;comment:关闭 Webhook 通知"`
+	CreatedAt     time.Time `gorm:"comment:创建时间"`
+	UpdatedAt     time.Time `gorm:"comment:更新时间"`
+}
+
+func (UserPreference) TableName() string {
+	return "user_preference"
+}
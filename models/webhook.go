@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+// Webhook 用户配置的外发 Webhook，订阅事件以 HMAC 签名的 POST 推送到 URL
+type Webhook struct {
+	ID        uint      `gorm:"primaryKey;comment:主键ID"`
+	UserID    uint      `gorm:"index;not null;comment:用户ID"`
+	URL       string    `gorm:"not null;comment:推送地址"`
+	Secret    string    `gorm:"not null;comment:HMAC 签名密钥"`
+	Enabled   bool      `gorm:"not null;default:true;comment:是否启用"`
+	CreatedAt time.Time `gorm:"comment:创建时间"`
+	UpdatedAt time.Time `gorm:"comment:更新时间"`
+}
+
+func (Webhook) TableName() string {
+	return "webhook"
+}
+
+// WebhookDelivery Webhook 投递日志，每次尝试一条；失败且可重试时记录下次重试时间与请求体
+type WebhookDelivery struct {
+	ID         uint   `json:"id" gorm:"primaryKey;comment:主键ID"`
+	WebhookID  uint   `json:"webhook_id" gorm:"index:idx_webhook_delivery_hook_event;not null;comment:Webhook ID"`
+	EventKey   string `json:"event_key" gorm:"index:idx_webhook_delivery_hook_event;not null;comment:事件唯一键"`
+	EventType  string `json:"event_type" gorm:"comment:事件类型"`
+	Attempt    int    `json:"attempt" gorm:"comment:第几次尝试"`
+	StatusCode int    `json:"status_code" gorm:"comment:响应状态码"`
+	Success    bool   `json:"success" gorm:"comment:是否成功(2xx)"`
+	Error      string `json:"error" gorm:"type:text;comment:失败原因"`
+	DurationMs int64  `json:"duration_ms" gorm:"comment:耗时(毫秒)"`
+	// NextAttemptAt 排队等待重试时为下次尝试时间，重试被认领或无需重试时为空
+	NextAttemptAt *time.Time `json:"next_attempt_at,omitempty" gorm:"index;comment:下次重试时间"`
+	Payload       string     `json:"-" gorm:"type:text;comment:待重试的请求体"`
+	CreatedAt     time.Time  `json:"created_at" gorm:"index;comment:创建时间"`
+}
+
+func (WebhookDelivery) TableName() string {
+	return "webhook_delivery"
+}
@@ -0,0 +1,287 @@
+package openapi
+
+import (
+	"encoding/json"
+	"strings"
+	"sync"
+
+	"github.com/cryptoSelect/backendapi/api/auth"
+	"github.com/cryptoSelect/backendapi/response"
+	"github.com/cryptoSelect/backendapi/tgBot"
+)
+
+type document struct {
+	OpenAPI    string                                 `json:"openapi"`
+	Info       info                                   `json:"info"`
+	Tags       []tag                                  `json:"tags"`
+	Paths      map[string]map[string]*operationObject `json:"paths"`
+	Components components                             `json:"components"`
+}
+
+type info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description"`
+}
+
+type tag struct {
+	Name string `json:"name"`
+}
+
+type components struct {
+	Schemas         map[string]*Schema        `json:"schemas"`
+	SecuritySchemes map[string]securityScheme `json:"securitySchemes"`
+}
+
+type securityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+	In           string `json:"in,omitempty"`
+	Name         string `json:"name,omitempty"`
+	Description  string `json:"description,omitempty"`
+}
+
+type operationObject struct {
+	Tags        []string                 `json:"tags"`
+	Summary     string                   `json:"summary"`
+	Description string                   `json:"description,omitempty"`
+	OperationID string                   `json:"operationId"`
+	Security    []map[string][]string    `json:"security,omitempty"`
+	Parameters  []parameterObject        `json:"parameters,omitempty"`
+	RequestBody *requestBody             `json:"requestBody,omitempty"`
+	Responses   map[string]responseEntry `json:"responses"`
+	ErrorCodes  []string                 `json:"x-error-codes,omitempty"`
+}
+
+type parameterObject struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Style       string  `json:"style,omitempty"`
+	Explode     bool    `json:"explode,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type requestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]mediaType `json:"content"`
+}
+
+type responseEntry struct {
+	Description string               `json:"description"`
+	Content     map[string]mediaType `json:"content,omitempty"`
+}
+
+type mediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+var (
+	specOnce  sync.Once
+	specBytes []byte
+)
+
+// Spec OpenAPI 文档（JSON），首次调用时生成
+func Spec() []byte {
+	specOnce.Do(func() {
+		b, err := json.Marshal(buildDocument())
+		if err != nil {
+			panic("openapi: marshal document: " + err.Error())
+		}
+		specBytes = b
+	})
+	return specBytes
+}
+
+// responseSchema 统一响应结构 Response{error, code, message, data}
+func responseSchema() *Schema {
+	return object(map[string]*Schema{
+		"error":   {Type: "string", Description: "错误码，见 ErrorCode；成功时为空字符串"},
+		"code":    {Type: "integer", Description: "业务状态码：200 成功，其余为错误码对应的 HTTP 状态码（400/401/403/404/409/429/500）"},
+		"message": {Type: "string", Description: "错误码的文案，按 Accept-Language 选择语言；成功时不返回"},
+		"data":    {Description: "成功时为接口数据，失败时为 null 或错误详情"},
+	})
+}
+
+// errorCodes 接口可能返回的全部错误码：自身的错误码加上鉴权、限流等通用错误码
+func (op *Operation) errorCodes() []string {
+	codes := append([]string{}, op.Errors...)
+	switch op.Auth {
+	case AuthOptional:
+		codes = append(codes, "invalid_api_key", "insufficient_scope", "account_disabled")
+	case AuthUserOrAPIKey:
+		codes = append(codes, "unauthorized", "session_revoked", "invalid_api_key", "insufficient_scope", "account_disabled")
+	case AuthUser:
+		codes = append(codes, "unauthorized", "session_revoked")
+	case AuthAdmin:
+		codes = append(codes, "unauthorized", "session_revoked", "forbidden")
+	}
+	if op.Verified {
+		codes = append(codes, "email_not_verified")
+	}
+	if op.RateLimited {
+		codes = append(codes, "too_many_requests")
+	}
+	if !op.Raw {
+		codes = append(codes, "server_error")
+	}
+	return dedupe(codes)
+}
+
+func (op *Operation) security() []map[string][]string {
+	bearer := map[string][]string{"bearerAuth": {}}
+	apiKey := map[string][]string{"apiKey": {}}
+	switch op.Auth {
+	case AuthOptional:
+		return []map[string][]string{{}, bearer, apiKey}
+	case AuthUser, AuthAdmin:
+		return []map[string][]string{bearer}
+	case AuthUserOrAPIKey:
+		return []map[string][]string{bearer, apiKey}
+	case AuthTelegram:
+		return []map[string][]string{{"telegramSecret": {}}}
+	case AuthBindCallback:
+		return []map[string][]string{{"bindSignature": {}}}
+	}
+	return nil
+}
+
+func (op *Operation) description() string {
+	var notes []string
+	switch op.Auth {
+	case AuthOptional:
+		notes = append(notes, "公开；登录 token 无效时按匿名访问，携带 API key 时校验，API key 需 "+op.Scope+" 权限")
+	case AuthUser:
+		notes = append(notes, "需登录")
+	case AuthUserOrAPIKey:
+		notes = append(notes, "需登录，或带 "+op.Scope+" 权限的 API key")
+	case AuthAdmin:
+		notes = append(notes, "需管理员")
+	case AuthBindCallback:
+		notes = append(notes, "请求头 "+auth.HeaderBindTimestamp+"、"+auth.HeaderBindNonce+"、"+auth.HeaderBindSignature+" 为回调签名")
+	}
+	if op.Verified {
+		notes = append(notes, "需已验证邮箱")
+	}
+	if op.RateLimited {
+		notes = append(notes, "限流")
+	}
+	return strings.Join(notes, "；")
+}
+
+// operationID 如 GET /api/user/webhooks/:id -> get_user_webhooks_id
+func (op *Operation) operationID() string {
+	id := strings.ToLower(op.Method)
+	for _, seg := range strings.Split(strings.TrimPrefix(op.Path, "/api"), "/") {
+		seg = strings.NewReplacer(":", "", "-", "_", ".", "_").Replace(seg)
+		if seg != "" {
+			id += "_" + seg
+		}
+	}
+	return id
+}
+
+// openAPIPath gin 路径转为 OpenAPI 路径：/webhooks/:id -> /webhooks/{id}
+func openAPIPath(path string) string {
+	segs := strings.Split(path, "/")
+	for i, seg := range segs {
+		if strings.HasPrefix(seg, ":") {
+			segs[i] = "{" + seg[1:] + "}"
+		}
+	}
+	return strings.Join(segs, "/")
+}
+
+func (op *Operation) object() *operationObject {
+	o := &operationObject{
+		Tags:        []string{op.Tag},
+		Summary:     op.Summary,
+		Description: op.description(),
+		OperationID: op.operationID(),
+		Security:    op.security(),
+		ErrorCodes:  op.errorCodes(),
+	}
+	for _, p := range append(op.pathParams(), op.queryParams()...) {
+		po := parameterObject{Name: p.Name, In: p.In, Description: p.Description, Required: p.Required, Style: p.Style, Schema: p.Schema}
+		if p.Style == "deepObject" {
+			po.Explode = true
+		}
+		o.Parameters = append(o.Parameters, po)
+	}
+	if op.Body != nil {
+		o.RequestBody = &requestBody{
+			Required: !op.BodyOptional,
+			Content:  map[string]mediaType{"application/json": {Schema: SchemaOf(op.Body)}},
+		}
+	}
+	switch {
+	case op.Path == "/api/docs":
+		o.Responses = map[string]responseEntry{"200": {Description: "HTML", Content: map[string]mediaType{"text/html": {Schema: str()}}}}
+	case op.Raw:
+		o.Responses = map[string]responseEntry{"200": {Description: "OK", Content: map[string]mediaType{"application/json": {Schema: SchemaOf(op.Data)}}}}
+	default:
+		data := SchemaOf(op.Data)
+		envelope := &Schema{AllOf: []*Schema{
+			{Ref: "#/components/schemas/Response"},
+			object(map[string]*Schema{"data": data}),
+		}}
+		o.Responses = map[string]responseEntry{"200": {
+			Description: "统一响应；code 不为 200 时 error 为错误码，可能的错误码见 x-error-codes",
+			Content:     map[string]mediaType{"application/json": {Schema: envelope}},
+		}}
+	}
+	return o
+}
+
+func buildDocument() *document {
+	doc := &document{
+		OpenAPI: "3.0.3",
+		Info: info{
+			Title:   "CryptoSelect Backend API",
+			Version: "1.0.0",
+			Description: "除 /api/tg/webhook 与文档接口外，所有接口均返回 Response{error, code, message, data}，" +
+				"以 code 判断成功与否，失败时 error 为错误码、message 为按 Accept-Language 选择的文案。" +
+				"默认 HTTP 状态码恒为 200；请求头 " + response.HeaderAPIVersion + ": 2 时 HTTP 状态码与 code 一致。" +
+				"全部错误码见 GET /api/errors。",
+		},
+		Paths: map[string]map[string]*operationObject{},
+		Components: components{
+			Schemas: map[string]*Schema{"Response": responseSchema()},
+			SecuritySchemes: map[string]securityScheme{
+				"bearerAuth":     {Type: "http", Scheme: "bearer", BearerFormat: "JWT", Description: "登录、注册或刷新返回的 access token"},
+				"apiKey":         {Type: "apiKey", In: "header", Name: auth.HeaderAPIKey, Description: "用户创建的 API key，按权限访问"},
+				"telegramSecret": {Type: "apiKey", In: "header", Name: tgBot.HeaderSecretToken, Description: "setWebhook 时注册的 secret_token"},
+				"bindSignature":  {Type: "apiKey", In: "header", Name: auth.HeaderBindSignature, Description: "HMAC-SHA256(TelegramCallbackSecret, timestamp.nonce.body)"},
+			},
+		},
+	}
+	seenTags := map[string]bool{}
+	for i := range Operations {
+		op := &Operations[i]
+		if !seenTags[op.Tag] {
+			seenTags[op.Tag] = true
+			doc.Tags = append(doc.Tags, tag{Name: op.Tag})
+		}
+		path := openAPIPath(op.Path)
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = map[string]*operationObject{}
+		}
+		doc.Paths[path][strings.ToLower(op.Method)] = op.object()
+	}
+	doc.Components.Schemas["ErrorCode"] = &Schema{Type: "string", Enum: response.Codes(), Description: "Response.error 的取值，文案见 GET /api/errors"}
+	return doc
+}
+
+func dedupe(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	out := values[:0]
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			out = append(out, v)
+		}
+	}
+	return out
+}
@@ -0,0 +1,424 @@
+package openapi
+
+import (
+	"github.com/cryptoSelect/backendapi/api/admin"
+	"github.com/cryptoSelect/backendapi/api/auth"
+	"github.com/cryptoSelect/backendapi/api/funds"
+	"github.com/cryptoSelect/backendapi/api/subscription"
+	"github.com/cryptoSelect/backendapi/api/symbol"
+	"github.com/cryptoSelect/backendapi/api/user"
+	"github.com/cryptoSelect/backendapi/models"
+	"github.com/cryptoSelect/backendapi/preference"
+	"github.com/cryptoSelect/backendapi/response"
+	"github.com/cryptoSelect/backendapi/screener"
+)
+
+// 分页参数
+var (
+	pageParam     = query("page", "页码，从 1 开始", integer())
+	pageSizeParam = query("page_size", "每页条数，默认取配置，最大 100", integer())
+)
+
+// loginData 登录成功返回 token；开启两步验证时返回挑战，需调用 /api/auth/2fa/verify
+func loginData() *Schema {
+	return &Schema{OneOf: []*Schema{SchemaOf(auth.AuthResponse{}), SchemaOf(auth.TwoFactorChallenge{})}}
+}
+
+// symbolListData 标的列表，指定 fields 时每项只含所选字段
+func symbolListData() *Schema {
+	s := SchemaOf(symbol.ListData{})
+	s.Properties["data"] = &Schema{Type: "array", Items: SchemaOf(symbol.SymbolItem{}), Description: "指定 fields 时每项只含所选字段"}
+	return s
+}
+
+// Operations 所有路由的接口描述，顺序即文档中的顺序
+var Operations = []Operation{
+	// 标的
+	{
+		Method: "GET", Path: "/api/symbol/", Tag: "标的", Summary: "查询标的列表",
+		Auth: AuthOptional, Scope: models.ScopeReadSymbol,
+		Params: []Param{
+			query("symbol", "交易对，如 BTCUSDT", str()),
+			query("cycle", "周期；不传时已登录用户只看偏好中的默认周期", enum(preference.Cycles...)),
+			query("shape", "分型：1 顶分型，2 底分型", integer()),
+			query("rsi", "RSI", number()),
+			query("cross_type", "MACD 交叉类型：1~4", integer()),
+			query("filter", "筛选表达式，如 rsi between 30 and 40 and cycle in (1h,4h)", &Schema{Type: "string", MaxLength: intPtr(screener.MaxLength)}),
+			query("fields", "只返回所选字段，逗号分隔，如 symbol,cycle,rsi", str()),
+			query("sort", "多字段排序，逗号分隔，- 前缀表示倒序，如 -taker_buy_ratio,rsi", str()),
+			query("nulls", "NaN/NULL 排在最前（first）或最后（默认）", enum("first", "last")),
+			query("order_by", "旧的单字段排序，sort 优先", str()),
+			query("order_type", "order_by 的方向，默认 desc", enum("asc", "desc")),
+			pageParam,
+			query("page_size", "每页条数，覆盖偏好设置", between(integer(), 1, 100)),
+			query("cursor", "游标分页，第一页传空值，之后传上一页的 next_cursor", str()),
+			query("count", "false 时不统计总数", boolean()),
+		},
+		Data:   symbolListData(),
+		Errors: []string{"invalid_filter", "invalid_fields", "invalid_sort", "invalid_page_size", "invalid_cursor"},
+	},
+	{
+		Method: "GET", Path: "/api/symbol/confluence", Tag: "标的", Summary: "多周期共振筛选",
+		Auth: AuthOptional, Scope: models.ScopeReadSymbol,
+		Params: []Param{
+			requiredQuery("cycles", "至少两个周期，逗号分隔，如 1h,4h,1d", str()),
+			query("filter", "对所有周期生效的筛选表达式", str()),
+			{Name: "when", In: "query", Style: "deepObject", Description: "只对某个周期生效的筛选表达式，如 when[15m]=rsi<30",
+				Schema: &Schema{Type: "object", AdditionalProperties: str()}},
+			query("same", "要求各周期取值相同的字段，如 cross_type", str()),
+			query("min_match", "至少满足条件的周期数，默认全部", integer()),
+			query("symbol", "交易对", str()),
+			query("order_by", "排序字段，默认 score", enum("score", "symbol")),
+			query("order_type", "排序方向，默认 desc", enum("asc", "desc")),
+			pageParam,
+			pageSizeParam,
+		},
+		Data:   symbol.ConfluenceData{},
+		Errors: []string{"invalid_cycle", "invalid_cycles", "invalid_filter", "invalid_field", "invalid_min_match"},
+	},
+
+	// 资金流向
+	{
+		Method: "GET", Path: "/api/funds/", Tag: "资金流向", Summary: "查询资金流向数据",
+		Auth: AuthOptional, Scope: models.ScopeReadFunds,
+		Params:       []Param{requiredQuery("symbol", "币种，如 BTC 或 BTCUSDT", str())},
+		Data:         funds.ListData{},
+		Errors:       []string{"invalid_request"},
+		LegacyStatus: true,
+	},
+
+	// 认证
+	{
+		Method: "POST", Path: "/api/auth/login", Tag: "认证", Summary: "邮箱密码登录",
+		RateLimited: true, Body: auth.LoginRequest{}, Data: loginData(),
+		Errors:       []string{"invalid_request", "email_or_password_wrong", "account_locked", "account_disabled"},
+		LegacyStatus: true,
+	},
+	{
+		Method: "POST", Path: "/api/auth/register", Tag: "认证", Summary: "注册并发送验证邮件",
+		RateLimited: true, Body: auth.RegisterRequest{}, Data: auth.AuthResponse{},
+		Errors: []string{"invalid_request", "email_reserved", "email_already_registered"},
+	},
+	{
+		Method: "POST", Path: "/api/auth/refresh", Tag: "认证", Summary: "用 refresh token 换取新 token，旧 refresh token 失效",
+		RateLimited: true, Body: auth.RefreshRequest{}, Data: auth.AuthResponse{},
+		Errors: []string{"invalid_request", "invalid_refresh_token", "account_disabled"},
+	},
+	{
+		Method: "POST", Path: "/api/auth/logout", Tag: "认证", Summary: "注销当前会话",
+		Auth: AuthUser, Data: okData(),
+	},
+	{
+		Method: "POST", Path: "/api/auth/logout/all", Tag: "认证", Summary: "退出所有设备",
+		Auth: AuthUser, Data: object(map[string]*Schema{"ok": boolean(), "revoked": integer()}),
+	},
+	{
+		Method: "POST", Path: "/api/auth/verify-email", Tag: "认证", Summary: "使用邮件中的令牌验证邮箱",
+		RateLimited: true, Body: auth.TokenRequest{}, Data: okData(),
+		Errors: []string{"invalid_request", "invalid_or_expired_token"},
+	},
+	{
+		Method: "POST", Path: "/api/auth/verify-email/resend", Tag: "认证", Summary: "重新发送验证邮件",
+		Auth: AuthUser, RateLimited: true, Data: okData(),
+		Errors: []string{"email_not_set", "email_already_verified", "send_mail_failed"},
+	},
+	{
+		Method: "POST", Path: "/api/auth/password/forgot", Tag: "认证", Summary: "发送重置密码邮件（邮箱不存在时同样返回成功）",
+		RateLimited: true, Body: auth.ForgotPasswordRequest{}, Data: okData(),
+		Errors: []string{"invalid_request"},
+	},
+	{
+		Method: "POST", Path: "/api/auth/password/reset", Tag: "认证", Summary: "使用一次性令牌设置新密码，并注销全部会话",
+		RateLimited: true, Body: auth.ResetPasswordRequest{}, Data: okData(),
+		Errors: []string{"invalid_request", "invalid_or_expired_token"},
+	},
+	{
+		Method: "POST", Path: "/api/auth/email/confirm", Tag: "认证", Summary: "使用新邮箱收到的令牌完成邮箱修改",
+		RateLimited: true, Body: auth.TokenRequest{}, Data: okData(),
+		Errors: []string{"invalid_request", "invalid_or_expired_token", "email_already_registered"},
+	},
+	{
+		Method: "POST", Path: "/api/auth/2fa/verify", Tag: "认证", Summary: "两步验证登录第二步：挑战 token + 验证码换取 token",
+		RateLimited: true, Body: withEnum(SchemaOf(auth.TwoFactorVerifyRequest{}), "method", "totp", "recovery", "telegram"), Data: auth.AuthResponse{},
+		Errors: []string{"invalid_request", "invalid_challenge", "invalid_code", "account_disabled"},
+	},
+	{
+		Method: "POST", Path: "/api/auth/2fa/telegram", Tag: "认证", Summary: "将登录验证码发送到绑定的 Telegram",
+		RateLimited: true, Body: auth.ChallengeRequest{}, Data: okData(),
+		Errors: []string{"invalid_request", "invalid_challenge", "telegram_not_bound"},
+	},
+	{
+		Method: "POST", Path: "/api/auth/tg/bind/start", Tag: "Telegram", Summary: "生成一次性绑定链接",
+		Auth: AuthUser, RateLimited: true, Data: auth.TgBindStartResp{},
+	},
+	{
+		Method: "GET", Path: "/api/auth/tg/bind/status", Tag: "Telegram", Summary: "查询绑定 token 是否已被 Bot 确认",
+		Params: []Param{requiredQuery("token", "tg/bind/start 返回的 token", str())},
+		Data:   auth.TgBindStatusResp{},
+		Errors: []string{"invalid_request", "bind_token_not_found"},
+	},
+	{
+		Method: "POST", Path: "/api/auth/tg/bind/confirm", Tag: "Telegram", Summary: "独立部署的 Bot 确认绑定（签名回调）",
+		Auth: AuthBindCallback, Body: auth.TgBindConfirmReq{}, Data: okData(),
+		Errors: []string{"invalid_request", "invalid_signature", "bind_token_not_found", "bind_token_used", "telegram_already_bound"},
+	},
+	{
+		Method: "DELETE", Path: "/api/auth/tg/bind", Tag: "Telegram", Summary: "解除当前用户的 Telegram 绑定",
+		Auth: AuthUser, Data: okData(),
+		Errors: []string{"telegram_not_bound", "telegram_only_account"},
+	},
+	{
+		Method: "POST", Path: "/api/auth/tg/login", Tag: "认证", Summary: "Telegram Login Widget 登录，未绑定账号的 Telegram 自动创建账号",
+		RateLimited: true, Body: auth.TelegramLoginRequest{}, Data: loginData(),
+		Errors: []string{"invalid_request", "invalid_telegram_login", "account_disabled"},
+	},
+
+	// 用户
+	{
+		Method: "GET", Path: "/api/user/me", Tag: "用户", Summary: "当前用户信息",
+		Auth: AuthUser, Data: user.MeData{},
+	},
+	{
+		Method: "GET", Path: "/api/user/export", Tag: "用户", Summary: "导出个人数据（JSON 附件）",
+		Auth: AuthUser, Data: user.ExportData{},
+	},
+	{
+		Method: "DELETE", Path: "/api/user", Tag: "用户", Summary: "注销账号，宽限期后彻底删除数据",
+		Auth: AuthUser, Body: user.DeleteAccountRequest{}, Data: okData(),
+		Errors: []string{"invalid_request", "password_wrong", "confirm_required", "invalid_code"},
+	},
+	{
+		Method: "POST", Path: "/api/user/credentials", Tag: "用户", Summary: "Telegram 登录创建的账号设置密码，邮箱确认后生效",
+		Auth: AuthUser, Body: user.CredentialsRequest{}, Data: okData(),
+		Errors: []string{"invalid_request", "credentials_already_set", "email_reserved", "email_already_registered"},
+	},
+	{
+		Method: "POST", Path: "/api/user/password", Tag: "用户", Summary: "修改密码，其他设备退出登录",
+		Auth: AuthUser, RateLimited: true, Body: user.ChangePasswordRequest{}, Data: okData(),
+		Errors: []string{"invalid_request", "password_wrong", "credentials_not_set"},
+	},
+	{
+		Method: "POST", Path: "/api/user/email", Tag: "用户", Summary: "修改邮箱，新邮箱确认后生效",
+		Auth: AuthUser, RateLimited: true, Body: user.ChangeEmailRequest{}, Data: okData(),
+		Errors: []string{"invalid_request", "password_wrong", "credentials_not_set", "email_unchanged", "email_reserved", "email_already_registered"},
+	},
+	{
+		Method: "GET", Path: "/api/user/preferences", Tag: "偏好", Summary: "查询偏好设置",
+		Auth: AuthUser, Data: preference.Preferences{},
+	},
+	{
+		Method: "PATCH", Path: "/api/user/preferences", Tag: "偏好", Summary: "部分更新偏好设置，未传的字段保持不变",
+		Auth: AuthUser, Body: preference.Patch{}, Data: preference.Preferences{},
+		Errors: []string{"invalid_request", "invalid_preference"},
+	},
+	{
+		Method: "GET", Path: "/api/user/sessions", Tag: "用户", Summary: "已登录设备列表",
+		Auth: AuthUser, Data: listData(auth.SessionItem{}),
+	},
+	{
+		Method: "DELETE", Path: "/api/user/sessions/:id", Tag: "用户", Summary: "注销某个会话",
+		Auth: AuthUser, Params: []Param{{Name: "id", In: "path", Description: "会话 ID", Required: true, Schema: str()}}, Data: okData(),
+		Errors: []string{"session_not_found"},
+	},
+
+	// 两步验证
+	{
+		Method: "GET", Path: "/api/user/2fa", Tag: "两步验证", Summary: "是否启用、剩余恢复码数量",
+		Auth: AuthUser, Data: auth.TwoFactorInfo{},
+	},
+	{
+		Method: "POST", Path: "/api/user/2fa/setup", Tag: "两步验证", Summary: "生成密钥与 otpauth:// 地址",
+		Auth: AuthUser, Data: object(map[string]*Schema{"secret": str(), "otpauth_uri": str()}),
+		Errors: []string{"two_factor_already_enabled"},
+	},
+	{
+		Method: "POST", Path: "/api/user/2fa/enable", Tag: "两步验证", Summary: "用首个验证码确认并启用，返回恢复码（仅此一次）",
+		Auth: AuthUser, Body: user.TwoFactorCodeRequest{}, Data: object(map[string]*Schema{"recovery_codes": {Type: "array", Items: str()}}),
+		Errors: []string{"invalid_request", "invalid_code", "two_factor_already_enabled", "two_factor_not_setup"},
+	},
+	{
+		Method: "POST", Path: "/api/user/2fa/disable", Tag: "两步验证", Summary: "用验证码或恢复码关闭",
+		Auth: AuthUser, Body: user.TwoFactorCodeRequest{}, Data: okData(),
+		Errors: []string{"invalid_request", "invalid_code", "two_factor_not_enabled"},
+	},
+	{
+		Method: "POST", Path: "/api/user/2fa/recovery-codes", Tag: "两步验证", Summary: "用验证码重新生成恢复码",
+		Auth: AuthUser, Body: user.TwoFactorCodeRequest{}, Data: object(map[string]*Schema{"recovery_codes": {Type: "array", Items: str()}}),
+		Errors: []string{"invalid_request", "invalid_code", "two_factor_not_enabled"},
+	},
+
+	// API key
+	{
+		Method: "GET", Path: "/api/user/api-keys", Tag: "API key", Summary: "API key 列表（不含明文）",
+		Auth: AuthUser, Data: listData(auth.APIKeyItem{}),
+	},
+	{
+		Method: "POST", Path: "/api/user/api-keys", Tag: "API key", Summary: "创建 API key，返回完整 key（仅此一次）",
+		Auth: AuthUser, Body: user.APIKeyRequest{}, Data: auth.APIKeyItem{},
+		Errors: []string{"invalid_request", "invalid_scope", "too_many_api_keys"},
+	},
+	{
+		Method: "DELETE", Path: "/api/user/api-keys/:id", Tag: "API key", Summary: "吊销 API key",
+		Auth: AuthUser, Params: []Param{pathID("API key ID")}, Data: okData(),
+		Errors: []string{"invalid_id", "api_key_not_found"},
+	},
+
+	// Webhook
+	{
+		Method: "GET", Path: "/api/user/webhooks", Tag: "Webhook", Summary: "Webhook 列表",
+		Auth: AuthUser, Data: listData(user.WebhookItem{}),
+	},
+	{
+		Method: "POST", Path: "/api/user/webhooks", Tag: "Webhook", Summary: "创建 Webhook，返回完整签名密钥",
+		Auth: AuthUser, Body: user.WebhookRequest{}, Data: user.WebhookItem{},
+		Errors: []string{"invalid_request", "invalid_webhook_url", "too_many_webhooks"},
+	},
+	{
+		Method: "PUT", Path: "/api/user/webhooks/:id", Tag: "Webhook", Summary: "修改 Webhook，未传的字段保持不变",
+		Auth: AuthUser, Params: []Param{pathID("Webhook ID")}, Body: user.WebhookRequest{}, Data: user.WebhookItem{},
+		Errors: []string{"invalid_request", "invalid_id", "invalid_webhook_url", "webhook_not_found"},
+	},
+	{
+		Method: "DELETE", Path: "/api/user/webhooks/:id", Tag: "Webhook", Summary: "删除 Webhook 及其投递日志",
+		Auth: AuthUser, Params: []Param{pathID("Webhook ID")}, Data: okData(),
+		Errors: []string{"invalid_id", "webhook_not_found"},
+	},
+	{
+		Method: "GET", Path: "/api/user/webhooks/:id/deliveries", Tag: "Webhook", Summary: "最近投递日志",
+		Auth: AuthUser, Params: []Param{pathID("Webhook ID"), query("limit", "条数，默认 50，最大 200", integer())},
+		Data:   listData(models.WebhookDelivery{}),
+		Errors: []string{"invalid_id", "webhook_not_found"},
+	},
+	{
+		Method: "POST", Path: "/api/user/webhooks/:id/test", Tag: "Webhook", Summary: "立即发送一条测试事件，返回投递结果",
+		Auth: AuthUser, Params: []Param{pathID("Webhook ID")}, Data: models.WebhookDelivery{},
+		Errors: []string{"invalid_id", "webhook_not_found"},
+	},
+
+	// 订阅
+	{
+		Method: "GET", Path: "/api/subscription/", Tag: "订阅", Summary: "当前用户的订阅",
+		Auth: AuthUserOrAPIKey, Scope: models.ScopeWriteSubscription, Verified: true,
+		Params: []Param{query("symbol", "只看某个交易对", str())},
+		Data:   listData(subscription.SubItem{}),
+	},
+	{
+		Method: "POST", Path: "/api/subscription/", Tag: "订阅", Summary: "订阅交易对的一个或多个周期，可同时附带规则",
+		Auth: AuthUserOrAPIKey, Scope: models.ScopeWriteSubscription, Verified: true,
+		Body: subscription.CreateRequest{}, Data: okData(),
+		Errors: []string{"invalid_request", "invalid_rule", "too_many_rules"},
+	},
+	{
+		Method: "DELETE", Path: "/api/subscription/", Tag: "订阅", Summary: "删除某条订阅及其规则",
+		Auth: AuthUserOrAPIKey, Scope: models.ScopeWriteSubscription, Verified: true,
+		Params: []Param{requiredQuery("symbol", "交易对", str()), requiredQuery("cycle", "周期", str())},
+		Data:   okData(),
+		Errors: []string{"invalid_request"},
+	},
+	{
+		Method: "GET", Path: "/api/subscription/rules", Tag: "订阅规则", Summary: "查询某条订阅的规则",
+		Auth: AuthUserOrAPIKey, Scope: models.ScopeWriteSubscription, Verified: true,
+		Params: []Param{requiredQuery("symbol", "交易对", str()), requiredQuery("cycle", "周期", str())},
+		Data:   listData(subscription.RuleItem{}),
+		Errors: []string{"invalid_request", "subscription_not_found"},
+	},
+	{
+		Method: "POST", Path: "/api/subscription/rules", Tag: "订阅规则", Summary: "为某条订阅添加规则",
+		Auth: AuthUserOrAPIKey, Scope: models.ScopeWriteSubscription, Verified: true,
+		Body: subscription.RuleRequest{}, Data: subscription.RuleItem{},
+		Errors: []string{"invalid_request", "invalid_rule", "subscription_not_found", "too_many_rules"},
+	},
+	{
+		Method: "POST", Path: "/api/subscription/rules/validate", Tag: "订阅规则", Summary: "校验并规范化规则表达式",
+		Auth: AuthUserOrAPIKey, Scope: models.ScopeWriteSubscription, Verified: true,
+		Body: subscription.RuleRequest{}, Data: object(map[string]*Schema{"rule": str()}),
+		Errors: []string{"invalid_request", "invalid_rule"},
+	},
+	{
+		Method: "PUT", Path: "/api/subscription/rules/:id", Tag: "订阅规则", Summary: "修改单条规则",
+		Auth: AuthUserOrAPIKey, Scope: models.ScopeWriteSubscription, Verified: true,
+		Params: []Param{pathID("规则 ID")}, Body: subscription.RuleRequest{}, Data: subscription.RuleItem{},
+		Errors: []string{"invalid_id", "invalid_request", "invalid_rule", "rule_not_found", "duplicate_rule"},
+	},
+	{
+		Method: "DELETE", Path: "/api/subscription/rules/:id", Tag: "订阅规则", Summary: "删除单条规则",
+		Auth: AuthUserOrAPIKey, Scope: models.ScopeWriteSubscription, Verified: true,
+		Params: []Param{pathID("规则 ID")}, Data: okData(),
+		Errors: []string{"invalid_id", "rule_not_found"},
+	},
+
+	// 管理
+	{
+		Method: "GET", Path: "/api/admin/users", Tag: "管理", Summary: "用户列表",
+		Auth: AuthAdmin, Params: []Param{query("q", "按邮箱模糊匹配，或按 Telegram ID / 用户 ID 精确匹配", str()), pageParam, pageSizeParam},
+		Data: pageData(admin.UserItem{}),
+	},
+	{
+		Method: "GET", Path: "/api/admin/users/:id", Tag: "管理", Summary: "用户详情与订阅",
+		Auth: AuthAdmin, Params: []Param{pathID("用户 ID")},
+		Data:   object(map[string]*Schema{"user": SchemaOf(admin.UserItem{}), "subscriptions": {Type: "array", Items: SchemaOf(subscription.SubItem{})}}),
+		Errors: []string{"invalid_id", "user_not_found"},
+	},
+	{
+		Method: "POST", Path: "/api/admin/users/:id/disable", Tag: "管理", Summary: "停用账号并注销全部会话",
+		Auth: AuthAdmin, Params: []Param{pathID("用户 ID")}, Data: okData(),
+		Errors: []string{"invalid_id", "user_not_found", "cannot_modify_self"},
+	},
+	{
+		Method: "POST", Path: "/api/admin/users/:id/enable", Tag: "管理", Summary: "恢复账号",
+		Auth: AuthAdmin, Params: []Param{pathID("用户 ID")}, Data: okData(),
+		Errors: []string{"invalid_id", "user_not_found", "cannot_modify_self"},
+	},
+	{
+		Method: "PUT", Path: "/api/admin/users/:id/role", Tag: "管理", Summary: "设置角色",
+		Auth: AuthAdmin, Params: []Param{pathID("用户 ID")},
+		Body: withEnum(SchemaOf(admin.RoleRequest{}), "role", models.RoleUser, models.RoleAdmin), Data: okData(),
+		Errors: []string{"invalid_id", "user_not_found", "invalid_request", "cannot_modify_self"},
+	},
+	{
+		Method: "DELETE", Path: "/api/admin/users/:id/telegram", Tag: "管理", Summary: "强制解除 Telegram 绑定",
+		Auth: AuthAdmin, Params: []Param{pathID("用户 ID")}, Data: okData(),
+		Errors: []string{"invalid_id", "user_not_found", "telegram_not_bound"},
+	},
+	{
+		Method: "GET", Path: "/api/admin/users/:id/subscriptions", Tag: "管理", Summary: "查看用户订阅",
+		Auth: AuthAdmin, Params: []Param{pathID("用户 ID")}, Data: listData(subscription.SubItem{}),
+		Errors: []string{"invalid_id", "user_not_found"},
+	},
+	{
+		Method: "DELETE", Path: "/api/admin/subscriptions/:id", Tag: "管理", Summary: "删除任意订阅及其规则",
+		Auth: AuthAdmin, Params: []Param{pathID("订阅 ID")}, Data: okData(),
+		Errors: []string{"invalid_id", "subscription_not_found"},
+	},
+	{
+		Method: "GET", Path: "/api/admin/stats", Tag: "管理", Summary: "用户数、各币种订阅数、Telegram 绑定比例",
+		Auth: AuthAdmin, Data: admin.Stats{},
+	},
+	{
+		Method: "GET", Path: "/api/admin/audit-logs", Tag: "管理", Summary: "管理操作审计日志",
+		Auth: AuthAdmin, Params: []Param{pageParam, pageSizeParam}, Data: pageData(models.AdminAuditLog{}),
+	},
+
+	// Telegram
+	{
+		Method: "POST", Path: "/api/tg/webhook", Tag: "Telegram", Summary: "接收 Telegram 推送的更新（webhook 模式）",
+		Auth: AuthTelegram, Body: &Schema{Type: "object", Description: "Telegram Update"},
+		Data: object(map[string]*Schema{"ok": boolean()}), Raw: true,
+	},
+
+	// 文档
+	{
+		Method: "GET", Path: "/api/openapi.json", Tag: "文档", Summary: "本 OpenAPI 文档",
+		Data: &Schema{Type: "object"}, Raw: true,
+	},
+	{
+		Method: "GET", Path: "/api/errors", Tag: "文档", Summary: "错误码目录：错误码、HTTP 状态码与各语言文案",
+		Data: listData(response.CatalogEntry{}),
+	},
+	{
+		Method: "GET", Path: "/api/docs", Tag: "文档", Summary: "接口文档页面（HTML）",
+		Raw: true,
+	},
+}
+
+func intPtr(n int) *int { return &n }
@@ -0,0 +1,49 @@
+package openapi
+
+import (
+	_ "embed"
+	"net/http"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+)
+
+//go:embed viewer.html
+var viewerHTML []byte
+
+// SetupDocRoutes 注册 OpenAPI 文档与查看页面
+func SetupDocRoutes(router *gin.RouterGroup) {
+	router.GET("/openapi.json", ServeSpec)
+	router.GET("/docs", ServeViewer)
+}
+
+// ServeSpec 返回 OpenAPI 文档
+func ServeSpec(c *gin.Context) {
+	c.Data(http.StatusOK, "application/json; charset=utf-8", Spec())
+}
+
+// ServeViewer 返回内置的文档查看页面，页面读取同源的 /api/openapi.json，不依赖外部资源
+func ServeViewer(c *gin.Context) {
+	c.Data(http.StatusOK, "text/html; charset=utf-8", viewerHTML)
+}
+
+// CheckRoutes 对比已注册的路由与 Operations：undocumented 为已注册但未登记的路由，
+// unregistered 为已登记但未注册的接口，均为 "METHOD /path" 形式
+func CheckRoutes(routes gin.RoutesInfo) (undocumented, unregistered []string) {
+	registered := make(map[string]bool, len(routes))
+	for _, r := range routes {
+		key := routeKey(r.Method, r.Path)
+		registered[key] = true
+		if _, ok := index[key]; !ok {
+			undocumented = append(undocumented, key)
+		}
+	}
+	for key := range index {
+		if !registered[key] {
+			unregistered = append(unregistered, key)
+		}
+	}
+	sort.Strings(undocumented)
+	sort.Strings(unregistered)
+	return undocumented, unregistered
+}
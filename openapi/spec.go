@@ -0,0 +1,112 @@
+// Package openapi 接口描述：在 Go 代码中登记每个路由的参数、请求体、返回数据与错误码，
+// 生成 OpenAPI 3 文档，开发模式下据此校验请求，启动时检查是否有未登记的路由
+package openapi
+
+import "strings"
+
+// 鉴权方式
+const (
+	AuthNone         = ""              // 公开
+	AuthOptional     = "optional"      // 公开；登录 token 无效时匿名放行，携带 API key 时校验
+	AuthUser         = "user"          // 需登录
+	AuthUserOrAPIKey = "user_or_key"   // 需登录，或带指定权限的 API key
+	AuthAdmin        = "admin"         // 需管理员
+	AuthTelegram     = "telegram"      // Telegram 推送时携带的 secret_token 请求头
+	AuthBindCallback = "bind_callback" // 独立部署的 Bot 回调，HMAC 签名请求头
+)
+
+// Param 查询参数或路径参数
+type Param struct {
+	Name        string
+	In          string // query / path
+	Description string
+	Required    bool
+	Schema      *Schema
+	Style       string // deepObject 等，默认 form
+}
+
+// Operation 一个路由的接口描述
+type Operation struct {
+	Method       string
+	Path         string // gin 路由路径，如 /api/user/webhooks/:id
+	Tag          string
+	Summary      string
+	Auth         string
+	Scope        string // AuthOptional / AuthUserOrAPIKey 时 API key 需要的权限
+	Verified     bool   // 需已验证邮箱
+	RateLimited  bool
+	Params       []Param
+	Body         interface{} // 请求体类型的零值或 *Schema，nil 表示无请求体
+	BodyOptional bool
+	Data         interface{} // 成功时 data 的类型零值或 *Schema
+	Errors       []string    // 接口自身的错误码，鉴权、限流等通用错误码生成文档时自动补充
+	Raw          bool        // 响应不使用 Response 包装
+	LegacyStatus bool        // invalid_request 始终使用 HTTP 400（统一响应前的行为，见 response.FailStatus）
+}
+
+// key 路由标识，与 gin 的 method + FullPath 对应
+func (op *Operation) key() string {
+	return routeKey(op.Method, op.Path)
+}
+
+func routeKey(method, path string) string {
+	return method + " " + path
+}
+
+// pathParams 路径参数，未显式声明的按必填字符串补充
+func (op *Operation) pathParams() []Param {
+	var params []Param
+	for _, seg := range strings.Split(op.Path, "/") {
+		if !strings.HasPrefix(seg, ":") {
+			continue
+		}
+		name := seg[1:]
+		p := Param{Name: name, In: "path", Required: true, Schema: str()}
+		for _, declared := range op.Params {
+			if declared.In == "path" && declared.Name == name {
+				p = declared
+			}
+		}
+		params = append(params, p)
+	}
+	return params
+}
+
+// queryParams 查询参数
+func (op *Operation) queryParams() []Param {
+	var params []Param
+	for _, p := range op.Params {
+		if p.In == "query" {
+			params = append(params, p)
+		}
+	}
+	return params
+}
+
+func query(name, desc string, s *Schema) Param {
+	return Param{Name: name, In: "query", Description: desc, Schema: s}
+}
+
+func requiredQuery(name, desc string, s *Schema) Param {
+	p := query(name, desc, s)
+	p.Required = true
+	return p
+}
+
+func pathID(desc string) Param {
+	return Param{Name: "id", In: "path", Description: desc, Required: true, Schema: integer()}
+}
+
+func str() *Schema     { return &Schema{Type: "string"} }
+func integer() *Schema { return &Schema{Type: "integer"} }
+func number() *Schema  { return &Schema{Type: "number"} }
+func boolean() *Schema { return &Schema{Type: "boolean"} }
+
+func enum(values ...string) *Schema {
+	return &Schema{Type: "string", Enum: values}
+}
+
+func between(s *Schema, min, max float64) *Schema {
+	s.Minimum, s.Maximum = &min, &max
+	return s
+}
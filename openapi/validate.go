@@ -0,0 +1,241 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/mail"
+	"sort"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/cryptoSelect/backendapi/response"
+	"github.com/gin-gonic/gin"
+)
+
+// Problem 一处不符合接口描述的请求内容
+type Problem struct {
+	In      string `json:"in"`             // query / path / body
+	Name    string `json:"name,omitempty"` // 参数名；请求体中为字段路径，如 quiet_hours.start、cycles[0]
+	Message string `json:"message"`
+}
+
+// maxBodyBytes 校验时读取的请求体上限
+const maxBodyBytes = 1 << 20
+
+// index method + gin 路由路径 -> 接口描述
+var index = func() map[string]*Operation {
+	m := make(map[string]*Operation, len(Operations))
+	for i := range Operations {
+		m[Operations[i].key()] = &Operations[i]
+	}
+	return m
+}()
+
+// Lookup 按 method 与 gin 路由路径（c.FullPath()）查找接口描述
+func Lookup(method, path string) (*Operation, bool) {
+	op, ok := index[routeKey(method, path)]
+	return op, ok
+}
+
+// Validate 按接口描述校验请求的路径参数、查询参数与 JSON 请求体，不符合时返回 invalid_request 及错误明细；
+// 仅在开发模式下注册，用于尽早发现前端与接口描述不一致。未登记的路由与 Raw 接口直接放行：
+// Raw 接口（如 /api/tg/webhook）的调用方不是前端，无法处理包装后的 invalid_request
+func Validate(c *gin.Context) {
+	op, ok := Lookup(c.Request.Method, c.FullPath())
+	if !ok || op.Raw {
+		c.Next()
+		return
+	}
+	var problems []Problem
+	for _, p := range op.pathParams() {
+		if msg := checkParam(p.Schema, c.Param(p.Name)); msg != "" {
+			problems = append(problems, Problem{In: "path", Name: p.Name, Message: msg})
+		}
+	}
+	for _, p := range op.queryParams() {
+		if p.Style == "deepObject" {
+			continue
+		}
+		value := c.Query(p.Name)
+		if value == "" {
+			if p.Required {
+				problems = append(problems, Problem{In: "query", Name: p.Name, Message: "is required"})
+			}
+			continue
+		}
+		if msg := checkParam(p.Schema, value); msg != "" {
+			problems = append(problems, Problem{In: "query", Name: p.Name, Message: msg})
+		}
+	}
+	if op.Body != nil {
+		problems = append(problems, checkBody(c, op)...)
+	}
+	if len(problems) > 0 {
+		if op.LegacyStatus {
+			response.FailStatus(c, response.CodeInvalidRequest, gin.H{"errors": problems})
+			c.Abort()
+			return
+		}
+		response.Abort(c, response.CodeInvalidRequest, gin.H{"errors": problems})
+		return
+	}
+	c.Next()
+}
+
+// checkBody 读取并校验 JSON 请求体，读取后放回供后续处理函数使用
+func checkBody(c *gin.Context, op *Operation) []Problem {
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxBodyBytes))
+	if err != nil {
+		return []Problem{{In: "body", Message: "unreadable body"}}
+	}
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+	if len(bytes.TrimSpace(body)) == 0 {
+		if op.BodyOptional {
+			return nil
+		}
+		return []Problem{{In: "body", Message: "is required"}}
+	}
+	var v interface{}
+	if err := json.Unmarshal(body, &v); err != nil {
+		return []Problem{{In: "body", Message: "invalid JSON: " + err.Error()}}
+	}
+	var problems []Problem
+	checkValue(SchemaOf(op.Body), v, "", func(name, msg string) {
+		problems = append(problems, Problem{In: "body", Name: name, Message: msg})
+	})
+	return problems
+}
+
+// checkParam 校验路径或查询参数的取值，返回错误描述，合法时为空
+func checkParam(s *Schema, value string) string {
+	switch s.Type {
+	case "integer":
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return "must be an integer"
+		}
+		return checkRange(s, float64(n))
+	case "number":
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return "must be a number"
+		}
+		return checkRange(s, f)
+	case "boolean":
+		if _, err := strconv.ParseBool(value); err != nil {
+			return "must be true or false"
+		}
+	case "string":
+		return checkString(s, value)
+	}
+	return ""
+}
+
+// checkValue 按 Schema 校验 JSON 值；null 视为未传，由父对象的 required 判断
+func checkValue(s *Schema, v interface{}, name string, report func(name, msg string)) {
+	if v == nil {
+		return
+	}
+	switch s.Type {
+	case "string":
+		str, ok := v.(string)
+		if !ok {
+			report(name, "must be a string")
+		} else if msg := checkString(s, str); msg != "" {
+			report(name, msg)
+		}
+	case "integer", "number":
+		f, ok := v.(float64)
+		switch {
+		case !ok:
+			report(name, "must be a number")
+		case s.Type == "integer" && f != float64(int64(f)):
+			report(name, "must be an integer")
+		default:
+			if msg := checkRange(s, f); msg != "" {
+				report(name, msg)
+			}
+		}
+	case "boolean":
+		if _, ok := v.(bool); !ok {
+			report(name, "must be true or false")
+		}
+	case "array":
+		items, ok := v.([]interface{})
+		if !ok {
+			report(name, "must be an array")
+			return
+		}
+		for i, item := range items {
+			checkValue(s.Items, item, fmt.Sprintf("%s[%d]", name, i), report)
+		}
+	case "object":
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			report(name, "must be an object")
+			return
+		}
+		for _, key := range s.Required {
+			if obj[key] == nil {
+				report(join(name, key), "is required")
+			}
+		}
+		keys := make([]string, 0, len(s.Properties))
+		for key := range s.Properties {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if value, ok := obj[key]; ok {
+				checkValue(s.Properties[key], value, join(name, key), report)
+			}
+		}
+	}
+}
+
+func checkString(s *Schema, value string) string {
+	if len(s.Enum) > 0 && !containsString(s.Enum, value) {
+		return fmt.Sprintf("must be one of %v", s.Enum)
+	}
+	n := utf8.RuneCountInString(value)
+	if s.MinLength != nil && n < *s.MinLength {
+		return fmt.Sprintf("must be at least %d characters", *s.MinLength)
+	}
+	if s.MaxLength != nil && n > *s.MaxLength {
+		return fmt.Sprintf("must be at most %d characters", *s.MaxLength)
+	}
+	if s.Format == "email" {
+		if _, err := mail.ParseAddress(value); err != nil {
+			return "must be an email address"
+		}
+	}
+	return ""
+}
+
+func checkRange(s *Schema, f float64) string {
+	if s.Minimum != nil && f < *s.Minimum {
+		return fmt.Sprintf("must be >= %v", *s.Minimum)
+	}
+	if s.Maximum != nil && f > *s.Maximum {
+		return fmt.Sprintf("must be <= %v", *s.Maximum)
+	}
+	return ""
+}
+
+func join(parent, key string) string {
+	if parent == "" {
+		return key
+	}
+	return parent + "." + key
+}
+
+func containsString(values []string, v string) bool {
+	for _, s := range values {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
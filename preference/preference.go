@@ -0,0 +1,241 @@
+// Package preference 用户偏好设置（语言、时区、分页、默认周期、免打扰、通知通道），供 Bot、告警与标的列表读取
+package preference
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cryptoSelect/backendapi/models"
+	"github.com/cryptoSelect/backendapi/utils/i18n"
+	"github.com/cryptoSelect/public/database"
+	"gorm.io/gorm"
+)
+
+const (
+	// DefaultTimezone 未设置时区时使用
+	DefaultTimezone = "Asia/Shanghai"
+	// MaxPageSize 用户可设置的最大每页条数
+	MaxPageSize = 100
+)
+
+// Cycles 支持的周期，按从短到长排序
+var Cycles = []string{"5m", "15m", "30m", "1h", "4h", "1d", "1w", "1M"}
+
+// ValidCycle 是否为支持的周期
+func ValidCycle(cycle string) bool {
+	for _, c := range Cycles {
+		if c == cycle {
+			return true
+		}
+	}
+	return false
+}
+
+// QuietHours 免打扰时段（用户时区），Start 为空表示关闭；Start 晚于 End 时跨越午夜
+type QuietHours struct {
+	Start string `json:"start"` // HH:MM
+	End   string `json:"end"`   // HH:MM
+}
+
+// Channels 通知通道开关
+type Channels struct {
+	Telegram bool `json:"telegram"`
+	Webhook  bool `json:"webhook"`
+}
+
+// Preferences 用户偏好（已填充默认值）
+type Preferences struct {
+	Language      string     `json:"language"`
+	Timezone      string     `json:"timezone"`
+	PageSize      int        `json:"page_size"` // 0 表示使用系统默认
+	DefaultCycles []string   `json:"default_cycles"`
+	QuietHours    QuietHours `json:"quiet_hours"`
+	Channels      Channels   `json:"channels"`
+}
+
+// Patch 部分更新，nil 字段保持不变
+type Patch struct {
+	Language      *string     `json:"language"`
+	Timezone      *string     `json:"timezone"`
+	PageSize      *int        `json:"page_size"`
+	DefaultCycles *[]string   `json:"default_cycles"`
+	QuietHours    *QuietHours `json:"quiet_hours"`
+	Channels      *struct {
+		Telegram *bool `json:"telegram"`
+		Webhook  *bool `json:"webhook"`
+	} `json:"channels"`
+}
+
+// ValidationError 偏好字段校验失败
+type ValidationError struct {
+	Field   string
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Field + ": " + e.Message
+}
+
+// Default 未设置任何偏好时的默认值
+func Default() Preferences {
+	return Preferences{
+		Language:      i18n.Default,
+		Timezone:      DefaultTimezone,
+		DefaultCycles: []string{},
+		Channels:      Channels{Telegram: true, Webhook: true},
+	}
+}
+
+func fromModel(m *models.UserPreference) Preferences {
+	p := Default()
+	if i18n.Supported(m.Language) {
+		p.Language = m.Language
+	}
+	if m.Timezone != "" {
+		p.Timezone = m.Timezone
+	}
+	p.PageSize = m.PageSize
+	for _, c := range strings.Split(m.DefaultCycles, ",") {
+		if c = strings.TrimSpace(c); c != "" {
+			p.DefaultCycles = append(p.DefaultCycles, c)
+		}
+	}
+	p.QuietHours = QuietHours{Start: m.QuietStart, End: m.QuietEnd}
+	p.Channels = Channels{Telegram: !m.TelegramMuted, Webhook: !m.WebhookMuted}
+	return p
+}
+
+// Get 读取用户偏好，未设置或读取失败时返回默认值
+func Get(userID uint) Preferences {
+	var m models.UserPreference
+	if userID == 0 || database.DB.Where("user_id = ?", userID).First(&m).Error != nil {
+		return Default()
+	}
+	return fromModel(&m)
+}
+
+// Language 用户语言
+func Language(userID uint) string {
+	return Get(userID).Language
+}
+
+func parseClock(s string) (int, bool) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, false
+	}
+	return t.Hour()*60 + t.Minute(), true
+}
+
+// apply 校验并把 patch 写入 model
+func apply(m *models.UserPreference, patch Patch) error {
+	if patch.Language != nil {
+		lang := strings.ToLower(strings.TrimSpace(*patch.Language))
+		if !i18n.Supported(lang) {
+			return &ValidationError{Field: "language", Message: "must be one of " + strings.Join(i18n.Languages, ", ")}
+		}
+		m.Language = lang
+	}
+	if patch.Timezone != nil {
+		tz := strings.TrimSpace(*patch.Timezone)
+		if _, err := time.LoadLocation(tz); err != nil || tz == "" {
+			return &ValidationError{Field: "timezone", Message: "unknown IANA timezone"}
+		}
+		m.Timezone = tz
+	}
+	if patch.PageSize != nil {
+		if *patch.PageSize < 0 || *patch.PageSize > MaxPageSize {
+			return &ValidationError{Field: "page_size", Message: fmt.Sprintf("must be between 0 and %d", MaxPageSize)}
+		}
+		m.PageSize = *patch.PageSize
+	}
+	if patch.DefaultCycles != nil {
+		seen := map[string]bool{}
+		var cycles []string
+		for _, c := range *patch.DefaultCycles {
+			c = strings.TrimSpace(c)
+			if !ValidCycle(c) {
+				return &ValidationError{Field: "default_cycles", Message: "unsupported cycle " + c}
+			}
+			if !seen[c] {
+				seen[c] = true
+				cycles = append(cycles, c)
+			}
+		}
+		m.DefaultCycles = strings.Join(cycles, ",")
+	}
+	if q := patch.QuietHours; q != nil {
+		start, end := strings.TrimSpace(q.Start), strings.TrimSpace(q.End)
+		if start != "" || end != "" {
+			_, ok1 := parseClock(start)
+			_, ok2 := parseClock(end)
+			if !ok1 || !ok2 || start == end {
+				return &ValidationError{Field: "quiet_hours", Message: "start and end must be different HH:MM times"}
+			}
+		}
+		m.QuietStart, m.QuietEnd = start, end
+	}
+	if ch := patch.Channels; ch != nil {
+		if ch.Telegram != nil {
+			m.TelegramMuted = !*ch.Telegram
+		}
+		if ch.Webhook != nil {
+			m.WebhookMuted = !*ch.Webhook
+		}
+	}
+	return nil
+}
+
+// Update 部分更新用户偏好，返回更新后的完整偏好；校验失败返回 *ValidationError
+func Update(userID uint, patch Patch) (Preferences, error) {
+	var m models.UserPreference
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("user_id = ?", userID).First(&m).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			m = models.UserPreference{UserID: userID}
+		} else if err != nil {
+			return err
+		}
+		if err := apply(&m, patch); err != nil {
+			return err
+		}
+		return tx.Save(&m).Error
+	})
+	if err != nil {
+		return Preferences{}, err
+	}
+	return fromModel(&m), nil
+}
+
+// Location 用户时区，无效时使用默认时区
+func (p Preferences) Location() *time.Location {
+	if loc, err := time.LoadLocation(p.Timezone); err == nil {
+		return loc
+	}
+	if loc, err := time.LoadLocation(DefaultTimezone); err == nil {
+		return loc
+	}
+	return time.UTC
+}
+
+// InQuietHours t 是否处于用户的免打扰时段
+func (p Preferences) InQuietHours(t time.Time) bool {
+	start, ok1 := parseClock(p.QuietHours.Start)
+	end, ok2 := parseClock(p.QuietHours.End)
+	if !ok1 || !ok2 || start == end {
+		return false
+	}
+	local := t.In(p.Location())
+	now := local.Hour()*60 + local.Minute()
+	if start < end {
+		return now >= start && now < end
+	}
+	return now >= start || now < end
+}
+
+// FormatTime 按用户时区格式化时间
+func (p Preferences) FormatTime(t time.Time) string {
+	return t.In(p.Location()).Format("2006-01-02 15:04")
+}
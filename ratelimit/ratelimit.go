@@ -0,0 +1,204 @@
+// Package ratelimit 接口限流（令牌桶，按 IP / 账号 / 登录用户）与登录失败锁定
+package ratelimit
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cryptoSelect/backendapi/config"
+	"github.com/cryptoSelect/backendapi/response"
+	"github.com/cryptoSelect/backendapi/utils/logger"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	BackendMemory   = "memory"
+	BackendPostgres = "postgres"
+)
+
+// failureWindow 距上次失败超过该时长后失败次数重新计数
+const failureWindow = 24 * time.Hour
+
+var store Store = NewMemoryStore()
+
+// Init 按 config.RateLimit.Backend 选择存储：memory（默认）或 postgres（多实例共享，需已迁移相关表）
+func Init(cfg config.RateLimitConfig) {
+	switch cfg.Backend {
+	case BackendPostgres:
+		store = NewDBStore()
+	default:
+		store = NewMemoryStore()
+	}
+}
+
+// SetStore 替换存储实现
+func SetStore(s Store) {
+	store = s
+}
+
+// RunSweeper 按 interval 周期清理闲置令牌桶与过期失败记录
+func RunSweeper(interval time.Duration) {
+	for {
+		time.Sleep(interval)
+		if err := store.Sweep(time.Now(), failureWindow); err != nil {
+			logger.Log.Error("rate limit sweep failed", map[string]interface{}{"error": err.Error()})
+		}
+	}
+}
+
+func orDefault(v, def int) int {
+	if v <= 0 {
+		return def
+	}
+	return v
+}
+
+// IPLimit 按 IP 限流的参数，默认每分钟 20 次、突发 10 次
+func IPLimit() Limit {
+	cfg := config.Cfg.RateLimit
+	return PerMinute(orDefault(cfg.IPPerMinute, 20), orDefault(cfg.IPBurst, 10))
+}
+
+// AccountLimit 按账号（邮箱或登录用户）限流的参数，默认每分钟 5 次、突发 5 次
+func AccountLimit() Limit {
+	cfg := config.Cfg.RateLimit
+	return PerMinute(orDefault(cfg.AccountPerMinute, 5), orDefault(cfg.AccountBurst, 5))
+}
+
+// APIKeyLimit API key 的默认限流参数，默认每分钟 60 次、突发 60 次；单个 key 可单独设置每分钟次数
+func APIKeyLimit(perMinute int) Limit {
+	n := orDefault(perMinute, orDefault(config.Cfg.RateLimit.APIKeyPerMinute, 60))
+	return PerMinute(n, n)
+}
+
+// LockoutPolicy 登录锁定策略，默认连续失败 5 次锁定 60 秒，之后每次翻倍，最长 1 小时
+func LockoutPolicy() Policy {
+	cfg := config.Cfg.RateLimit
+	return Policy{
+		MaxFailures: orDefault(cfg.LockoutMaxFailures, 5),
+		Base:        time.Duration(orDefault(cfg.LockoutBaseSeconds, 60)) * time.Second,
+		Max:         time.Duration(orDefault(cfg.LockoutMaxSeconds, 3600)) * time.Second,
+		Window:      failureWindow,
+	}
+}
+
+// KeyFunc 从请求中取限流键，返回空字符串时不限流
+type KeyFunc func(c *gin.Context) string
+
+// ByIP 按客户端 IP
+func ByIP(c *gin.Context) string {
+	return "ip:" + c.ClientIP()
+}
+
+// ByContextKey 按 gin 上下文中的值，如登录用户 ID；需放在写入该值的中间件之后
+func ByContextKey(key string) KeyFunc {
+	return func(c *gin.Context) string {
+		v, ok := c.Get(key)
+		if !ok {
+			return ""
+		}
+		return key + ":" + fmt.Sprint(v)
+	}
+}
+
+// ByJSONField 按 JSON 请求体中的字符串字段（如 email），读取后恢复请求体供 handler 继续绑定
+func ByJSONField(field string) KeyFunc {
+	return func(c *gin.Context) string {
+		if c.Request.Body == nil {
+			return ""
+		}
+		body, err := io.ReadAll(io.LimitReader(c.Request.Body, 1<<16))
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		if err != nil {
+			return ""
+		}
+		var m map[string]interface{}
+		if json.Unmarshal(body, &m) != nil {
+			return ""
+		}
+		v, _ := m[field].(string)
+		v = strings.ToLower(strings.TrimSpace(v))
+		if v == "" {
+			return ""
+		}
+		return field + ":" + v
+	}
+}
+
+// Middleware 令牌桶限流中间件，scope 区分不同接口的计数；存储出错时放行
+func Middleware(scope string, limit Limit, keyFunc KeyFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := keyFunc(c)
+		if key == "" {
+			c.Next()
+			return
+		}
+		if !Allow(c, scope, key, limit) {
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// Allow 从 scope:key 的令牌桶取一个令牌，超限时写入 429 响应并返回 false；存储出错时放行
+func Allow(c *gin.Context, scope, key string, limit Limit) bool {
+	key = scope + ":" + key
+	allowed, wait, err := store.Take(key, limit, time.Now())
+	if err != nil {
+		logger.Log.Error("rate limit store failed", map[string]interface{}{"key": key, "error": err.Error()})
+		return true
+	}
+	if !allowed {
+		retryAfter := int(math.Ceil(wait.Seconds()))
+		logger.Log.Warn("rate limited", map[string]interface{}{"event": "rate_limited", "scope": scope, "key": key, "ip": c.ClientIP(), "retry_after": retryAfter})
+		c.Header("Retry-After", strconv.Itoa(retryAfter))
+		response.Fail(c, response.CodeTooManyRequests, gin.H{"retry_after": retryAfter})
+	}
+	return allowed
+}
+
+// LockKey 登录锁定键
+func LockKey(scope, account string) string {
+	return scope + ":" + strings.ToLower(strings.TrimSpace(account))
+}
+
+// Locked 返回 key 是否处于锁定中及剩余秒数；存储出错时视为未锁定
+func Locked(key string) (bool, int) {
+	now := time.Now()
+	until, err := store.LockedUntil(key, now)
+	if err != nil {
+		logger.Log.Error("lockout store failed", map[string]interface{}{"key": key, "error": err.Error()})
+		return false, 0
+	}
+	if until.IsZero() {
+		return false, 0
+	}
+	return true, int(math.Ceil(until.Sub(now).Seconds()))
+}
+
+// Fail 记录一次失败，达到阈值时锁定并记录日志
+func Fail(key, ip string) {
+	now := time.Now()
+	count, until, err := store.Fail(key, LockoutPolicy(), now)
+	if err != nil {
+		logger.Log.Error("lockout store failed", map[string]interface{}{"key": key, "error": err.Error()})
+		return
+	}
+	if until.After(now) {
+		logger.Log.Warn("account locked", map[string]interface{}{"event": "account_locked", "key": key, "ip": ip, "failures": count, "locked_until": until.Format(time.RFC3339)})
+	}
+}
+
+// Reset 成功后清除失败计数
+func Reset(key string) {
+	if err := store.Reset(key); err != nil {
+		logger.Log.Error("lockout store failed", map[string]interface{}{"key": key, "error": err.Error()})
+	}
+}
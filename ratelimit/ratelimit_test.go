@@ -0,0 +1,78 @@
+package ratelimit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cryptoSelect/backendapi/config"
+	"github.com/cryptoSelect/backendapi/response"
+	"github.com/cryptoSelect/backendapi/utils/logger"
+	"github.com/gin-gonic/gin"
+)
+
+func setup(t *testing.T, cfg config.RateLimitConfig) {
+	t.Helper()
+	logger.Init("test")
+	gin.SetMode(gin.TestMode)
+	oldCfg, oldStore := config.Cfg, store
+	config.Cfg = &config.ServerConfig{RateLimit: cfg}
+	store = NewMemoryStore()
+	t.Cleanup(func() { config.Cfg, store = oldCfg, oldStore })
+}
+
+func TestMiddlewareRetryAfter(t *testing.T) {
+	setup(t, config.RateLimitConfig{})
+	r := gin.New()
+	r.POST("/login", Middleware("login", PerMinute(2, 2), ByJSONField("email")), func(c *gin.Context) {
+		var body struct{ Email string }
+		// 限流读取请求体后仍可再次绑定
+		if err := c.ShouldBindJSON(&body); err != nil || body.Email == "" {
+			t.Errorf("body not restored: %v", err)
+		}
+		c.Status(http.StatusNoContent)
+	})
+	post := func(email string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/login", strings.NewReader(`{"email":"`+email+`"}`))
+		req.Header.Set(response.HeaderAPIVersion, "2")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	for i := 0; i < 2; i++ {
+		if w := post("A@example.com"); w.Code != http.StatusNoContent {
+			t.Fatalf("request %d: status %d", i+1, w.Code)
+		}
+	}
+	// 键不区分大小写与首尾空白
+	w := post(" a@example.com ")
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != "30" {
+		t.Fatalf("status %d Retry-After %q, want 429 after 30s", w.Code, w.Header().Get("Retry-After"))
+	}
+	if w := post("b@example.com"); w.Code != http.StatusNoContent {
+		t.Fatalf("other account limited: %d", w.Code)
+	}
+}
+
+func TestLockedFailReset(t *testing.T) {
+	setup(t, config.RateLimitConfig{LockoutMaxFailures: 2, LockoutBaseSeconds: 30})
+	key := LockKey("login", " A@Example.com")
+	if key != "login:a@example.com" {
+		t.Fatalf("LockKey = %q", key)
+	}
+
+	Fail(key, "127.0.0.1")
+	if locked, _ := Locked(key); locked {
+		t.Fatal("locked below the threshold")
+	}
+	Fail(key, "127.0.0.1")
+	if locked, secs := Locked(key); !locked || secs != 30 {
+		t.Fatalf("Locked = %v %d, want locked for 30s", locked, secs)
+	}
+	Reset(key)
+	if locked, _ := Locked(key); locked {
+		t.Fatal("still locked after Reset")
+	}
+}
@@ -0,0 +1,241 @@
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/cryptoSelect/backendapi/models"
+	"github.com/cryptoSelect/public/database"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// idleTTL 令牌桶闲置超过该时长后可被清理（此时桶早已补满）
+const idleTTL = time.Hour
+
+// Limit 令牌桶参数
+type Limit struct {
+	Rate  float64 // 每秒补充的令牌数
+	Burst int     // 桶容量，即允许的瞬时请求数
+}
+
+// PerMinute 每分钟 n 次、容量 burst 的令牌桶
+func PerMinute(n, burst int) Limit {
+	return Limit{Rate: float64(n) / 60, Burst: burst}
+}
+
+// Policy 登录失败锁定策略：连续失败 MaxFailures 次后锁定 Base，此后每次失败时长翻倍，最长 Max
+type Policy struct {
+	MaxFailures int
+	Base        time.Duration
+	Max         time.Duration
+	Window      time.Duration // 距上次失败超过该时长则重新计数
+}
+
+// lockDuration 第 failures 次失败后的锁定时长，未达到阈值时为 0
+func (p Policy) lockDuration(failures int) time.Duration {
+	if failures < p.MaxFailures {
+		return 0
+	}
+	d := time.Duration(float64(p.Base) * math.Pow(2, float64(failures-p.MaxFailures)))
+	if d > p.Max || d <= 0 {
+		d = p.Max
+	}
+	return d
+}
+
+// record 在已有失败记录（count 次，最近一次在 last）之上再记一次失败，返回新的失败次数与锁定截止时间；
+// 距上次失败超过 Window 时重新计数并清除旧的锁定
+func (p Policy) record(count int, last, lockedUntil, now time.Time) (int, time.Time) {
+	if now.Sub(last) > p.Window {
+		count, lockedUntil = 0, time.Time{}
+	}
+	count++
+	if d := p.lockDuration(count); d > 0 {
+		lockedUntil = now.Add(d)
+	}
+	return count, lockedUntil
+}
+
+// Store 限流与锁定状态存储
+type Store interface {
+	// Take 从 key 对应的令牌桶取一个令牌；不足时返回 false 与需等待的时长
+	Take(key string, limit Limit, now time.Time) (bool, time.Duration, error)
+	// Fail 记录一次失败，返回累计失败次数与锁定截止时间（未锁定时为零值）
+	Fail(key string, policy Policy, now time.Time) (int, time.Time, error)
+	// LockedUntil 返回 key 的锁定截止时间，未锁定时为零值
+	LockedUntil(key string, now time.Time) (time.Time, error)
+	// Reset 清除 key 的失败计数
+	Reset(key string) error
+	// Sweep 清理闲置的令牌桶与过期的失败记录
+	Sweep(now time.Time, window time.Duration) error
+}
+
+// refill 按经过的时间补充令牌并尝试取一个
+func refill(tokens float64, last time.Time, limit Limit, now time.Time) (float64, bool, time.Duration) {
+	if elapsed := now.Sub(last).Seconds(); elapsed > 0 {
+		tokens = math.Min(float64(limit.Burst), tokens+elapsed*limit.Rate)
+	}
+	if tokens >= 1 {
+		return tokens - 1, true, 0
+	}
+	if limit.Rate <= 0 {
+		return tokens, false, idleTTL
+	}
+	return tokens, false, time.Duration((1 - tokens) / limit.Rate * float64(time.Second))
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+type failure struct {
+	count       int
+	last        time.Time
+	lockedUntil time.Time
+}
+
+// MemoryStore 进程内存储，适合单实例部署
+type MemoryStore struct {
+	mu       sync.Mutex
+	buckets  map[string]*bucket
+	failures map[string]*failure
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: map[string]*bucket{}, failures: map[string]*failure{}}
+}
+
+func (s *MemoryStore) Take(key string, limit Limit, now time.Time) (bool, time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Burst), last: now}
+		s.buckets[key] = b
+	}
+	tokens, allowed, wait := refill(b.tokens, b.last, limit, now)
+	b.tokens, b.last = tokens, now
+	return allowed, wait, nil
+}
+
+func (s *MemoryStore) Fail(key string, policy Policy, now time.Time) (int, time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f, ok := s.failures[key]
+	if !ok {
+		f = &failure{}
+		s.failures[key] = f
+	}
+	f.count, f.lockedUntil = policy.record(f.count, f.last, f.lockedUntil, now)
+	f.last = now
+	return f.count, f.lockedUntil, nil
+}
+
+func (s *MemoryStore) LockedUntil(key string, now time.Time) (time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if f, ok := s.failures[key]; ok && f.lockedUntil.After(now) {
+		return f.lockedUntil, nil
+	}
+	return time.Time{}, nil
+}
+
+func (s *MemoryStore) Reset(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.failures, key)
+	return nil
+}
+
+func (s *MemoryStore) Sweep(now time.Time, window time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for k, b := range s.buckets {
+		if now.Sub(b.last) > idleTTL {
+			delete(s.buckets, k)
+		}
+	}
+	for k, f := range s.failures {
+		if now.Sub(f.last) > window && !f.lockedUntil.After(now) {
+			delete(s.failures, k)
+		}
+	}
+	return nil
+}
+
+// DBStore 基于 rate_limit_bucket / login_lockout 表的存储，多实例共享；行锁保证并发下计数准确
+type DBStore struct{}
+
+func NewDBStore() *DBStore {
+	return &DBStore{}
+}
+
+func (s *DBStore) Take(key string, limit Limit, now time.Time) (bool, time.Duration, error) {
+	var allowed bool
+	var wait time.Duration
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&models.RateLimitBucket{Key: key, Tokens: float64(limit.Burst), RefilledAt: now}).Error; err != nil {
+			return err
+		}
+		var b models.RateLimitBucket
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("key = ?", key).First(&b).Error; err != nil {
+			return err
+		}
+		var tokens float64
+		tokens, allowed, wait = refill(b.Tokens, b.RefilledAt, limit, now)
+		return tx.Model(&models.RateLimitBucket{}).Where("key = ?", key).
+			Updates(map[string]interface{}{"tokens": tokens, "refilled_at": now}).Error
+	})
+	return allowed, wait, err
+}
+
+func (s *DBStore) Fail(key string, policy Policy, now time.Time) (int, time.Time, error) {
+	var count int
+	var lockedUntil time.Time
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&models.LoginLockout{Key: key, LastFailureAt: now}).Error; err != nil {
+			return err
+		}
+		var rec models.LoginLockout
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("key = ?", key).First(&rec).Error; err != nil {
+			return err
+		}
+		var prevLocked time.Time
+		if rec.LockedUntil != nil {
+			prevLocked = *rec.LockedUntil
+		}
+		count, lockedUntil = policy.record(rec.Failures, rec.LastFailureAt, prevLocked, now)
+		updates := map[string]interface{}{"failures": count, "last_failure_at": now, "locked_until": nil}
+		if !lockedUntil.IsZero() {
+			updates["locked_until"] = lockedUntil
+		}
+		return tx.Model(&models.LoginLockout{}).Where("key = ?", key).Updates(updates).Error
+	})
+	return count, lockedUntil, err
+}
+
+func (s *DBStore) LockedUntil(key string, now time.Time) (time.Time, error) {
+	var rec models.LoginLockout
+	err := database.DB.Where("key = ? AND locked_until > ?", key, now).Limit(1).Find(&rec).Error
+	if err != nil || rec.LockedUntil == nil {
+		return time.Time{}, err
+	}
+	return *rec.LockedUntil, nil
+}
+
+func (s *DBStore) Reset(key string) error {
+	return database.DB.Where("key = ?", key).Delete(&models.LoginLockout{}).Error
+}
+
+func (s *DBStore) Sweep(now time.Time, window time.Duration) error {
+	if err := database.DB.Where("refilled_at < ?", now.Add(-idleTTL)).Delete(&models.RateLimitBucket{}).Error; err != nil {
+		return err
+	}
+	return database.DB.Where("last_failure_at < ? AND (locked_until IS NULL OR locked_until < ?)", now.Add(-window), now).
+		Delete(&models.LoginLockout{}).Error
+}
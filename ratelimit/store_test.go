@@ -0,0 +1,158 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+var t0 = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func TestTakeRefillAndBurst(t *testing.T) {
+	s := NewMemoryStore()
+	limit := PerMinute(60, 3) // 每秒一个令牌，容量 3
+	steps := []struct {
+		at      time.Duration
+		allowed bool
+		wait    time.Duration
+	}{
+		// 新桶是满的，可以瞬时用完容量
+		{0, true, 0},
+		{0, true, 0},
+		{0, true, 0},
+		{0, false, time.Second},
+		{500 * time.Millisecond, false, 500 * time.Millisecond},
+		{time.Second, true, 0},
+		{time.Second, false, time.Second},
+		// 闲置再久也只补满到容量
+		{time.Hour, true, 0},
+		{time.Hour, true, 0},
+		{time.Hour, true, 0},
+		{time.Hour, false, time.Second},
+	}
+	for i, st := range steps {
+		allowed, wait, err := s.Take("ip:1.2.3.4", limit, t0.Add(st.at))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if allowed != st.allowed || wait != st.wait {
+			t.Fatalf("step %d at %v: got %v wait %v, want %v wait %v", i, st.at, allowed, wait, st.allowed, st.wait)
+		}
+	}
+	// 不同键互不影响
+	if allowed, _, _ := s.Take("ip:5.6.7.8", limit, t0.Add(time.Hour)); !allowed {
+		t.Fatal("other key limited")
+	}
+}
+
+func TestRefillZeroRate(t *testing.T) {
+	tokens, allowed, wait := refill(0, t0, Limit{Rate: 0, Burst: 1}, t0.Add(time.Hour))
+	if allowed || tokens != 0 || wait != idleTTL {
+		t.Fatalf("got %v %v %v", tokens, allowed, wait)
+	}
+}
+
+func TestLockDuration(t *testing.T) {
+	p := Policy{MaxFailures: 5, Base: time.Minute, Max: time.Hour, Window: 24 * time.Hour}
+	tests := map[int]time.Duration{
+		1:   0,
+		4:   0,
+		5:   time.Minute,
+		6:   2 * time.Minute,
+		7:   4 * time.Minute,
+		10:  32 * time.Minute,
+		11:  time.Hour, // 64 分钟超过上限
+		12:  time.Hour,
+		200: time.Hour, // 溢出时同样取上限
+	}
+	for failures, want := range tests {
+		if got := p.lockDuration(failures); got != want {
+			t.Errorf("lockDuration(%d) = %v, want %v", failures, got, want)
+		}
+	}
+}
+
+func TestFailLockoutAndWindow(t *testing.T) {
+	s := NewMemoryStore()
+	p := Policy{MaxFailures: 3, Base: time.Minute, Max: 5 * time.Minute, Window: time.Hour}
+	const key = "login:a@example.com"
+
+	now := t0
+	for i := 1; i <= 2; i++ {
+		count, until, _ := s.Fail(key, p, now)
+		if count != i || !until.IsZero() {
+			t.Fatalf("failure %d: count %d until %v", i, count, until)
+		}
+	}
+	// 达到阈值后锁定 Base，此后每次翻倍直到 Max
+	for i, want := range []time.Duration{time.Minute, 2 * time.Minute, 4 * time.Minute, 5 * time.Minute, 5 * time.Minute} {
+		now = now.Add(time.Second)
+		count, until, _ := s.Fail(key, p, now)
+		if count != 3+i || !until.Equal(now.Add(want)) {
+			t.Fatalf("failure %d: count %d locked %v, want %v", 3+i, count, until.Sub(now), want)
+		}
+		if got, _ := s.LockedUntil(key, now); !got.Equal(until) {
+			t.Fatalf("LockedUntil = %v, want %v", got, until)
+		}
+	}
+	if got, _ := s.LockedUntil(key, now.Add(5*time.Minute)); !got.IsZero() {
+		t.Fatalf("still locked after lock expired: %v", got)
+	}
+
+	// 恰好 Window 时仍累计，超过 Window 后重新计数
+	now = now.Add(time.Hour)
+	if count, _, _ := s.Fail(key, p, now); count != 8 {
+		t.Fatalf("count %d at the window edge, want 8", count)
+	}
+	now = now.Add(time.Hour + time.Nanosecond)
+	count, until, _ := s.Fail(key, p, now)
+	if count != 1 || !until.IsZero() {
+		t.Fatalf("after window: count %d until %v, want a fresh count", count, until)
+	}
+}
+
+func TestPolicyRecordClearsStaleLock(t *testing.T) {
+	p := Policy{MaxFailures: 2, Base: time.Minute, Max: time.Hour, Window: time.Hour}
+	stale := t0.Add(time.Minute)
+	count, until := p.record(9, t0, stale, t0.Add(2*time.Hour))
+	if count != 1 || !until.IsZero() {
+		t.Fatalf("got %d %v, want 1 and no lock", count, until)
+	}
+	// 未达阈值时保留尚未到期的锁定
+	count, until = p.record(0, t0, stale, t0)
+	if count != 1 || !until.Equal(stale) {
+		t.Fatalf("got %d %v, want the existing lock kept", count, until)
+	}
+}
+
+func TestResetAndSweep(t *testing.T) {
+	s := NewMemoryStore()
+	p := Policy{MaxFailures: 1, Base: time.Minute, Max: time.Minute, Window: time.Hour}
+	s.Fail("locked", p, t0)
+	s.Reset("locked")
+	if got, _ := s.LockedUntil("locked", t0); !got.IsZero() {
+		t.Fatal("Reset did not clear the lock")
+	}
+	if count, _, _ := s.Fail("locked", p, t0); count != 1 {
+		t.Fatalf("count after Reset = %d, want 1", count)
+	}
+
+	s.Take("idle", PerMinute(1, 1), t0)
+	s.Take("active", PerMinute(1, 1), t0.Add(2*time.Hour))
+	s.Fail("old", Policy{MaxFailures: 10, Window: time.Hour}, t0)
+	s.Fail("long", Policy{MaxFailures: 1, Base: 5 * time.Hour, Max: 5 * time.Hour, Window: time.Hour}, t0)
+	if err := s.Sweep(t0.Add(2*time.Hour), time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := s.buckets["idle"]; ok {
+		t.Error("idle bucket not swept")
+	}
+	if _, ok := s.buckets["active"]; !ok {
+		t.Error("active bucket swept")
+	}
+	if _, ok := s.failures["old"]; ok {
+		t.Error("expired failure not swept")
+	}
+	if _, ok := s.failures["long"]; !ok {
+		t.Error("failure still locked was swept")
+	}
+}
@@ -0,0 +1,220 @@
+package response
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/cryptoSelect/backendapi/utils/i18n"
+	"github.com/gin-gonic/gin"
+)
+
+// ErrorCode 错误码，即 Response.error 的取值；文案在 i18n 中以 "error.<错误码>" 登记
+type ErrorCode string
+
+// 错误码
+const (
+	// 400 请求有误
+	CodeInvalidRequest          ErrorCode = "invalid_request"
+	CodeInvalidID               ErrorCode = "invalid_id"
+	CodeInvalidFilter           ErrorCode = "invalid_filter"
+	CodeInvalidFields           ErrorCode = "invalid_fields"
+	CodeInvalidSort             ErrorCode = "invalid_sort"
+	CodeInvalidPageSize         ErrorCode = "invalid_page_size"
+	CodeInvalidCursor           ErrorCode = "invalid_cursor"
+	CodeInvalidCycle            ErrorCode = "invalid_cycle"
+	CodeInvalidCycles           ErrorCode = "invalid_cycles"
+	CodeInvalidField            ErrorCode = "invalid_field"
+	CodeInvalidMinMatch         ErrorCode = "invalid_min_match"
+	CodeInvalidRule             ErrorCode = "invalid_rule"
+	CodeTooManyRules            ErrorCode = "too_many_rules"
+	CodeInvalidScope            ErrorCode = "invalid_scope"
+	CodeTooManyAPIKeys          ErrorCode = "too_many_api_keys"
+	CodeInvalidWebhookURL       ErrorCode = "invalid_webhook_url"
+	CodeTooManyWebhooks         ErrorCode = "too_many_webhooks"
+	CodeInvalidPreference       ErrorCode = "invalid_preference"
+	CodeInvalidOrExpiredToken   ErrorCode = "invalid_or_expired_token"
+	CodeEmailNotSet             ErrorCode = "email_not_set"
+	CodeEmailAlreadyVerified    ErrorCode = "email_already_verified"
+	CodeEmailUnchanged          ErrorCode = "email_unchanged"
+	CodeEmailReserved           ErrorCode = "email_reserved"
+	CodeCredentialsNotSet       ErrorCode = "credentials_not_set"
+	CodeCredentialsAlreadySet   ErrorCode = "credentials_already_set"
+	CodeConfirmRequired         ErrorCode = "confirm_required"
+	CodeTelegramNotBound        ErrorCode = "telegram_not_bound"
+	CodeTelegramOnlyAccount     ErrorCode = "telegram_only_account"
+	CodeTwoFactorAlreadyEnabled ErrorCode = "two_factor_already_enabled"
+	CodeTwoFactorNotEnabled     ErrorCode = "two_factor_not_enabled"
+	CodeTwoFactorNotSetup       ErrorCode = "two_factor_not_setup"
+	CodeCannotModifySelf        ErrorCode = "cannot_modify_self"
+
+	// 401 未通过身份验证
+	CodeUnauthorized         ErrorCode = "unauthorized"
+	CodeSessionRevoked       ErrorCode = "session_revoked"
+	CodeEmailOrPasswordWrong ErrorCode = "email_or_password_wrong"
+	CodePasswordWrong        ErrorCode = "password_wrong"
+	CodeInvalidRefreshToken  ErrorCode = "invalid_refresh_token"
+	CodeInvalidChallenge     ErrorCode = "invalid_challenge"
+	CodeInvalidCode          ErrorCode = "invalid_code"
+	CodeInvalidAPIKey        ErrorCode = "invalid_api_key"
+	CodeInvalidSignature     ErrorCode = "invalid_signature"
+	CodeInvalidTelegramLogin ErrorCode = "invalid_telegram_login"
+
+	// 403 无权限
+	CodeForbidden         ErrorCode = "forbidden"
+	CodeAccountDisabled   ErrorCode = "account_disabled"
+	CodeEmailNotVerified  ErrorCode = "email_not_verified"
+	CodeInsufficientScope ErrorCode = "insufficient_scope"
+
+	// 404 不存在
+	CodeUserNotFound         ErrorCode = "user_not_found"
+	CodeSubscriptionNotFound ErrorCode = "subscription_not_found"
+	CodeRuleNotFound         ErrorCode = "rule_not_found"
+	CodeSessionNotFound      ErrorCode = "session_not_found"
+	CodeAPIKeyNotFound       ErrorCode = "api_key_not_found"
+	CodeWebhookNotFound      ErrorCode = "webhook_not_found"
+	CodeBindTokenNotFound    ErrorCode = "bind_token_not_found"
+
+	// 409 冲突
+	CodeEmailAlreadyRegistered ErrorCode = "email_already_registered"
+	CodeTelegramAlreadyBound   ErrorCode = "telegram_already_bound"
+	CodeBindTokenUsed          ErrorCode = "bind_token_used"
+	CodeDuplicateRule          ErrorCode = "duplicate_rule"
+
+	// 429 请求过多
+	CodeTooManyRequests ErrorCode = "too_many_requests"
+	CodeAccountLocked   ErrorCode = "account_locked"
+
+	// 500 服务端错误，详情只记录日志
+	CodeServerError    ErrorCode = "server_error"
+	CodeSendMailFailed ErrorCode = "send_mail_failed"
+)
+
+// statuses 错误码 -> HTTP 状态码，同时作为 Response.code
+var statuses = map[ErrorCode]int{
+	CodeInvalidRequest:          http.StatusBadRequest,
+	CodeInvalidID:               http.StatusBadRequest,
+	CodeInvalidFilter:           http.StatusBadRequest,
+	CodeInvalidFields:           http.StatusBadRequest,
+	CodeInvalidSort:             http.StatusBadRequest,
+	CodeInvalidPageSize:         http.StatusBadRequest,
+	CodeInvalidCursor:           http.StatusBadRequest,
+	CodeInvalidCycle:            http.StatusBadRequest,
+	CodeInvalidCycles:           http.StatusBadRequest,
+	CodeInvalidField:            http.StatusBadRequest,
+	CodeInvalidMinMatch:         http.StatusBadRequest,
+	CodeInvalidRule:             http.StatusBadRequest,
+	CodeTooManyRules:            http.StatusBadRequest,
+	CodeInvalidScope:            http.StatusBadRequest,
+	CodeTooManyAPIKeys:          http.StatusBadRequest,
+	CodeInvalidWebhookURL:       http.StatusBadRequest,
+	CodeTooManyWebhooks:         http.StatusBadRequest,
+	CodeInvalidPreference:       http.StatusBadRequest,
+	CodeInvalidOrExpiredToken:   http.StatusBadRequest,
+	CodeEmailNotSet:             http.StatusBadRequest,
+	CodeEmailAlreadyVerified:    http.StatusBadRequest,
+	CodeEmailUnchanged:          http.StatusBadRequest,
+	CodeEmailReserved:           http.StatusBadRequest,
+	CodeCredentialsNotSet:       http.StatusBadRequest,
+	CodeCredentialsAlreadySet:   http.StatusBadRequest,
+	CodeConfirmRequired:         http.StatusBadRequest,
+	CodeTelegramNotBound:        http.StatusBadRequest,
+	CodeTelegramOnlyAccount:     http.StatusBadRequest,
+	CodeTwoFactorAlreadyEnabled: http.StatusBadRequest,
+	CodeTwoFactorNotEnabled:     http.StatusBadRequest,
+	CodeTwoFactorNotSetup:       http.StatusBadRequest,
+	CodeCannotModifySelf:        http.StatusBadRequest,
+
+	CodeUnauthorized:         http.StatusUnauthorized,
+	CodeSessionRevoked:       http.StatusUnauthorized,
+	CodeEmailOrPasswordWrong: http.StatusUnauthorized,
+	CodePasswordWrong:        http.StatusUnauthorized,
+	CodeInvalidRefreshToken:  http.StatusUnauthorized,
+	CodeInvalidChallenge:     http.StatusUnauthorized,
+	CodeInvalidCode:          http.StatusUnauthorized,
+	CodeInvalidAPIKey:        http.StatusUnauthorized,
+	CodeInvalidSignature:     http.StatusUnauthorized,
+	CodeInvalidTelegramLogin: http.StatusUnauthorized,
+
+	CodeForbidden:         http.StatusForbidden,
+	CodeAccountDisabled:   http.StatusForbidden,
+	CodeEmailNotVerified:  http.StatusForbidden,
+	CodeInsufficientScope: http.StatusForbidden,
+
+	CodeUserNotFound:         http.StatusNotFound,
+	CodeSubscriptionNotFound: http.StatusNotFound,
+	CodeRuleNotFound:         http.StatusNotFound,
+	CodeSessionNotFound:      http.StatusNotFound,
+	CodeAPIKeyNotFound:       http.StatusNotFound,
+	CodeWebhookNotFound:      http.StatusNotFound,
+	CodeBindTokenNotFound:    http.StatusNotFound,
+
+	CodeEmailAlreadyRegistered: http.StatusConflict,
+	CodeTelegramAlreadyBound:   http.StatusConflict,
+	CodeBindTokenUsed:          http.StatusConflict,
+	CodeDuplicateRule:          http.StatusConflict,
+
+	CodeTooManyRequests: http.StatusTooManyRequests,
+	CodeAccountLocked:   http.StatusTooManyRequests,
+
+	CodeServerError:    http.StatusInternalServerError,
+	CodeSendMailFailed: http.StatusInternalServerError,
+}
+
+// Status 错误码对应的 HTTP 状态码，未登记的错误码按 500
+func (code ErrorCode) Status() int {
+	if s, ok := statuses[code]; ok {
+		return s
+	}
+	return http.StatusInternalServerError
+}
+
+// Message 错误码的文案
+func (code ErrorCode) Message(lang string) string {
+	return i18n.T(lang, "error."+string(code))
+}
+
+// CatalogEntry 错误码目录项
+type CatalogEntry struct {
+	Code     ErrorCode         `json:"code"`
+	Status   int               `json:"status"`
+	Messages map[string]string `json:"messages"` // 语言 -> 文案
+}
+
+// Catalog 全部错误码，按 HTTP 状态码、错误码排序
+func Catalog() []CatalogEntry {
+	entries := make([]CatalogEntry, 0, len(statuses))
+	for code, status := range statuses {
+		messages := make(map[string]string, len(i18n.Languages))
+		for _, lang := range i18n.Languages {
+			messages[lang] = code.Message(lang)
+		}
+		entries = append(entries, CatalogEntry{Code: code, Status: status, Messages: messages})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Status != entries[j].Status {
+			return entries[i].Status < entries[j].Status
+		}
+		return entries[i].Code < entries[j].Code
+	})
+	return entries
+}
+
+// Codes 全部错误码，按字母排序
+func Codes() []string {
+	codes := make([]string, 0, len(statuses))
+	for code := range statuses {
+		codes = append(codes, string(code))
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+// SetupCatalogRoutes 注册错误码目录接口
+func SetupCatalogRoutes(router *gin.RouterGroup) {
+	router.GET("/errors", ServeCatalog)
+}
+
+// ServeCatalog 返回机器可读的错误码目录：错误码、HTTP 状态码与各语言文案
+func ServeCatalog(c *gin.Context) {
+	OK(c, gin.H{"data": Catalog()})
+}
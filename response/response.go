@@ -0,0 +1,82 @@
+// Package response 统一响应结构与错误码：所有接口返回 Response{error, code, message, data}，
+// 默认 HTTP 状态码为 200、以 code 区分结果（原本已返回真实状态码的错误除外，见 FailStatus）；
+// 请求头 X-API-Version: 2 时 HTTP 状态码与 code 一致
+package response
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/cryptoSelect/backendapi/utils/i18n"
+	"github.com/cryptoSelect/backendapi/utils/logger"
+	"github.com/gin-gonic/gin"
+)
+
+// HeaderAPIVersion 客户端声明的接口版本；2 及以上时错误响应使用真实的 HTTP 状态码
+const HeaderAPIVersion = "X-API-Version"
+
+// Response 统一响应结构
+type Response struct {
+	Error   ErrorCode   `json:"error"`             // 错误码，成功时为空
+	Code    int         `json:"code"`              // 200 成功，否则为错误码对应的 HTTP 状态码
+	Message string      `json:"message,omitempty"` // 错误码的文案，按 Accept-Language 选择语言
+	Data    interface{} `json:"data"`
+}
+
+// HTTPStatusMode 客户端是否选择了真实 HTTP 状态码（X-API-Version >= 2）
+func HTTPStatusMode(c *gin.Context) bool {
+	v, err := strconv.Atoi(strings.TrimSpace(c.GetHeader(HeaderAPIVersion)))
+	return err == nil && v >= 2
+}
+
+// Language 按 Accept-Language 的首选语言选择文案语言，不支持时使用默认语言
+func Language(c *gin.Context) string {
+	first := strings.Split(c.GetHeader("Accept-Language"), ",")[0]
+	first = strings.ToLower(strings.TrimSpace(strings.Split(first, ";")[0]))
+	for _, lang := range i18n.Languages {
+		if first == lang || strings.HasPrefix(first, lang+"-") {
+			return lang
+		}
+	}
+	return i18n.Default
+}
+
+// OK 成功响应
+func OK(c *gin.Context, data interface{}) {
+	c.JSON(http.StatusOK, Response{Code: http.StatusOK, Data: data})
+}
+
+// Fail 错误响应，data 为错误详情（如出错的参数、位置），没有时传 nil
+func Fail(c *gin.Context, code ErrorCode, data interface{}) {
+	status := http.StatusOK
+	if HTTPStatusMode(c) {
+		status = code.Status()
+	}
+	c.JSON(status, Response{Error: code, Code: code.Status(), Message: code.Message(Language(c)), Data: data})
+}
+
+// FailStatus 错误响应，不论 X-API-Version 都使用错误码对应的 HTTP 状态码；
+// 用于统一响应前已返回真实状态码的错误（如 /api/funds 缺少 symbol、登录请求体有误），保持与旧客户端兼容
+func FailStatus(c *gin.Context, code ErrorCode, data interface{}) {
+	c.JSON(code.Status(), Response{Error: code, Code: code.Status(), Message: code.Message(Language(c)), Data: data})
+}
+
+// Abort 错误响应并中止后续处理，用于中间件
+func Abort(c *gin.Context, code ErrorCode, data interface{}) {
+	Fail(c, code, data)
+	c.Abort()
+}
+
+// ServerError 记录内部错误并返回 server_error，错误详情（如数据库错误）只写日志，不返回给客户端
+func ServerError(c *gin.Context, msg string, err error, fields map[string]interface{}) {
+	entry := map[string]interface{}{"path": c.FullPath()}
+	for k, v := range fields {
+		entry[k] = v
+	}
+	if err != nil {
+		entry["error"] = err.Error()
+	}
+	logger.Log.Error(msg, entry)
+	Fail(c, CodeServerError, nil)
+}
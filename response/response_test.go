@@ -0,0 +1,57 @@
+package response
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func serve(version string, handler gin.HandlerFunc) (*httptest.ResponseRecorder, Response) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/", handler)
+	req := httptest.NewRequest("GET", "/", nil)
+	if version != "" {
+		req.Header.Set(HeaderAPIVersion, version)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	var body Response
+	json.Unmarshal(w.Body.Bytes(), &body)
+	return w, body
+}
+
+func TestFailStatusMode(t *testing.T) {
+	tests := []struct {
+		version string
+		status  int
+	}{
+		{"", http.StatusOK},
+		{"1", http.StatusOK},
+		{"abc", http.StatusOK},
+		{"2", http.StatusNotFound},
+		{" 3 ", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		w, body := serve(tt.version, func(c *gin.Context) { Fail(c, CodeSubscriptionNotFound, nil) })
+		if w.Code != tt.status {
+			t.Errorf("X-API-Version %q: status %d, want %d", tt.version, w.Code, tt.status)
+		}
+		if body.Error != CodeSubscriptionNotFound || body.Code != http.StatusNotFound {
+			t.Errorf("X-API-Version %q: body %+v", tt.version, body)
+		}
+	}
+}
+
+// 统一响应前已返回真实状态码的错误不论版本都保持原状态码
+func TestFailStatusAlwaysUsesRealStatus(t *testing.T) {
+	for _, version := range []string{"", "1", "2"} {
+		w, body := serve(version, func(c *gin.Context) { FailStatus(c, CodeInvalidRequest, nil) })
+		if w.Code != http.StatusBadRequest || body.Code != http.StatusBadRequest || body.Error != CodeInvalidRequest {
+			t.Errorf("X-API-Version %q: status %d body %+v, want 400", version, w.Code, body)
+		}
+	}
+}
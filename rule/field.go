@@ -0,0 +1,71 @@
+package rule
+
+import (
+	"strings"
+
+	"github.com/cryptoSelect/backendapi/screener"
+	publicModels "github.com/cryptoSelect/public/models"
+)
+
+// Kind 字段值类型
+type Kind int
+
+const (
+	Number Kind = iota
+	String
+)
+
+// Unit 数值单位，决定规则值中 % 的含义，与筛选字段共用同一定义
+type Unit = screener.Unit
+
+const (
+	UnitNone    = screener.UnitNone
+	UnitPercent = screener.UnitPercent
+	UnitRatio   = screener.UnitRatio
+)
+
+// Field 可用于规则的 SymbolRecord 字段
+type Field struct {
+	Name   string
+	Kind   Kind
+	Unit   Unit
+	number func(*publicModels.SymbolRecord) float64
+	text   func(*publicModels.SymbolRecord) string
+}
+
+// fields 由 screener.Fields 派生：除时间字段与标记 NoRule 的字段外均可用于规则
+var fields = func() map[string]Field {
+	m := make(map[string]Field)
+	for _, f := range screener.Fields {
+		if f.NoRule || f.Kind == screener.Time {
+			continue
+		}
+		get := f.Get
+		if f.Kind == screener.String {
+			m[f.Name] = Field{Name: f.Name, Kind: String, text: func(r *publicModels.SymbolRecord) string { return get(r).(string) }}
+			continue
+		}
+		m[f.Name] = Field{Name: f.Name, Kind: Number, Unit: f.Unit, number: func(r *publicModels.SymbolRecord) float64 { return toFloat(get(r)) }}
+	}
+	return m
+}()
+
+func toFloat(v interface{}) float64 {
+	switch x := v.(type) {
+	case float64:
+		return x
+	case int:
+		return float64(x)
+	case int64:
+		return float64(x)
+	case uint:
+		return float64(x)
+	}
+	return 0
+}
+
+// LookupField 按名称（不区分大小写）查找字段
+func LookupField(name string) (Field, bool) {
+	f, ok := fields[strings.ToLower(strings.TrimSpace(name))]
+	return f, ok
+}
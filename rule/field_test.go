@@ -0,0 +1,65 @@
+package rule
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"testing"
+
+	publicModels "github.com/cryptoSelect/public/models"
+)
+
+// 规则字段由 screener.Fields 派生，集合与单位需与原先手写的列表一致
+func TestFieldsDerivedFromScreener(t *testing.T) {
+	want := map[string]Unit{
+		"price": UnitNone, "volume": UnitNone, "taker_buy_volume": UnitNone, "taker_buy_ratio": UnitRatio,
+		"rsi": UnitNone, "rate": UnitNone, "rate_cycle": UnitNone, "cross_type": UnitNone, "shape": UnitNone,
+		"change": UnitPercent, "support": UnitNone, "resistance": UnitNone, "next_funding_time": UnitNone,
+	}
+	texts := []string{"vp_signal", "smc_signal", "fvg", "ob"}
+
+	var names []string
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if len(fields) != len(want)+len(texts) {
+		t.Fatalf("rule fields %v", names)
+	}
+	for name, unit := range want {
+		f, ok := LookupField(strings.ToUpper(name))
+		if !ok || f.Kind != Number || f.Unit != unit {
+			t.Errorf("field %s = %+v, want number with unit %d", name, f, unit)
+		}
+	}
+	for _, name := range texts {
+		if f, ok := LookupField(name); !ok || f.Kind != String {
+			t.Errorf("field %s = %+v, want string", name, f)
+		}
+	}
+	for _, name := range []string{"id", "symbol", "cycle", "description", "cross_time", "updated_at"} {
+		if _, ok := LookupField(name); ok {
+			t.Errorf("field %s must not be usable in rules", name)
+		}
+	}
+}
+
+func TestEvalDerivedGetters(t *testing.T) {
+	rec := &publicModels.SymbolRecord{Shape: 2, NextFundingTime: 1700000000000, TakerBuyRatio: 0.6, Rsi: math.NaN(), Fvg: "bull"}
+	tests := map[string]bool{
+		"shape == 2":                        true,
+		"next_funding_time > 1600000000000": true,
+		"taker_buy_ratio >= 60%":            true,
+		"rsi < 100":                         false, // NaN 视为不满足
+		"fvg == BULL":                       true,
+	}
+	for expr, want := range tests {
+		r, err := Parse(expr)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", expr, err)
+		}
+		if got := r.Eval(rec); got != want {
+			t.Errorf("%q on record = %v, want %v", expr, got, want)
+		}
+	}
+}
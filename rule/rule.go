@@ -0,0 +1,136 @@
+// Package rule 订阅告警规则：解析形如 "rsi >= 70"、"change < -5%" 的单条比较表达式，并针对 SymbolRecord 求值
+package rule
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	publicModels "github.com/cryptoSelect/public/models"
+)
+
+// MaxLength 规则表达式最大长度
+const MaxLength = 128
+
+// 支持的比较运算符，长的在前以便优先匹配
+var operators = []string{">=", "<=", "==", "!=", ">", "<", "="}
+
+// Rule 一条已解析的规则：<field> <op> <value>
+type Rule struct {
+	Field Field
+	Op    string
+	Num   float64
+	Text  string
+}
+
+// Parse 解析规则表达式，字段名不区分大小写，= 等同于 ==，字符串值可加引号
+func Parse(expr string) (*Rule, error) {
+	expr = strings.TrimSpace(expr)
+	if expr == "" {
+		return nil, fmt.Errorf("rule is empty")
+	}
+	if len(expr) > MaxLength {
+		return nil, fmt.Errorf("rule longer than %d characters", MaxLength)
+	}
+	idx, op := -1, ""
+	for i := 0; i < len(expr) && idx < 0; i++ {
+		for _, candidate := range operators {
+			if strings.HasPrefix(expr[i:], candidate) {
+				idx, op = i, candidate
+				break
+			}
+		}
+	}
+	if idx < 0 {
+		return nil, fmt.Errorf("rule %q has no comparison operator", expr)
+	}
+	name := strings.TrimSpace(expr[:idx])
+	value := strings.TrimSpace(expr[idx+len(op):])
+	if op == "=" {
+		op = "=="
+	}
+	field, ok := LookupField(name)
+	if !ok {
+		return nil, fmt.Errorf("unknown field %q", name)
+	}
+	if value == "" {
+		return nil, fmt.Errorf("rule %q has no value", expr)
+	}
+
+	r := &Rule{Field: field, Op: op}
+	if field.Kind == String {
+		if op != "==" && op != "!=" {
+			return nil, fmt.Errorf("field %s only supports == and !=", field.Name)
+		}
+		r.Text = strings.Trim(value, `"'`)
+		return r, nil
+	}
+
+	percent := strings.HasSuffix(value, "%")
+	if percent {
+		value = strings.TrimSpace(strings.TrimSuffix(value, "%"))
+		if field.Unit == UnitNone {
+			return nil, fmt.Errorf("field %s does not accept %%", field.Name)
+		}
+	}
+	v, err := strconv.ParseFloat(value, 64)
+	if err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+		return nil, fmt.Errorf("invalid number %q", value)
+	}
+	if percent && field.Unit == UnitRatio {
+		v /= 100
+	}
+	r.Num = v
+	return r, nil
+}
+
+// String 返回规范化表达式，如 "rsi >= 70"、"change < -5%"
+func (r *Rule) String() string {
+	if r.Field.Kind == String {
+		return fmt.Sprintf("%s %s %q", r.Field.Name, r.Op, r.Text)
+	}
+	v := strconv.FormatFloat(r.Num, 'f', -1, 64)
+	if r.Field.Unit == UnitPercent {
+		v += "%"
+	}
+	return fmt.Sprintf("%s %s %s", r.Field.Name, r.Op, v)
+}
+
+// Eval 对记录求值；数值为 NaN/Inf 时视为不满足
+func (r *Rule) Eval(rec *publicModels.SymbolRecord) bool {
+	if rec == nil {
+		return false
+	}
+	if r.Field.Kind == String {
+		eq := strings.EqualFold(r.Field.text(rec), r.Text)
+		if r.Op == "==" {
+			return eq
+		}
+		return !eq
+	}
+	v := r.Field.number(rec)
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return false
+	}
+	switch r.Op {
+	case ">":
+		return v > r.Num
+	case ">=":
+		return v >= r.Num
+	case "<":
+		return v < r.Num
+	case "<=":
+		return v <= r.Num
+	case "==":
+		return v == r.Num
+	case "!=":
+		return v != r.Num
+	}
+	return false
+}
+
+// Triggered 规则在 prev 上不满足、在 cur 上满足，即发生了一次"进入"
+func (r *Rule) Triggered(prev, cur *publicModels.SymbolRecord) bool {
+	return !r.Eval(prev) && r.Eval(cur)
+}
@@ -0,0 +1,110 @@
+package screener
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	publicModels "github.com/cryptoSelect/public/models"
+)
+
+// ErrInvalidCursor 游标无法解析，或与当前排序方式不一致
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// cursorPayload 游标内容：排序方式、上一页最后一条记录的排序键取值与 id
+type cursorPayload struct {
+	Order  string   `json:"o"`
+	Values []string `json:"v"`
+	ID     uint     `json:"id"`
+}
+
+// formatValue 排序键取值转为文本；浮点数按文本保存以便表示 NaN
+func formatValue(v interface{}) string {
+	switch x := v.(type) {
+	case float64:
+		return strconv.FormatFloat(x, 'g', -1, 64)
+	case time.Time:
+		return x.UTC().Format(time.RFC3339Nano)
+	case string:
+		return x
+	case int:
+		return strconv.Itoa(x)
+	case int64:
+		return strconv.FormatInt(x, 10)
+	case uint:
+		return strconv.FormatUint(uint64(x), 10)
+	}
+	return ""
+}
+
+func parseValue(f Field, s string) (interface{}, error) {
+	switch f.Kind {
+	case Float:
+		return strconv.ParseFloat(s, 64)
+	case Int:
+		return strconv.ParseInt(s, 10, 64)
+	case Time:
+		return time.Parse(time.RFC3339Nano, s)
+	}
+	return s, nil
+}
+
+// EncodeCursor 以记录 r 作为上一页最后一条，生成下一页的不透明游标
+func EncodeCursor(keys []OrderKey, r *publicModels.SymbolRecord) string {
+	p := cursorPayload{Order: orderSignature(keys), ID: r.ID}
+	for _, k := range keys {
+		p.Values = append(p.Values, formatValue(k.Field.Get(r)))
+	}
+	b, _ := json.Marshal(p)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// CursorSQL 解析游标，返回"排在该记录之后"的条件（按 terms 展开后的排序表达式 t1..tn）：
+// (t1 > v1) OR (t1 = v1 AND t2 > v2) OR ... OR (t1 = v1 AND ... AND tn = vn AND id > last_id)，降序使用 <
+func CursorSQL(keys []OrderKey, token string) (string, []interface{}, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return "", nil, ErrInvalidCursor
+	}
+	var p cursorPayload
+	if json.Unmarshal(raw, &p) != nil || p.Order != orderSignature(keys) || len(p.Values) != len(keys) {
+		return "", nil, ErrInvalidCursor
+	}
+	values := make([]interface{}, len(keys))
+	for i, k := range keys {
+		if values[i], err = parseValue(k.Field, p.Values[i]); err != nil {
+			return "", nil, ErrInvalidCursor
+		}
+		if f, ok := values[i].(float64); ok && math.IsInf(f, 0) {
+			return "", nil, ErrInvalidCursor
+		}
+	}
+
+	ts := terms(keys, values)
+	var ors []string
+	var args []interface{}
+	for i := 0; i <= len(ts); i++ {
+		var ands []string
+		for j := 0; j < i; j++ {
+			ands = append(ands, ts[j].expr+" = ?")
+			args = append(args, ts[j].value)
+		}
+		if i == len(ts) {
+			ands = append(ands, "id > ?")
+			args = append(args, p.ID)
+		} else {
+			op := " > ?"
+			if ts[i].desc {
+				op = " < ?"
+			}
+			ands = append(ands, ts[i].expr+op)
+			args = append(args, ts[i].value)
+		}
+		ors = append(ors, "("+strings.Join(ands, " AND ")+")")
+	}
+	return "(" + strings.Join(ors, " OR ") + ")", args, nil
+}
@@ -0,0 +1,170 @@
+package screener
+
+import (
+	"encoding/base64"
+	"errors"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	publicModels "github.com/cryptoSelect/public/models"
+)
+
+func mustSort(t *testing.T, spec string, nanFirst bool) []OrderKey {
+	t.Helper()
+	keys, err := ParseSort(spec, nanFirst)
+	if err != nil {
+		t.Fatalf("ParseSort(%q): %v", spec, err)
+	}
+	return keys
+}
+
+// predicate 按 terms 展开后的各项拼出期望的游标条件
+func predicate(ts []string, ops []string) string {
+	var ors []string
+	for i := 0; i <= len(ts); i++ {
+		var ands []string
+		for j := 0; j < i; j++ {
+			ands = append(ands, ts[j]+" = ?")
+		}
+		if i == len(ts) {
+			ands = append(ands, "id > ?")
+		} else {
+			ands = append(ands, ts[i]+" "+ops[i]+" ?")
+		}
+		ors = append(ors, "("+strings.Join(ands, " AND ")+")")
+	}
+	return "(" + strings.Join(ors, " OR ") + ")"
+}
+
+func TestCursorSQLMixedOrder(t *testing.T) {
+	keys := mustSort(t, "-rsi,cycle", false)
+	rec := &publicModels.SymbolRecord{ID: 42, Rsi: 55.5, Cycle: "4h"}
+	sql, args, err := CursorSQL(keys, EncodeCursor(keys, rec))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const missing, value = "(rsi IS NULL OR rsi = 'NaN')", "COALESCE(rsi, 'NaN')"
+	cycle := ordinalSQL(keys[1].Field)
+	// rsi 降序用 <，NaN/NULL 排在最后用 >，cycle 升序用 >
+	if want := predicate([]string{missing, value, cycle}, []string{">", "<", ">"}); sql != want {
+		t.Fatalf("CursorSQL\n got %s\nwant %s", sql, want)
+	}
+	want := []interface{}{
+		false,
+		false, 55.5,
+		false, 55.5, 4,
+		false, 55.5, 4, uint(42),
+	}
+	if !reflect.DeepEqual(args, want) {
+		t.Fatalf("args = %#v, want %#v", args, want)
+	}
+}
+
+// 排序键全部相同时由 id 决定先后
+func TestCursorSQLIDTiebreaker(t *testing.T) {
+	keys := mustSort(t, "shape", false)
+	for _, id := range []uint{1, 7, 1 << 40} {
+		sql, args, err := CursorSQL(keys, EncodeCursor(keys, &publicModels.SymbolRecord{ID: id, Shape: 2}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := "((shape > ?) OR (shape = ? AND id > ?))"; sql != want {
+			t.Fatalf("CursorSQL = %s, want %s", sql, want)
+		}
+		if want := []interface{}{int64(2), int64(2), id}; !reflect.DeepEqual(args, want) {
+			t.Fatalf("args = %#v, want %#v", args, want)
+		}
+	}
+}
+
+// 上一页最后一条为 NaN 时，游标停在 NaN/NULL 分组内，之后只按 id 继续
+func TestCursorSQLNaN(t *testing.T) {
+	for _, nanFirst := range []bool{false, true} {
+		keys := mustSort(t, "rsi", nanFirst)
+		sql, args, err := CursorSQL(keys, EncodeCursor(keys, &publicModels.SymbolRecord{ID: 9, Rsi: math.NaN()}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		missingOp := ">"
+		if nanFirst {
+			missingOp = "<"
+		}
+		if want := predicate([]string{"(rsi IS NULL OR rsi = 'NaN')", "COALESCE(rsi, 'NaN')"}, []string{missingOp, ">"}); sql != want {
+			t.Fatalf("nanFirst=%v CursorSQL\n got %s\nwant %s", nanFirst, sql, want)
+		}
+		if len(args) != 6 || args[0] != true || args[1] != true || args[3] != true || args[5] != uint(9) {
+			t.Fatalf("nanFirst=%v args = %#v", nanFirst, args)
+		}
+		for _, i := range []int{2, 4} {
+			if f, ok := args[i].(float64); !ok || !math.IsNaN(f) {
+				t.Fatalf("args[%d] = %#v, want NaN", i, args[i])
+			}
+		}
+	}
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	keys := mustSort(t, "-updated_at,symbol,-taker_buy_ratio,rate_cycle", true)
+	updated := time.Date(2025, 3, 4, 5, 6, 7, 891011, time.FixedZone("UTC+8", 8*3600))
+	rec := &publicModels.SymbolRecord{ID: 3, UpdatedAt: updated, Symbol: "BTCUSDT", TakerBuyRatio: 0.1 + 0.2, RateCycle: 8}
+	_, args, err := CursorSQL(keys, EncodeCursor(keys, rec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 最后一组为全部排序项取值与 id
+	last := args[len(args)-6:]
+	if ts, ok := last[0].(time.Time); !ok || !ts.Equal(updated) {
+		t.Errorf("updated_at = %#v, want %v", last[0], updated)
+	}
+	if want := []interface{}{"BTCUSDT", false, 0.1 + 0.2, int64(8), uint(3)}; !reflect.DeepEqual(last[1:], want) {
+		t.Errorf("values = %#v, want %#v", last[1:], want)
+	}
+}
+
+func TestCursorSQLInvalid(t *testing.T) {
+	keys := mustSort(t, "-rsi,cycle", false)
+	encode := func(payload string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(payload))
+	}
+	valid := EncodeCursor(keys, &publicModels.SymbolRecord{ID: 1, Rsi: 10, Cycle: "1h"})
+	tests := map[string]string{
+		"not base64":      "%%%",
+		"padded base64":   valid + "==",
+		"not json":        encode("hello"),
+		"json array":      encode(`[1,2]`),
+		"other order":     EncodeCursor(mustSort(t, "rsi,cycle", false), &publicModels.SymbolRecord{ID: 1, Cycle: "1h"}),
+		"nan order":       EncodeCursor(mustSort(t, "-rsi,cycle", true), &publicModels.SymbolRecord{ID: 1, Cycle: "1h"}),
+		"missing values":  encode(`{"o":"-rsi,cycle","v":["10"],"id":1}`),
+		"extra values":    encode(`{"o":"-rsi,cycle","v":["10","1h","x"],"id":1}`),
+		"null values":     encode(`{"o":"-rsi,cycle","v":null,"id":1}`),
+		"bad number":      encode(`{"o":"-rsi,cycle","v":["abc","1h"],"id":1}`),
+		"infinite number": encode(`{"o":"-rsi,cycle","v":["+Inf","1h"],"id":1}`),
+		"negative id":     encode(`{"o":"-rsi,cycle","v":["10","1h"],"id":-1}`),
+		"wrong types":     encode(`{"o":1,"v":[10,"1h"],"id":"1"}`),
+	}
+	for name, token := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, _, err := CursorSQL(keys, token); !errors.Is(err, ErrInvalidCursor) {
+				t.Fatalf("err = %v, want ErrInvalidCursor", err)
+			}
+		})
+	}
+
+	intKeys := mustSort(t, "shape,-updated_at", false)
+	for _, payload := range []string{
+		`{"o":"shape,-updated_at","v":["1.5","2025-01-01T00:00:00Z"],"id":1}`,
+		`{"o":"shape,-updated_at","v":["1","yesterday"],"id":1}`,
+	} {
+		if _, _, err := CursorSQL(intKeys, encode(payload)); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("%s: err = %v, want ErrInvalidCursor", payload, err)
+		}
+	}
+	// 不在周期列表中的值只影响排序位置，不应 panic
+	if _, _, err := CursorSQL(keys, encode(`{"o":"-rsi,cycle","v":["10","2h"],"id":1}`)); err != nil {
+		t.Errorf("unknown cycle: %v", err)
+	}
+}
@@ -0,0 +1,97 @@
+package screener
+
+import (
+	"strings"
+
+	"github.com/cryptoSelect/backendapi/preference"
+	"github.com/cryptoSelect/backendapi/utils/sanitize"
+	publicModels "github.com/cryptoSelect/public/models"
+)
+
+// Kind 字段值类型
+type Kind int
+
+const (
+	Float Kind = iota // 浮点数，可能为 NaN
+	Int
+	String
+	Time
+)
+
+// Unit 数值单位，决定表达式与告警规则值中 % 的含义
+type Unit int
+
+const (
+	UnitNone    Unit = iota // 不允许 %
+	UnitPercent             // 字段本身即百分数，% 仅作后缀，如 change < -5%
+	UnitRatio               // 字段为 0~1 比例，60% 按 0.6 处理
+)
+
+// Field symbol_records 中可用于筛选的列，Name 与接口返回的 JSON 字段名一致
+type Field struct {
+	Name    string
+	Column  string
+	Kind    Kind
+	Unit    Unit
+	Upper   bool                                           // 值统一转大写，如 symbol
+	Ordinal []string                                       // 排序时按此顺序而非字典序，如周期
+	NoSort  bool                                           // 不允许排序，如长文本
+	Extra   bool                                           // 默认不返回，需通过 fields 参数指定
+	NoRule  bool                                           // 不可用于告警规则（时间字段同样不可用），如订阅本身已限定的 symbol/cycle
+	Get     func(r *publicModels.SymbolRecord) interface{} // 取记录中的原始值
+}
+
+// Fields 全部字段，顺序即接口返回的字段顺序
+var Fields = []Field{
+	{Name: "id", Column: "id", NoRule: true, Kind: Int, Get: func(r *publicModels.SymbolRecord) interface{} { return r.ID }},
+	{Name: "symbol", Column: "symbol", NoRule: true, Kind: String, Upper: true, Get: func(r *publicModels.SymbolRecord) interface{} { return r.Symbol }},
+	{Name: "cycle", Column: "cycle", NoRule: true, Kind: String, Ordinal: preference.Cycles, Get: func(r *publicModels.SymbolRecord) interface{} { return r.Cycle }},
+	{Name: "shape", Column: "shape", Kind: Int, Get: func(r *publicModels.SymbolRecord) interface{} { return r.Shape }},
+	{Name: "rsi", Column: "rsi", Kind: Float, Get: func(r *publicModels.SymbolRecord) interface{} { return r.Rsi }},
+	{Name: "cross_type", Column: "cross_type", Kind: Int, Get: func(r *publicModels.SymbolRecord) interface{} { return r.CrossType }},
+	{Name: "cross_time", Column: "cross_time", Kind: Time, Get: func(r *publicModels.SymbolRecord) interface{} { return r.CrossTime }},
+	{Name: "price", Column: "price", Kind: Float, Get: func(r *publicModels.SymbolRecord) interface{} { return r.Price }},
+	{Name: "volume", Column: "volume", Kind: Float, Get: func(r *publicModels.SymbolRecord) interface{} { return r.Volume }},
+	{Name: "taker_buy_volume", Column: "taker_buy_volume", Kind: Float, Get: func(r *publicModels.SymbolRecord) interface{} { return r.TakerBuyVolume }},
+	{Name: "taker_buy_ratio", Column: "taker_buy_ratio", Kind: Float, Unit: UnitRatio, Get: func(r *publicModels.SymbolRecord) interface{} { return r.TakerBuyRatio }},
+	{Name: "rate", Column: "rate", Kind: Float, Get: func(r *publicModels.SymbolRecord) interface{} { return r.Rate }},
+	{Name: "rate_cycle", Column: "rate_cycle", Kind: Int, Get: func(r *publicModels.SymbolRecord) interface{} { return r.RateCycle }},
+	{Name: "change", Column: "change", Kind: Float, Unit: UnitPercent, Get: func(r *publicModels.SymbolRecord) interface{} { return r.Change }},
+	{Name: "support", Column: "support", Extra: true, Kind: Float, Get: func(r *publicModels.SymbolRecord) interface{} { return r.Support }},
+	{Name: "resistance", Column: "resistance", Extra: true, Kind: Float, Get: func(r *publicModels.SymbolRecord) interface{} { return r.Resistance }},
+	{Name: "next_funding_time", Column: "next_funding_time", Extra: true, Kind: Int, Get: func(r *publicModels.SymbolRecord) interface{} { return r.NextFundingTime }},
+	{Name: "updated_at", Column: "updated_at", Kind: Time, Get: func(r *publicModels.SymbolRecord) interface{} { return r.UpdatedAt }},
+	{Name: "vp_signal", Column: "vp_signal", Kind: String, Get: func(r *publicModels.SymbolRecord) interface{} { return r.VpSignal }},
+	{Name: "smc_signal", Column: "smc_signal", Kind: String, Get: func(r *publicModels.SymbolRecord) interface{} { return r.SMCSignal }},
+	{Name: "fvg", Column: "fvg", Kind: String, Get: func(r *publicModels.SymbolRecord) interface{} { return r.Fvg }},
+	{Name: "ob", Column: "ob", Kind: String, Get: func(r *publicModels.SymbolRecord) interface{} { return r.Ob }},
+	{Name: "description", Column: "description", NoRule: true, Extra: true, Kind: String, NoSort: true, Get: func(r *publicModels.SymbolRecord) interface{} { return r.Description }},
+}
+
+var fieldIndex = func() map[string]Field {
+	m := make(map[string]Field, len(Fields))
+	for _, f := range Fields {
+		m[f.Name] = f
+	}
+	return m
+}()
+
+// LookupField 按名称（不区分大小写）查找字段
+func LookupField(name string) (Field, bool) {
+	f, ok := fieldIndex[strings.ToLower(strings.TrimSpace(name))]
+	return f, ok
+}
+
+// Value 接口输出的取值，浮点数 NaN/Inf 置为 0
+func (f Field) Value(r *publicModels.SymbolRecord) interface{} {
+	v := f.Get(r)
+	if x, ok := v.(float64); ok {
+		return sanitize.Float(x)
+	}
+	return v
+}
+
+// Numeric 是否为数值或时间字段（可比较大小）
+func (f Field) Numeric() bool {
+	return f.Kind != String
+}
@@ -0,0 +1,432 @@
+// Package screener 标的筛选：symbol_records 字段注册表，以及筛选表达式的解析与 SQL 转换。
+//
+// 表达式示例：rsi between 30 and 40 and taker_buy_ratio > 0.55 and cycle in (1h, 4h)
+//
+//	expr       = or
+//	or         = and { "or" and }
+//	and        = unary { "and" unary }
+//	unary      = "not" unary | "(" expr ")" | comparison
+//	comparison = field op value | field ["not"] "between" value "and" value | field ["not"] "in" "(" value { "," value } ")"
+//	op         = "=" | "==" | "!=" | "<>" | ">" | ">=" | "<" | "<="
+//
+// 字段只能是 Fields 中的列，值一律作为 SQL 参数传递
+package screener
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// MaxLength 表达式最大长度
+	MaxLength = 512
+	// MaxDepth 括号与 not 的最大嵌套层数
+	MaxDepth = 16
+	// MaxInValues in 列表最多元素个数
+	MaxInValues = 50
+)
+
+// SyntaxError 表达式错误，Pos 为出错位置（从 1 开始的字符序号）
+type SyntaxError struct {
+	Pos     int
+	Message string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("position %d: %s", e.Pos, e.Message)
+}
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokWord
+	tokString
+	tokOp
+	tokLParen
+	tokRParen
+	tokComma
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int // 从 1 开始的字符序号
+}
+
+func (t token) keyword(k string) bool {
+	return t.kind == tokWord && strings.EqualFold(t.text, k)
+}
+
+func (t token) describe() string {
+	if t.kind == tokEOF {
+		return "end of filter"
+	}
+	return fmt.Sprintf("%q", t.text)
+}
+
+// lex 切分表达式；位置按字符（rune）计数
+func lex(expr string) ([]token, error) {
+	runes := []rune(expr)
+	var tokens []token
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		pos := i + 1
+		switch {
+		case r == ' ' || r == '\t' || r == '\n' || r == '\r':
+			i++
+		case r == '(':
+			tokens = append(tokens, token{tokLParen, "(", pos})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{tokRParen, ")", pos})
+			i++
+		case r == ',':
+			tokens = append(tokens, token{tokComma, ",", pos})
+			i++
+		case r == '"' || r == '\'':
+			j := i + 1
+			for j < len(runes) && runes[j] != r {
+				j++
+			}
+			if j >= len(runes) {
+				return nil, &SyntaxError{pos, "unterminated string"}
+			}
+			tokens = append(tokens, token{tokString, string(runes[i+1 : j]), pos})
+			i = j + 1
+		case strings.ContainsRune("=!<>", r):
+			j := i + 1
+			if j < len(runes) && (runes[j] == '=' || (r == '<' && runes[j] == '>')) {
+				j++
+			}
+			op := string(runes[i:j])
+			if op == "!" {
+				return nil, &SyntaxError{pos, `unexpected "!", did you mean "!="?`}
+			}
+			tokens = append(tokens, token{tokOp, op, pos})
+			i = j
+		default:
+			j := i
+			for j < len(runes) && !strings.ContainsRune(" \t\n\r(),\"'=!<>", runes[j]) {
+				j++
+			}
+			tokens = append(tokens, token{tokWord, string(runes[i:j]), pos})
+			i = j
+		}
+	}
+	return append(tokens, token{tokEOF, "", len(runes) + 1}), nil
+}
+
+// Node 表达式语法树节点
+type Node interface {
+	// SQL 返回参数化的条件片段与参数
+	SQL() (string, []interface{})
+}
+
+// Logical and / or
+type Logical struct {
+	Op    string // AND / OR
+	Left  Node
+	Right Node
+}
+
+// Not 取反
+type Not struct {
+	Expr Node
+}
+
+// Compare field op value
+type Compare struct {
+	Field Field
+	Op    string
+	Value interface{}
+}
+
+// Between field [not] between low and high
+type Between struct {
+	Field     Field
+	Negate    bool
+	Low, High interface{}
+}
+
+// In field [not] in (v1, v2, ...)
+type In struct {
+	Field  Field
+	Negate bool
+	Values []interface{}
+}
+
+func (n *Logical) SQL() (string, []interface{}) {
+	l, la := n.Left.SQL()
+	r, ra := n.Right.SQL()
+	return "(" + l + " " + n.Op + " " + r + ")", append(la, ra...)
+}
+
+func (n *Not) SQL() (string, []interface{}) {
+	s, args := n.Expr.SQL()
+	return "NOT (" + s + ")", args
+}
+
+// notNaN 浮点列为 NaN 时任何条件都不成立（Postgres 中 NaN 大于所有数）
+func notNaN(f Field, cond string) string {
+	if f.Kind != Float {
+		return cond
+	}
+	return "(" + cond + " AND " + f.Column + " <> 'NaN')"
+}
+
+func (n *Compare) SQL() (string, []interface{}) {
+	return notNaN(n.Field, n.Field.Column+" "+n.Op+" ?"), []interface{}{n.Value}
+}
+
+func (n *Between) SQL() (string, []interface{}) {
+	op := "BETWEEN"
+	if n.Negate {
+		op = "NOT BETWEEN"
+	}
+	return notNaN(n.Field, n.Field.Column+" "+op+" ? AND ?"), []interface{}{n.Low, n.High}
+}
+
+func (n *In) SQL() (string, []interface{}) {
+	op := "IN"
+	if n.Negate {
+		op = "NOT IN"
+	}
+	return notNaN(n.Field, n.Field.Column+" "+op+" ?"), []interface{}{n.Values}
+}
+
+// Filter 已解析的筛选表达式
+type Filter struct {
+	Expr string
+	Root Node
+}
+
+// SQL 返回可直接传给 gorm Where 的条件与参数
+func (f *Filter) SQL() (string, []interface{}) {
+	return f.Root.SQL()
+}
+
+// Parse 解析筛选表达式，空表达式返回 nil；错误为 *SyntaxError
+func Parse(expr string) (*Filter, error) {
+	if strings.TrimSpace(expr) == "" {
+		return nil, nil
+	}
+	if n := len([]rune(expr)); n > MaxLength {
+		return nil, &SyntaxError{MaxLength + 1, fmt.Sprintf("filter longer than %d characters", MaxLength)}
+	}
+	tokens, err := lex(expr)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	root, err := p.parseOr(0)
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokEOF {
+		return nil, &SyntaxError{t.pos, fmt.Sprintf("unexpected %s, expected \"and\", \"or\" or end of filter", t.describe())}
+	}
+	return &Filter{Expr: expr, Root: root}, nil
+}
+
+type parser struct {
+	tokens []token
+	i      int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.i]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.i]
+	if t.kind != tokEOF {
+		p.i++
+	}
+	return t
+}
+
+func (p *parser) parseOr(depth int) (Node, error) {
+	left, err := p.parseAnd(depth)
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().keyword("or") {
+		p.next()
+		right, err := p.parseAnd(depth)
+		if err != nil {
+			return nil, err
+		}
+		left = &Logical{Op: "OR", Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd(depth int) (Node, error) {
+	left, err := p.parseUnary(depth)
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().keyword("and") {
+		p.next()
+		right, err := p.parseUnary(depth)
+		if err != nil {
+			return nil, err
+		}
+		left = &Logical{Op: "AND", Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseUnary(depth int) (Node, error) {
+	t := p.peek()
+	if depth > MaxDepth {
+		return nil, &SyntaxError{t.pos, fmt.Sprintf("filter nested deeper than %d levels", MaxDepth)}
+	}
+	if t.keyword("not") {
+		p.next()
+		expr, err := p.parseUnary(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		return &Not{Expr: expr}, nil
+	}
+	if t.kind == tokLParen {
+		p.next()
+		expr, err := p.parseOr(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		if c := p.next(); c.kind != tokRParen {
+			return nil, &SyntaxError{c.pos, fmt.Sprintf("expected \")\", got %s", c.describe())}
+		}
+		return expr, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *parser) parseComparison() (Node, error) {
+	t := p.next()
+	if t.kind != tokWord {
+		return nil, &SyntaxError{t.pos, fmt.Sprintf("expected field name, got %s", t.describe())}
+	}
+	field, ok := LookupField(t.text)
+	if !ok {
+		return nil, &SyntaxError{t.pos, fmt.Sprintf("unknown field %q", t.text)}
+	}
+	negate := false
+	if p.peek().keyword("not") {
+		p.next()
+		negate = true
+		if k := p.peek(); !k.keyword("in") && !k.keyword("between") {
+			return nil, &SyntaxError{k.pos, fmt.Sprintf("expected \"in\" or \"between\" after \"not\", got %s", k.describe())}
+		}
+	}
+	op := p.next()
+	switch {
+	case op.keyword("between"):
+		if !field.Numeric() {
+			return nil, &SyntaxError{op.pos, fmt.Sprintf("field %s does not support between", field.Name)}
+		}
+		low, err := p.parseValue(field)
+		if err != nil {
+			return nil, err
+		}
+		if a := p.next(); !a.keyword("and") {
+			return nil, &SyntaxError{a.pos, fmt.Sprintf("expected \"and\" in between, got %s", a.describe())}
+		}
+		high, err := p.parseValue(field)
+		if err != nil {
+			return nil, err
+		}
+		return &Between{Field: field, Negate: negate, Low: low, High: high}, nil
+	case op.keyword("in"):
+		if l := p.next(); l.kind != tokLParen {
+			return nil, &SyntaxError{l.pos, fmt.Sprintf("expected \"(\" after in, got %s", l.describe())}
+		}
+		var values []interface{}
+		for {
+			v, err := p.parseValue(field)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, v)
+			sep := p.next()
+			if sep.kind == tokRParen {
+				break
+			}
+			if sep.kind != tokComma {
+				return nil, &SyntaxError{sep.pos, fmt.Sprintf("expected \",\" or \")\", got %s", sep.describe())}
+			}
+			if len(values) >= MaxInValues {
+				return nil, &SyntaxError{sep.pos, fmt.Sprintf("in list longer than %d values", MaxInValues)}
+			}
+		}
+		return &In{Field: field, Negate: negate, Values: values}, nil
+	case op.kind == tokOp:
+		sqlOp := op.text
+		switch sqlOp {
+		case "==":
+			sqlOp = "="
+		case "!=":
+			sqlOp = "<>"
+		}
+		if !field.Numeric() && sqlOp != "=" && sqlOp != "<>" {
+			return nil, &SyntaxError{op.pos, fmt.Sprintf("field %s only supports =, != and in", field.Name)}
+		}
+		v, err := p.parseValue(field)
+		if err != nil {
+			return nil, err
+		}
+		return &Compare{Field: field, Op: sqlOp, Value: v}, nil
+	default:
+		return nil, &SyntaxError{op.pos, fmt.Sprintf("expected operator after %s, got %s", field.Name, op.describe())}
+	}
+}
+
+// parseValue 按字段类型解析值
+func (p *parser) parseValue(field Field) (interface{}, error) {
+	t := p.next()
+	if t.kind != tokWord && t.kind != tokString {
+		return nil, &SyntaxError{t.pos, fmt.Sprintf("expected value for %s, got %s", field.Name, t.describe())}
+	}
+	switch field.Kind {
+	case String:
+		if field.Upper {
+			return strings.ToUpper(t.text), nil
+		}
+		return t.text, nil
+	case Time:
+		for _, layout := range []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02"} {
+			if v, err := time.Parse(layout, t.text); err == nil {
+				return v, nil
+			}
+		}
+		return nil, &SyntaxError{t.pos, fmt.Sprintf("invalid time %q for %s, use RFC 3339 or YYYY-MM-DD", t.text, field.Name)}
+	}
+	text := t.text
+	percent := strings.HasSuffix(text, "%")
+	if percent {
+		text = strings.TrimSuffix(text, "%")
+		if field.Unit == UnitNone {
+			return nil, &SyntaxError{t.pos, fmt.Sprintf("field %s does not accept %%", field.Name)}
+		}
+	}
+	v, err := strconv.ParseFloat(text, 64)
+	if t.kind != tokWord || err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+		return nil, &SyntaxError{t.pos, fmt.Sprintf("invalid number %q for %s", t.text, field.Name)}
+	}
+	if percent && field.Unit == UnitRatio {
+		v /= 100
+	}
+	if field.Kind == Int {
+		if v != math.Trunc(v) {
+			return nil, &SyntaxError{t.pos, fmt.Sprintf("field %s expects an integer, got %q", field.Name, t.text)}
+		}
+		return int64(v), nil
+	}
+	return v, nil
+}
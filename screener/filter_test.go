@@ -0,0 +1,143 @@
+package screener
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseSQL(t *testing.T) {
+	tests := []struct {
+		expr string
+		sql  string
+		args []interface{}
+	}{
+		{
+			"rsi between 30 and 40 and taker_buy_ratio > 0.55 and cycle in (1h, 4h)",
+			"(((rsi BETWEEN ? AND ? AND rsi <> 'NaN') AND (taker_buy_ratio > ? AND taker_buy_ratio <> 'NaN')) AND cycle IN ?)",
+			[]interface{}{30.0, 40.0, 0.55, []interface{}{"1h", "4h"}},
+		},
+		// and 优先于 or
+		{
+			"shape = 1 or shape = 2 and rsi < 30",
+			"(shape = ? OR (shape = ? AND (rsi < ? AND rsi <> 'NaN')))",
+			[]interface{}{int64(1), int64(2), 30.0},
+		},
+		{
+			"(shape = 1 or shape = 2) and not rsi >= 70",
+			"((shape = ? OR shape = ?) AND NOT ((rsi >= ? AND rsi <> 'NaN')))",
+			[]interface{}{int64(1), int64(2), 70.0},
+		},
+		{"RSI > 1 AND Shape == 2", "((rsi > ? AND rsi <> 'NaN') AND shape = ?)", []interface{}{1.0, int64(2)}},
+		{"rsi not between 10 and 20", "(rsi NOT BETWEEN ? AND ? AND rsi <> 'NaN')", []interface{}{10.0, 20.0}},
+		{"cross_type not in (1,2)", "cross_type NOT IN ?", []interface{}{[]interface{}{int64(1), int64(2)}}},
+		{"symbol != btcusdt", "symbol <> ?", []interface{}{"BTCUSDT"}},
+		{`vp_signal = "high volume"`, "vp_signal = ?", []interface{}{"high volume"}},
+		// change 本身为百分数，taker_buy_ratio 为比例
+		{"change < -5%", "(change < ? AND change <> 'NaN')", []interface{}{-5.0}},
+		{"taker_buy_ratio >= 60%", "(taker_buy_ratio >= ? AND taker_buy_ratio <> 'NaN')", []interface{}{0.6}},
+		{"cross_time >= 2024-01-02", "cross_time >= ?", []interface{}{time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)}},
+		// 值中的 SQL 只会作为参数
+		{`ob = "x' or 1=1 --"`, "ob = ?", []interface{}{"x' or 1=1 --"}},
+	}
+	for _, tt := range tests {
+		f, err := Parse(tt.expr)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.expr, err)
+			continue
+		}
+		sql, args := f.SQL()
+		if sql != tt.sql {
+			t.Errorf("Parse(%q).SQL()\n got %s\nwant %s", tt.expr, sql, tt.sql)
+		}
+		if !reflect.DeepEqual(args, tt.args) {
+			t.Errorf("Parse(%q) args = %#v, want %#v", tt.expr, args, tt.args)
+		}
+	}
+}
+
+func TestParseEmpty(t *testing.T) {
+	for _, expr := range []string{"", "   ", "\t\n"} {
+		if f, err := Parse(expr); f != nil || err != nil {
+			t.Errorf("Parse(%q) = %v, %v, want nil", expr, f, err)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	inValues := make([]string, MaxInValues+1)
+	for i := range inValues {
+		inValues[i] = strconv.Itoa(i)
+	}
+	tests := []struct {
+		expr string
+		pos  int
+		msg  string
+	}{
+		// 字段白名单
+		{"foo > 1", 1, `unknown field "foo"`},
+		{"rsi > 1 or password = 'x'", 12, `unknown field "password"`},
+		{"and", 1, `unknown field "and"`},
+		{") ", 1, `expected field name, got ")"`},
+		{"rsi > 1; drop table symbol_records", 7, `invalid number "1;" for rsi`},
+		// 词法
+		{"rsi ! 3", 5, `unexpected "!", did you mean "!="?`},
+		{"symbol = 'btc", 10, "unterminated string"},
+		// 值
+		{"rsi >", 6, "expected value for rsi, got end of filter"},
+		{"rsi > 'abc'", 7, `invalid number "abc" for rsi`},
+		{"rsi > NaN", 7, `invalid number "NaN" for rsi`},
+		{"shape = 1.5", 9, `field shape expects an integer, got "1.5"`},
+		{"rsi > 5%", 7, "field rsi does not accept %"},
+		{"cross_time >= yesterday", 15, `invalid time "yesterday" for cross_time, use RFC 3339 or YYYY-MM-DD`},
+		// 运算符
+		{"rsi", 4, "expected operator after rsi, got end of filter"},
+		{"symbol > btc", 8, "field symbol only supports =, != and in"},
+		{"symbol between a and b", 8, "field symbol does not support between"},
+		{"rsi between 1 or 2", 15, `expected "and" in between, got "or"`},
+		{"rsi not > 1", 9, `expected "in" or "between" after "not", got ">"`},
+		{"cycle in 1h", 10, `expected "(" after in, got "1h"`},
+		{"cycle in (1h 4h)", 14, `expected "," or ")", got "4h"`},
+		// 结构
+		{"(rsi > 1", 9, `expected ")", got end of filter`},
+		{"rsi > 1 shape = 1", 9, `unexpected "shape", expected "and", "or" or end of filter`},
+		{"rsi > 1 and", 12, "expected field name, got end of filter"},
+		// 位置按字符计数
+		{"symbol = '币' and 价格 > 1", 18, `unknown field "价格"`},
+		// 限制
+		{strings.Repeat(" ", MaxLength-7) + "rsi > 1x", MaxLength + 1, "filter longer than 512 characters"},
+		{strings.Repeat("(", MaxDepth+1) + "rsi > 1" + strings.Repeat(")", MaxDepth+1), MaxDepth + 2, "filter nested deeper than 16 levels"},
+		{strings.Repeat("not ", MaxDepth+1) + "rsi > 1", 4*(MaxDepth+1) + 1, "filter nested deeper than 16 levels"},
+		{
+			"shape in (" + strings.Join(inValues, ",") + ")",
+			len("shape in (") + len(strings.Join(inValues[:MaxInValues], ",")) + 1,
+			"in list longer than 50 values",
+		},
+	}
+	for _, tt := range tests {
+		_, err := Parse(tt.expr)
+		se, ok := err.(*SyntaxError)
+		if !ok || se.Pos != tt.pos || se.Message != tt.msg {
+			t.Errorf("Parse(%.40q) error = %v, want position %d: %s", tt.expr, err, tt.pos, tt.msg)
+		}
+	}
+}
+
+func TestParseLimitsAccepted(t *testing.T) {
+	inValues := make([]string, MaxInValues)
+	for i := range inValues {
+		inValues[i] = strconv.Itoa(i)
+	}
+	for _, expr := range []string{
+		strings.Repeat(" ", MaxLength-7) + "rsi > 1",
+		strings.Repeat("(", MaxDepth) + "rsi > 1" + strings.Repeat(")", MaxDepth),
+		strings.Repeat("not ", MaxDepth) + "rsi > 1",
+		"shape in (" + strings.Join(inValues, ",") + ")",
+	} {
+		if _, err := Parse(expr); err != nil {
+			t.Errorf("Parse(%.40q): %v", expr, err)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package screener
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	publicModels "github.com/cryptoSelect/public/models"
+)
+
+// ParseFields 解析 fields 参数，如 "symbol,cycle,rsi"；返回的字段按注册表顺序排列
+func ParseFields(spec string) ([]Field, error) {
+	selected := make(map[string]bool)
+	pos := 1
+	for _, part := range strings.Split(spec, ",") {
+		partPos := pos + len([]rune(part)) - len([]rune(strings.TrimLeft(part, " ")))
+		pos += len([]rune(part)) + 1
+		name := strings.TrimSpace(part)
+		if name == "" {
+			return nil, &SyntaxError{partPos, "empty field name"}
+		}
+		field, ok := LookupField(name)
+		if !ok {
+			return nil, &SyntaxError{partPos, fmt.Sprintf("unknown field %q", name)}
+		}
+		selected[field.Name] = true
+	}
+	var fields []Field
+	for _, f := range Fields {
+		if selected[f.Name] {
+			fields = append(fields, f)
+		}
+	}
+	return fields, nil
+}
+
+// Row 按所选字段输出的一条记录，JSON 字段顺序同注册表
+type Row struct {
+	Fields []Field
+	Record *publicModels.SymbolRecord
+}
+
+func (r Row) MarshalJSON() ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for i, f := range r.Fields {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		v, err := json.Marshal(f.Value(r.Record))
+		if err != nil {
+			return nil, err
+		}
+		b.WriteString(`"` + f.Name + `":`)
+		b.Write(v)
+	}
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
@@ -0,0 +1,143 @@
+package screener
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// MaxSortKeys sort 参数最多的排序键个数
+const MaxSortKeys = 5
+
+// OrderKey 一个排序键；NaNFirst 为 true 时浮点字段的 NaN/NULL 排在最前，默认排在最后（与升降序无关）
+type OrderKey struct {
+	Field    Field
+	Desc     bool
+	NaNFirst bool
+}
+
+// ParseSort 解析 sort 参数，如 "-taker_buy_ratio,rsi"：逗号分隔，前缀 - 为降序、+ 或无前缀为升序
+func ParseSort(spec string, nanFirst bool) ([]OrderKey, error) {
+	var keys []OrderKey
+	seen := make(map[string]bool)
+	pos := 1
+	for _, part := range strings.Split(spec, ",") {
+		partPos := pos + len([]rune(part)) - len([]rune(strings.TrimLeft(part, " ")))
+		pos += len([]rune(part)) + 1
+		name := strings.TrimSpace(part)
+		if name == "" {
+			return nil, &SyntaxError{partPos, "empty sort key"}
+		}
+		key := OrderKey{NaNFirst: nanFirst}
+		switch name[0] {
+		case '-':
+			key.Desc = true
+			name = name[1:]
+		case '+':
+			name = name[1:]
+		}
+		field, ok := LookupField(name)
+		if !ok {
+			return nil, &SyntaxError{partPos, fmt.Sprintf("unknown field %q", name)}
+		}
+		if field.NoSort {
+			return nil, &SyntaxError{partPos, fmt.Sprintf("field %s is not sortable", field.Name)}
+		}
+		if seen[field.Name] {
+			return nil, &SyntaxError{partPos, fmt.Sprintf("field %s appears more than once", field.Name)}
+		}
+		if len(keys) == MaxSortKeys {
+			return nil, &SyntaxError{partPos, fmt.Sprintf("at most %d sort keys", MaxSortKeys)}
+		}
+		seen[field.Name] = true
+		key.Field = field
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// term 实际参与排序的一个表达式及其取值
+type term struct {
+	expr  string
+	desc  bool
+	value interface{}
+}
+
+// ordinalSQL 按 Ordinal 顺序排序的表达式，不在列表中的值排在最后
+func ordinalSQL(f Field) string {
+	var b strings.Builder
+	b.WriteString("CASE " + f.Column)
+	for i, v := range f.Ordinal {
+		fmt.Fprintf(&b, " WHEN '%s' THEN %d", v, i)
+	}
+	fmt.Fprintf(&b, " ELSE %d END", len(f.Ordinal))
+	return b.String()
+}
+
+func ordinalRank(f Field, v string) int {
+	for i, o := range f.Ordinal {
+		if o == v {
+			return i
+		}
+	}
+	return len(f.Ordinal)
+}
+
+// terms 将排序键展开为排序表达式：浮点字段先按是否 NaN/NULL 排序以控制其位置，再按 NULL 视为 NaN 的取值排序；Ordinal 字段按列表顺序；
+// values 为各键的取值（生成排序子句时为 nil）
+func terms(keys []OrderKey, values []interface{}) []term {
+	var ts []term
+	for i, k := range keys {
+		var v interface{}
+		if values != nil {
+			v = values[i]
+		}
+		f := k.Field
+		switch {
+		case f.Kind == Float:
+			var missing interface{}
+			if values != nil {
+				missing = math.IsNaN(v.(float64))
+			}
+			ts = append(ts, term{"(" + f.Column + " IS NULL OR " + f.Column + " = 'NaN')", k.NaNFirst, missing})
+			// NULL 与 NaN 视为同一取值，组内按 id 排序，游标才能越过二者混排的记录
+			ts = append(ts, term{"COALESCE(" + f.Column + ", 'NaN')", k.Desc, v})
+		case len(f.Ordinal) > 0:
+			if values != nil {
+				v = ordinalRank(f, v.(string))
+			}
+			ts = append(ts, term{ordinalSQL(f), k.Desc, v})
+		default:
+			ts = append(ts, term{f.Column, k.Desc, v})
+		}
+	}
+	return ts
+}
+
+// OrderSQL 排序子句，最后以 id 升序保证顺序稳定
+func OrderSQL(keys []OrderKey) string {
+	var parts []string
+	for _, t := range terms(keys, nil) {
+		dir := " ASC"
+		if t.desc {
+			dir = " DESC"
+		}
+		parts = append(parts, t.expr+dir)
+	}
+	return strings.Join(append(parts, "id ASC"), ", ")
+}
+
+// orderSignature 排序方式的文本表示，写入游标用于校验
+func orderSignature(keys []OrderKey) string {
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		parts[i] = k.Field.Name
+		if k.Desc {
+			parts[i] = "-" + parts[i]
+		}
+		if k.NaNFirst {
+			parts[i] += "!"
+		}
+	}
+	return strings.Join(parts, ",")
+}
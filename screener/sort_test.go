@@ -0,0 +1,87 @@
+package screener
+
+import (
+	"testing"
+)
+
+func TestParseSort(t *testing.T) {
+	tests := []struct {
+		spec     string
+		nanFirst bool
+		want     string // orderSignature
+	}{
+		{"rsi", false, "rsi"},
+		{"-taker_buy_ratio,rsi", false, "-taker_buy_ratio,rsi"},
+		{" +RSI , -change ", false, "rsi,-change"},
+		{"-price", true, "-price!"},
+		{"cycle,-updated_at", false, "cycle,-updated_at"},
+		{"rsi,price,volume,change,shape", false, "rsi,price,volume,change,shape"},
+	}
+	for _, tt := range tests {
+		keys, err := ParseSort(tt.spec, tt.nanFirst)
+		if err != nil {
+			t.Errorf("ParseSort(%q): %v", tt.spec, err)
+			continue
+		}
+		if got := orderSignature(keys); got != tt.want {
+			t.Errorf("ParseSort(%q) = %q, want %q", tt.spec, got, tt.want)
+		}
+	}
+}
+
+func TestParseSortErrors(t *testing.T) {
+	tests := []struct {
+		spec string
+		pos  int
+		msg  string
+	}{
+		{"", 1, "empty sort key"},
+		{"rsi,", 5, "empty sort key"},
+		{"rsi, ,price", 6, "empty sort key"}, // 空白之后的位置
+		{"-", 1, `unknown field ""`},
+		{"rsi, -foo", 6, `unknown field "foo"`},
+		{"description", 1, "field description is not sortable"},
+		{"rsi,-RSI", 5, "field rsi appears more than once"},
+		{"price, +price", 8, "field price appears more than once"},
+		{"rsi,price,volume,change,shape,rate", 31, "at most 5 sort keys"},
+	}
+	for _, tt := range tests {
+		_, err := ParseSort(tt.spec, false)
+		se, ok := err.(*SyntaxError)
+		if !ok || se.Pos != tt.pos || se.Message != tt.msg {
+			t.Errorf("ParseSort(%q) error = %v, want position %d: %s", tt.spec, err, tt.pos, tt.msg)
+		}
+	}
+}
+
+func TestOrderSQL(t *testing.T) {
+	const cycleCase = "CASE cycle WHEN '5m' THEN 0 WHEN '15m' THEN 1 WHEN '30m' THEN 2 WHEN '1h' THEN 3 WHEN '4h' THEN 4 WHEN '1d' THEN 5 WHEN '1w' THEN 6 WHEN '1M' THEN 7 ELSE 8 END"
+	tests := []struct {
+		spec     string
+		nanFirst bool
+		want     string
+	}{
+		// 没有排序键时仍以 id 保证顺序稳定
+		{"", false, "id ASC"},
+		// NaN/NULL 默认排在最后，与升降序无关
+		{"rsi", false, "(rsi IS NULL OR rsi = 'NaN') ASC, COALESCE(rsi, 'NaN') ASC, id ASC"},
+		{"-rsi", false, "(rsi IS NULL OR rsi = 'NaN') ASC, COALESCE(rsi, 'NaN') DESC, id ASC"},
+		{"-rsi", true, "(rsi IS NULL OR rsi = 'NaN') DESC, COALESCE(rsi, 'NaN') DESC, id ASC"},
+		{"rsi", true, "(rsi IS NULL OR rsi = 'NaN') DESC, COALESCE(rsi, 'NaN') ASC, id ASC"},
+		// 非浮点字段不加 NaN 排序项，id 作为显式键时仍追加隐式 id
+		{"-shape,id", false, "shape DESC, id ASC, id ASC"},
+		{"-cycle,symbol", false, cycleCase + " DESC, symbol ASC, id ASC"},
+	}
+	for _, tt := range tests {
+		var keys []OrderKey
+		if tt.spec != "" {
+			var err error
+			if keys, err = ParseSort(tt.spec, tt.nanFirst); err != nil {
+				t.Fatalf("ParseSort(%q): %v", tt.spec, err)
+			}
+		}
+		if got := OrderSQL(keys); got != tt.want {
+			t.Errorf("OrderSQL(%q, nanFirst=%v)\n got %s\nwant %s", tt.spec, tt.nanFirst, got, tt.want)
+		}
+	}
+}
@@ -1,31 +1,52 @@
-// Package tgBot 接收用户 /start 消息，解析绑定 token，调用后端 ConfirmTelegramBind
+// Package tgBot 接收 Telegram 更新（长轮询或 webhook），解析 /start <token> 并通过 Confirmer 确认绑定
 package tgBot
 
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/cryptoSelect/backendapi/api/auth"
 	"github.com/cryptoSelect/backendapi/config"
+	"github.com/cryptoSelect/backendapi/utils/i18n"
 	"github.com/cryptoSelect/backendapi/utils/logger"
+	"github.com/cryptoSelect/backendapi/utils/telegram"
+)
+
+// 更新接收模式
+const (
+	ModePolling = "polling" // 默认：getUpdates 长轮询，仅适合单实例
+	ModeWebhook = "webhook" // Telegram 推送到 TelegramWebhookURL，多实例共享
 )
 
 // Telegram Update 结构（简化）
 type tgUpdate struct {
-	UpdateID int `json:"update_id"`
-	Message  *struct {
-		MessageID int `json:"message_id"`
-		From      *struct {
-			ID        int64  `json:"id"`
-			Username  string `json:"username"`
-			FirstName string `json:"first_name"`
-		} `json:"from"`
-		Text string `json:"text"`
-	} `json:"message"`
+	UpdateID int        `json:"update_id"`
+	Message  *tgMessage `json:"message"`
+}
+
+type tgMessage struct {
+	MessageID int     `json:"message_id"`
+	From      *tgUser `json:"from"`
+	Chat      *tgChat `json:"chat"`
+	Text      string  `json:"text"`
+}
+
+type tgUser struct {
+	ID           int64  `json:"id"`
+	Username     string `json:"username"`
+	FirstName    string `json:"first_name"`
+	LanguageCode string `json:"language_code"`
+}
+
+type tgChat struct {
+	ID int64 `json:"id"`
 }
 
 type tgUpdatesResp struct {
@@ -33,33 +54,85 @@ type tgUpdatesResp struct {
 	Result []tgUpdate `json:"result"`
 }
 
+// Confirmer 确认绑定：同进程时直接使用 auth.ConfirmBind，独立部署时使用 HTTPConfirmer
+type Confirmer func(token string, acc auth.TelegramAccount) error
+
+// Bot 长轮询与 webhook 共用同一个更新处理逻辑
+type Bot struct {
+	confirm Confirmer
+	send    func(chatID, text string) error // 回复消息，默认 telegram.SendMessage
+
+	mu           sync.Mutex
+	lastUpdateID int       // webhook 模式下最近处理的 update_id，对应长轮询的 offset
+	lastUpdateAt time.Time // 最近处理更新的时间
+}
+
+func New(confirm Confirmer) *Bot {
+	return &Bot{confirm: confirm, send: telegram.SendMessage}
+}
+
 // deleteWebhook 删除 webhook，否则 getUpdates 收不到消息
 func deleteWebhook(token string) {
-	url := "https://api.telegram.org/bot" + token + "/deleteWebhook"
-	resp, err := http.Get(url)
+	resp, err := http.Get(telegram.MethodURL(token, "deleteWebhook"))
 	if err != nil {
 		return
 	}
 	resp.Body.Close()
 }
 
-// Run 启动 Bot 长轮询，收到 /start <token> 时调用后端确认绑定
-func Run(backendBase string) {
+// setWebhook 向 Telegram 注册 webhook 地址与 secret_token
+func setWebhook(token, url, secret string) error {
+	payload, _ := json.Marshal(map[string]interface{}{
+		"url":             url,
+		"secret_token":    secret,
+		"allowed_updates": []string{"message"},
+	})
+	resp, err := http.Post(telegram.MethodURL(token, "setWebhook"), "application/json", bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	var result struct {
+		OK          bool   `json:"ok"`
+		Description string `json:"description"`
+	}
+	if json.Unmarshal(body, &result) != nil || !result.OK {
+		return fmt.Errorf("setWebhook failed: status %d: %s", resp.StatusCode, string(body))
+	}
+	return nil
+}
+
+// Run 按 TelegramUpdateMode 启动：webhook 模式注册 webhook 后返回，否则进入长轮询
+func (b *Bot) Run() {
 	token := strings.TrimSpace(config.Cfg.TelegramBotToken)
 	if token == "" {
 		logger.Log.Info("tgBot skip: TelegramBotToken not configured", nil)
 		return
 	}
-	logger.Log.Info("tgBot starting", map[string]interface{}{"backend": backendBase})
+	if config.Cfg.TelegramUpdateMode == ModeWebhook {
+		url := strings.TrimSpace(config.Cfg.TelegramWebhookURL)
+		if url == "" || webhookSecret() == "" {
+			logger.Log.Error("tgBot webhook mode requires TelegramWebhookURL and TelegramWebhookSecret", nil)
+			return
+		}
+		if err := setWebhook(token, url, webhookSecret()); err != nil {
+			logger.Log.Error("tgBot setWebhook failed", map[string]interface{}{"error": err.Error()})
+			return
+		}
+		logger.Log.Info("tgBot webhook registered", map[string]interface{}{"url": url})
+		return
+	}
+	b.poll(token)
+}
+
+// poll getUpdates 长轮询
+func (b *Bot) poll(token string) {
+	logger.Log.Info("tgBot starting", map[string]interface{}{"mode": ModePolling})
 	// 删除 webhook，否则 getUpdates 无法接收消息
 	deleteWebhook(token)
 
-	base := strings.TrimRight(backendBase, "/")
-	if base == "" {
-		base = "http://localhost:8080"
-	}
-	apiURL := "https://api.telegram.org/bot" + token + "/getUpdates"
-	confirmURL := base + "/api/auth/tg/bind/confirm"
+	apiURL := telegram.MethodURL(token, "getUpdates")
 
 	var offset int
 	for {
@@ -80,53 +153,94 @@ func Run(backendBase string) {
 
 		for _, u := range data.Result {
 			offset = u.UpdateID + 1
-			if u.Message == nil || u.Message.From == nil {
-				continue
-			}
-			text := strings.TrimSpace(u.Message.Text)
-			if !strings.HasPrefix(strings.ToLower(text), "/start") {
-				continue
-			}
-			// /start 或 /start <bindToken>
-			parts := strings.Fields(text)
-			bindToken := ""
-			if len(parts) >= 2 {
-				bindToken = strings.TrimSpace(parts[1])
-			}
-			if bindToken == "" {
-				continue
-			}
-			telegramID := fmt.Sprintf("%d", u.Message.From.ID)
-			tokenPreview := bindToken
-			if len(tokenPreview) > 8 {
-				tokenPreview = tokenPreview[:8] + "..."
-			}
-			logger.Log.Info("tgBot received /start", map[string]interface{}{"telegram_id": telegramID, "token": tokenPreview})
-			confirmBind(confirmURL, bindToken, telegramID)
+			b.handleUpdate(u)
 		}
 
 		time.Sleep(100 * time.Millisecond)
 	}
 }
 
-func confirmBind(confirmURL, token, telegramID string) {
-	payload, _ := json.Marshal(map[string]string{"token": token, "telegram_id": telegramID})
-	req, err := http.NewRequest("POST", confirmURL, bytes.NewReader(payload))
-	if err != nil {
-		logger.Log.Error("tgBot confirmBind newRequest failed", map[string]interface{}{"error": err.Error()})
+// handleUpdate 处理单条更新：解析命令并回复到来源 chat
+func (b *Bot) handleUpdate(u tgUpdate) {
+	if u.Message == nil || u.Message.From == nil {
 		return
 	}
-	req.Header.Set("Content-Type", "application/json")
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		logger.Log.Error("tgBot confirmBind request failed", map[string]interface{}{"url": confirmURL, "error": err.Error()})
+	name, args := parseCommand(u.Message.Text)
+	if name == "" {
 		return
 	}
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		logger.Log.Error("tgBot confirmBind response non-200", map[string]interface{}{"status": resp.StatusCode, "body": string(body)})
+	chatID := fmt.Sprintf("%d", u.Message.From.ID)
+	if u.Message.Chat != nil {
+		chatID = fmt.Sprintf("%d", u.Message.Chat.ID)
+	}
+	reply := b.dispatch(name, args, u.Message)
+	if reply == "" {
 		return
 	}
+	if err := b.send(chatID, reply); err != nil {
+		logger.Log.Error("tgBot reply failed", map[string]interface{}{"chat_id": chatID, "command": name, "error": err.Error()})
+	}
+}
+
+// handleStart /start <bindToken>：确认绑定，成功消息由 Confirmer 发送
+func (b *Bot) handleStart(lang string, args []string, msg *tgMessage) string {
+	if len(args) == 0 {
+		return i18n.T(lang, "bot.help")
+	}
+	bindToken := strings.TrimSpace(args[0])
+	telegramID := fmt.Sprintf("%d", msg.From.ID)
+	acc := auth.TelegramAccount{ID: telegramID, Username: msg.From.Username, FirstName: msg.From.FirstName}
+	tokenPreview := bindToken
+	if len(tokenPreview) > 8 {
+		tokenPreview = tokenPreview[:8] + "..."
+	}
+	logger.Log.Info("tgBot received /start", map[string]interface{}{"telegram_id": telegramID, "token": tokenPreview})
+	if err := b.confirm(bindToken, acc); err != nil {
+		logger.Log.Error("tgBot confirmBind failed", map[string]interface{}{"telegram_id": telegramID, "error": err.Error()})
+		if errors.Is(err, auth.ErrTelegramAlreadyBound) {
+			return i18n.T(lang, "bot.already_bound")
+		}
+		return i18n.T(lang, "bot.bind_invalid")
+	}
 	logger.Log.Info("tgBot confirmBind success", map[string]interface{}{"telegram_id": telegramID})
+	return ""
+}
+
+// HTTPConfirmer 通过签名请求回调后端 /api/auth/tg/bind/confirm，用于 Bot 与后端分开部署
+func HTTPConfirmer(backendBase, secret string) Confirmer {
+	base := strings.TrimRight(backendBase, "/")
+	if base == "" {
+		base = "http://localhost:8080"
+	}
+	confirmURL := base + "/api/auth/tg/bind/confirm"
+	return func(token string, acc auth.TelegramAccount) error {
+		payload, _ := json.Marshal(auth.TgBindConfirmReq{Token: token, TelegramID: acc.ID, Username: acc.Username, FirstName: acc.FirstName})
+		headers, err := auth.SignBindCallback(secret, payload, time.Now())
+		if err != nil {
+			return err
+		}
+		req, err := http.NewRequest("POST", confirmURL, bytes.NewReader(payload))
+		if err != nil {
+			return err
+		}
+		req.Header = headers
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		var result struct {
+			Error string `json:"error"`
+			Code  int    `json:"code"`
+		}
+		if json.Unmarshal(body, &result) == nil && result.Error == "telegram_already_bound" {
+			return auth.ErrTelegramAlreadyBound
+		}
+		if resp.StatusCode != http.StatusOK || result.Code != 200 {
+			return fmt.Errorf("confirm callback status %d: %s", resp.StatusCode, string(body))
+		}
+		return nil
+	}
 }
@@ -0,0 +1,217 @@
+package tgBot
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/cryptoSelect/backendapi/api/auth"
+	"github.com/cryptoSelect/backendapi/api/subscription"
+	"github.com/cryptoSelect/backendapi/api/symbol"
+	"github.com/cryptoSelect/backendapi/preference"
+	"github.com/cryptoSelect/backendapi/utils/i18n"
+	"github.com/cryptoSelect/backendapi/utils/logger"
+)
+
+// commandHandler 已绑定用户的命令处理函数，lang 为用户偏好语言，返回回复文本
+type commandHandler func(userID uint, lang string, args []string) string
+
+// resolveUser 按 telegram_id 查找已绑定用户，userLanguage 读取用户偏好语言；测试时可替换
+var (
+	resolveUser  = auth.GetUserIDByTelegramID
+	userLanguage = preference.Language
+)
+
+var commands = map[string]commandHandler{
+	"/subs":   cmdSubs,
+	"/sub":    cmdSub,
+	"/unsub":  cmdUnsub,
+	"/unbind": cmdUnbind,
+}
+
+// parseCommand 解析 "/cmd@BotName arg1 arg2"，非命令消息返回空名称
+func parseCommand(text string) (string, []string) {
+	parts := strings.Fields(strings.TrimSpace(text))
+	if len(parts) == 0 || !strings.HasPrefix(parts[0], "/") {
+		return "", nil
+	}
+	name := strings.ToLower(parts[0])
+	if i := strings.Index(name, "@"); i >= 0 {
+		name = name[:i]
+	}
+	return name, parts[1:]
+}
+
+// telegramLanguage 未绑定用户按 Telegram 客户端语言选择回复语言
+func telegramLanguage(code string) string {
+	switch {
+	case code == "":
+		return i18n.Default
+	case strings.HasPrefix(strings.ToLower(code), "zh"):
+		return i18n.ZH
+	default:
+		return i18n.EN
+	}
+}
+
+// dispatch 路由命令：/start、/help、/price 无需绑定，其余命令按 telegram_id 解析用户；已绑定用户按偏好语言回复
+func (b *Bot) dispatch(name string, args []string, msg *tgMessage) string {
+	userID, err := resolveUser(fmt.Sprintf("%d", msg.From.ID))
+	lang := telegramLanguage(msg.From.LanguageCode)
+	if err == nil {
+		lang = userLanguage(userID)
+	}
+	switch name {
+	case "/start":
+		return b.handleStart(lang, args, msg)
+	case "/help":
+		return i18n.T(lang, "bot.help")
+	case "/price":
+		return cmdPrice(lang, args)
+	}
+	handler, ok := commands[name]
+	if !ok {
+		return i18n.T(lang, "bot.unknown_command")
+	}
+	if errors.Is(err, auth.ErrUserNotFound) {
+		return i18n.T(lang, "bot.unbound")
+	}
+	if err != nil {
+		logger.Log.Error("tgBot resolve user failed", map[string]interface{}{"telegram_id": msg.From.ID, "error": err.Error()})
+		return i18n.T(lang, "bot.unavailable")
+	}
+	if auth.Disabled(userID) {
+		return i18n.T(lang, "bot.disabled")
+	}
+	return handler(userID, lang, args)
+}
+
+func cmdSubs(userID uint, lang string, _ []string) string {
+	items, err := subscription.ListByUserID(userID)
+	if err != nil {
+		logger.Log.Error("tgBot list subscriptions failed", map[string]interface{}{"user_id": userID, "error": err.Error()})
+		return i18n.T(lang, "bot.subs_failed")
+	}
+	if len(items) == 0 {
+		return i18n.T(lang, "bot.subs_empty")
+	}
+	var b strings.Builder
+	b.WriteString(i18n.T(lang, "bot.subs_title"))
+	for _, it := range items {
+		fmt.Fprintf(&b, "\n%s %s", it.Symbol, it.Cycle)
+		if len(it.Rules) > 0 {
+			rules := make([]string, 0, len(it.Rules))
+			for _, r := range it.Rules {
+				rules = append(rules, r.Rule)
+			}
+			b.WriteString(i18n.T(lang, "bot.subs_rules", strings.Join(rules, "; ")))
+		}
+	}
+	return b.String()
+}
+
+// cmdSub 未指定周期时使用用户偏好中的默认周期
+func cmdSub(userID uint, lang string, args []string) string {
+	if !auth.AccountVerified(userID) {
+		return i18n.T(lang, "bot.verify_first")
+	}
+	sym, cycles, errText := parseSymbolCycles(lang, args, "/sub BTCUSDT 1h 4h", preference.Get(userID).DefaultCycles)
+	if errText != "" {
+		return errText
+	}
+	for _, cycle := range cycles {
+		if _, err := subscription.CreateSubscription(userID, sym, cycle); err != nil {
+			logger.Log.Error("tgBot create subscription failed", map[string]interface{}{"user_id": userID, "symbol": sym, "cycle": cycle, "error": err.Error()})
+			return i18n.T(lang, "bot.sub_failed")
+		}
+	}
+	return i18n.T(lang, "bot.sub_ok", sym, strings.Join(cycles, " "))
+}
+
+func cmdUnsub(userID uint, lang string, args []string) string {
+	sym, cycles, errText := parseSymbolCycles(lang, args, "/unsub BTCUSDT 1h", nil)
+	if errText != "" {
+		return errText
+	}
+	for _, cycle := range cycles {
+		if err := subscription.DeleteByUserIDSymbolCycle(userID, sym, cycle); err != nil {
+			logger.Log.Error("tgBot delete subscription failed", map[string]interface{}{"user_id": userID, "symbol": sym, "cycle": cycle, "error": err.Error()})
+			return i18n.T(lang, "bot.unsub_failed")
+		}
+	}
+	return i18n.T(lang, "bot.unsub_ok", sym, strings.Join(cycles, " "))
+}
+
+func cmdUnbind(userID uint, lang string, _ []string) string {
+	if _, err := auth.UnbindTelegram(userID); errors.Is(err, auth.ErrTelegramOnlyAccount) {
+		return i18n.T(lang, "bot.unbind_telegram_only")
+	} else if err != nil {
+		logger.Log.Error("tgBot unbind failed", map[string]interface{}{"user_id": userID, "error": err.Error()})
+		return i18n.T(lang, "bot.unbind_failed")
+	}
+	logger.Log.Info("tgBot unbind", map[string]interface{}{"user_id": userID})
+	return i18n.T(lang, "bind.unbound")
+}
+
+func cmdPrice(lang string, args []string) string {
+	if len(args) == 0 {
+		return i18n.T(lang, "bot.usage", "/price BTCUSDT")
+	}
+	sym := normalizeSymbol(args[0])
+	records, err := symbol.LatestBySymbol(sym)
+	if err != nil {
+		logger.Log.Error("tgBot query price failed", map[string]interface{}{"symbol": sym, "error": err.Error()})
+		return i18n.T(lang, "bot.price_failed")
+	}
+	if len(records) == 0 {
+		return i18n.T(lang, "bot.price_not_found", sym)
+	}
+	var b strings.Builder
+	b.WriteString(sym)
+	for _, r := range records {
+		b.WriteString(i18n.T(lang, "bot.price_line", r.Cycle, num(r.Price), num(r.Rsi), num(r.Change), num(r.TakerBuyRatio)))
+	}
+	return b.String()
+}
+
+// parseSymbolCycles 解析 "<symbol> <cycle>..."，未给周期时使用 defaults；返回错误提示文本（为空表示成功）
+func parseSymbolCycles(lang string, args []string, usage string, defaults []string) (string, []string, string) {
+	if len(args) == 0 || (len(args) < 2 && len(defaults) == 0) {
+		return "", nil, i18n.T(lang, "bot.usage", usage)
+	}
+	sym := normalizeSymbol(args[0])
+	input := args[1:]
+	if len(input) == 0 {
+		input = defaults
+	}
+	seen := make(map[string]bool)
+	var cycles []string
+	for _, cy := range input {
+		if !preference.ValidCycle(cy) {
+			return "", nil, i18n.T(lang, "bot.bad_cycle", cy, strings.Join(preference.Cycles, " "))
+		}
+		if !seen[cy] {
+			seen[cy] = true
+			cycles = append(cycles, cy)
+		}
+	}
+	return sym, cycles, ""
+}
+
+// normalizeSymbol 转大写，未带 USDT 后缀时补上
+func normalizeSymbol(s string) string {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	if !strings.HasSuffix(s, "USDT") {
+		s += "USDT"
+	}
+	return s
+}
+
+func num(v float64) string {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return "-"
+	}
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
@@ -0,0 +1,155 @@
+package tgBot
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/cryptoSelect/backendapi/api/auth"
+	"github.com/cryptoSelect/backendapi/config"
+	"github.com/cryptoSelect/backendapi/preference"
+	"github.com/cryptoSelect/backendapi/utils/i18n"
+	"github.com/cryptoSelect/backendapi/utils/logger"
+)
+
+func TestParseCommand(t *testing.T) {
+	tests := []struct {
+		text string
+		name string
+		args []string
+	}{
+		{"/help", "/help", []string{}},
+		{"  /sub BTC 1h 4h ", "/sub", []string{"BTC", "1h", "4h"}},
+		{"/Sub@CryptoSelectBot btc", "/sub", []string{"btc"}},
+		{"/start@CryptoSelectBot bind-token", "/start", []string{"bind-token"}},
+		{"/price\tETH", "/price", []string{"ETH"}},
+		{"hello /help", "", nil},
+		{"", "", nil},
+		{"   ", "", nil},
+	}
+	for _, tt := range tests {
+		name, args := parseCommand(tt.text)
+		if name != tt.name || !reflect.DeepEqual(args, tt.args) {
+			t.Errorf("parseCommand(%q) = %q %q, want %q %q", tt.text, name, args, tt.name, tt.args)
+		}
+	}
+}
+
+func TestParseSymbolCycles(t *testing.T) {
+	const usage = "/sub BTCUSDT 1h 4h"
+	tests := []struct {
+		name     string
+		args     []string
+		defaults []string
+		sym      string
+		cycles   []string
+		errText  string
+	}{
+		{"explicit cycles", []string{"btc", "1h", "4h"}, nil, "BTCUSDT", []string{"1h", "4h"}, ""},
+		{"defaults", []string{"eth"}, []string{"15m", "1d"}, "ETHUSDT", []string{"15m", "1d"}, ""},
+		{"explicit overrides defaults", []string{"eth", "1w"}, []string{"15m"}, "ETHUSDT", []string{"1w"}, ""},
+		{"dedup", []string{"BTCUSDT", "1h", "1h", "4h", "1h"}, nil, "BTCUSDT", []string{"1h", "4h"}, ""},
+		{"bad cycle", []string{"btc", "1h", "2h"}, nil, "", nil, i18n.T(i18n.EN, "bot.bad_cycle", "2h", strings.Join(preference.Cycles, " "))},
+		{"cycle is case sensitive", []string{"btc", "1H"}, nil, "", nil, i18n.T(i18n.EN, "bot.bad_cycle", "1H", strings.Join(preference.Cycles, " "))},
+		{"no args", nil, []string{"1h"}, "", nil, i18n.T(i18n.EN, "bot.usage", usage)},
+		{"no cycle and no defaults", []string{"btc"}, nil, "", nil, i18n.T(i18n.EN, "bot.usage", usage)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sym, cycles, errText := parseSymbolCycles(i18n.EN, tt.args, usage, tt.defaults)
+			if sym != tt.sym || !reflect.DeepEqual(cycles, tt.cycles) || errText != tt.errText {
+				t.Fatalf("got %q %q %q, want %q %q %q", sym, cycles, errText, tt.sym, tt.cycles, tt.errText)
+			}
+		})
+	}
+}
+
+func TestNormalizeSymbol(t *testing.T) {
+	tests := map[string]string{
+		"btc":      "BTCUSDT",
+		" eth ":    "ETHUSDT",
+		"BTCUSDT":  "BTCUSDT",
+		"solusdt":  "SOLUSDT",
+		"1000pepe": "1000PEPEUSDT",
+	}
+	for in, want := range tests {
+		if got := normalizeSymbol(in); got != want {
+			t.Errorf("normalizeSymbol(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+// fakeBotAPI 记录 sendMessage 请求的假 Telegram Bot API
+type fakeBotAPI struct {
+	mu   sync.Mutex
+	sent []map[string]string
+}
+
+func (f *fakeBotAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/bottest-token/sendMessage" {
+		http.NotFound(w, r)
+		return
+	}
+	var body map[string]string
+	json.NewDecoder(r.Body).Decode(&body)
+	f.mu.Lock()
+	f.sent = append(f.sent, body)
+	f.mu.Unlock()
+	w.Write([]byte(`{"ok":true}`))
+}
+
+func TestHandleUpdateRepliesThroughBotAPI(t *testing.T) {
+	logger.Init("test")
+	api := &fakeBotAPI{}
+	srv := httptest.NewServer(api)
+	defer srv.Close()
+
+	oldCfg, oldResolve, oldLang := config.Cfg, resolveUser, userLanguage
+	config.Cfg = &config.ServerConfig{TelegramBotToken: "test-token", TelegramAPIBase: srv.URL}
+	// 用户 7 已绑定 telegram_id 100，偏好中文；其余未绑定
+	resolveUser = func(telegramID string) (uint, error) {
+		if telegramID == "100" {
+			return 7, nil
+		}
+		return 0, auth.ErrUserNotFound
+	}
+	userLanguage = func(uint) string { return i18n.ZH }
+	defer func() { config.Cfg, resolveUser, userLanguage = oldCfg, oldResolve, oldLang }()
+
+	tests := []struct {
+		from   int64
+		lang   string
+		text   string
+		chatID string
+		reply  string
+	}{
+		{200, "en", "/help@CryptoSelectBot", "200", i18n.T(i18n.EN, "bot.help")},
+		{200, "zh-hans", "/subs", "200", i18n.T(i18n.ZH, "bot.unbound")},
+		{200, "en", "/price", "200", i18n.T(i18n.EN, "bot.usage", "/price BTCUSDT")},
+		{100, "en", "/whatever", "100", i18n.T(i18n.ZH, "bot.unknown_command")},
+		{200, "en", "not a command", "", ""},
+	}
+	b := New(nil)
+	for i, tt := range tests {
+		b.handleUpdate(tgUpdate{UpdateID: i + 1, Message: &tgMessage{
+			MessageID: i + 1,
+			From:      &tgUser{ID: tt.from, LanguageCode: tt.lang},
+			Chat:      &tgChat{ID: tt.from},
+			Text:      tt.text,
+		}})
+	}
+
+	var want []map[string]string
+	for _, tt := range tests {
+		if tt.reply != "" {
+			want = append(want, map[string]string{"chat_id": tt.chatID, "text": tt.reply})
+		}
+	}
+	if !reflect.DeepEqual(api.sent, want) {
+		t.Fatalf("Bot API received %v, want %v", api.sent, want)
+	}
+}
@@ -0,0 +1,10 @@
+package tgBot
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// SetupBotRoutes 注册 Telegram webhook 接收地址（TelegramUpdateMode 为 webhook 时由 Telegram 调用）
+func SetupBotRoutes(router *gin.RouterGroup, bot *Bot) {
+	router.POST("/webhook", bot.HandleWebhook)
+}
@@ -0,0 +1,64 @@
+package tgBot
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/cryptoSelect/backendapi/config"
+	"github.com/cryptoSelect/backendapi/utils/logger"
+	"github.com/gin-gonic/gin"
+)
+
+// HeaderSecretToken Telegram 推送 webhook 时携带 setWebhook 注册的 secret_token
+const HeaderSecretToken = "X-Telegram-Bot-Api-Secret-Token"
+
+func webhookSecret() string {
+	return strings.TrimSpace(config.Cfg.TelegramWebhookSecret)
+}
+
+// updateIDReset 超过该时长未收到更新时不按 update_id 去重：Telegram 在一周无更新后会随机选择新的 update_id
+const updateIDReset = 24 * time.Hour
+
+// seen 更新是否已处理过；Telegram 未收到 2xx 时会重发同一 update_id，按递增的 update_id 去重
+func (b *Bot) seen(updateID int, now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if updateID <= b.lastUpdateID && now.Sub(b.lastUpdateAt) < updateIDReset {
+		return true
+	}
+	b.lastUpdateID, b.lastUpdateAt = updateID, now
+	return false
+}
+
+// HandleWebhook 接收 Telegram 推送的更新，校验 secret_token 后交给与长轮询相同的处理逻辑；重发的更新直接确认
+func (b *Bot) HandleWebhook(c *gin.Context) {
+	secret := webhookSecret()
+	got := c.GetHeader(HeaderSecretToken)
+	if secret == "" || subtle.ConstantTimeCompare([]byte(got), []byte(secret)) != 1 {
+		logger.Log.Warn("tgBot webhook rejected", map[string]interface{}{"ip": c.ClientIP()})
+		c.JSON(http.StatusUnauthorized, gin.H{"ok": false})
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, 1<<20))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"ok": false})
+		return
+	}
+	var u tgUpdate
+	if err := json.Unmarshal(body, &u); err != nil {
+		logger.Log.Error("tgBot webhook invalid update", map[string]interface{}{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"ok": false})
+		return
+	}
+	if b.seen(u.UpdateID, time.Now()) {
+		logger.Log.Debug("tgBot webhook duplicate update", map[string]interface{}{"update_id": u.UpdateID})
+		c.JSON(http.StatusOK, gin.H{"ok": true})
+		return
+	}
+	b.handleUpdate(u)
+	c.JSON(http.StatusOK, gin.H{"ok": true})
+}
@@ -0,0 +1,176 @@
+package tgBot
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cryptoSelect/backendapi/api/auth"
+	"github.com/cryptoSelect/backendapi/config"
+	"github.com/cryptoSelect/backendapi/utils/logger"
+	"github.com/gin-gonic/gin"
+)
+
+// startUpdate Telegram 推送的 /start 更新（录制自 setWebhook 后的真实请求）
+const startUpdate = `{
+  "update_id": 815203377,
+  "message": {
+    "message_id": 42,
+    "from": {"id": 123456789, "is_bot": false, "first_name": "Alice", "username": "alice", "language_code": "en"},
+    "chat": {"id": 123456789, "first_name": "Alice", "username": "alice", "type": "private"},
+    "date": 1760000000,
+    "text": "/start bind-token-abc"
+  }
+}`
+
+// webhookBot 记录 Confirmer 调用与回复的 Bot，未绑定任何用户
+type webhookBot struct {
+	*Bot
+	confirmed []string
+	replies   []string
+}
+
+func newWebhookBot(t *testing.T, confirmErr error) (*webhookBot, *gin.Engine) {
+	t.Helper()
+	logger.Init("test")
+	gin.SetMode(gin.TestMode)
+	oldCfg, oldResolve := config.Cfg, resolveUser
+	config.Cfg = &config.ServerConfig{TelegramWebhookSecret: "tg-secret"}
+	resolveUser = func(string) (uint, error) { return 0, auth.ErrUserNotFound }
+	t.Cleanup(func() { config.Cfg, resolveUser = oldCfg, oldResolve })
+
+	wb := &webhookBot{}
+	wb.Bot = New(func(token string, acc auth.TelegramAccount) error {
+		wb.confirmed = append(wb.confirmed, token+"|"+acc.ID+"|"+acc.Username)
+		return confirmErr
+	})
+	wb.send = func(chatID, text string) error {
+		wb.replies = append(wb.replies, chatID+"|"+text)
+		return nil
+	}
+	r := gin.New()
+	SetupBotRoutes(r.Group("/api/tg"), wb.Bot)
+	return wb, r
+}
+
+func postUpdate(r *gin.Engine, secret, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("POST", "/api/tg/webhook", strings.NewReader(body))
+	if secret != "" {
+		req.Header.Set(HeaderSecretToken, secret)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestWebhookConfirmsBind(t *testing.T) {
+	wb, r := newWebhookBot(t, nil)
+
+	if w := postUpdate(r, "tg-secret", startUpdate); w.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", w.Code, w.Body.String())
+	}
+	if len(wb.confirmed) != 1 || wb.confirmed[0] != "bind-token-abc|123456789|alice" {
+		t.Fatalf("confirmed %v", wb.confirmed)
+	}
+	if len(wb.replies) != 0 {
+		t.Fatalf("successful bind should not reply from the handler: %v", wb.replies)
+	}
+}
+
+func TestWebhookRepliesOnInvalidBind(t *testing.T) {
+	wb, r := newWebhookBot(t, errors.New("token expired"))
+
+	if w := postUpdate(r, "tg-secret", startUpdate); w.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", w.Code, w.Body.String())
+	}
+	if len(wb.replies) != 1 || !strings.HasPrefix(wb.replies[0], "123456789|") {
+		t.Fatalf("replies %v", wb.replies)
+	}
+}
+
+func TestWebhookRejectsBadSecret(t *testing.T) {
+	tests := []struct {
+		name   string
+		secret string
+	}{
+		{"missing", ""},
+		{"wrong", "not-the-secret"},
+		{"prefix", "tg-secre"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wb, r := newWebhookBot(t, nil)
+			if w := postUpdate(r, tt.secret, startUpdate); w.Code != http.StatusUnauthorized {
+				t.Fatalf("status %d, want 401", w.Code)
+			}
+			if len(wb.confirmed) != 0 {
+				t.Fatal("rejected update was handled")
+			}
+		})
+	}
+}
+
+func TestWebhookRejectsWhenSecretUnset(t *testing.T) {
+	wb, r := newWebhookBot(t, nil)
+	config.Cfg.TelegramWebhookSecret = ""
+
+	if w := postUpdate(r, "", startUpdate); w.Code != http.StatusUnauthorized {
+		t.Fatalf("status %d, want 401", w.Code)
+	}
+	if len(wb.confirmed) != 0 {
+		t.Fatal("update handled without a configured secret")
+	}
+}
+
+func TestWebhookRejectsInvalidJSON(t *testing.T) {
+	wb, r := newWebhookBot(t, nil)
+
+	if w := postUpdate(r, "tg-secret", `{"update_id":`); w.Code != http.StatusBadRequest {
+		t.Fatalf("status %d, want 400", w.Code)
+	}
+	if len(wb.confirmed) != 0 {
+		t.Fatal("invalid update was handled")
+	}
+}
+
+func TestWebhookSkipsRedeliveredUpdate(t *testing.T) {
+	wb, r := newWebhookBot(t, nil)
+
+	for i := 0; i < 2; i++ {
+		if w := postUpdate(r, "tg-secret", startUpdate); w.Code != http.StatusOK {
+			t.Fatalf("delivery #%d: status %d", i+1, w.Code)
+		}
+	}
+	// 更早的 update_id 同样视为已处理
+	older := strings.Replace(startUpdate, "815203377", "815203376", 1)
+	if w := postUpdate(r, "tg-secret", older); w.Code != http.StatusOK {
+		t.Fatalf("older update: status %d", w.Code)
+	}
+	if len(wb.confirmed) != 1 {
+		t.Fatalf("confirmed %d times, want 1", len(wb.confirmed))
+	}
+
+	newer := strings.Replace(startUpdate, "815203377", "815203378", 1)
+	postUpdate(r, "tg-secret", newer)
+	if len(wb.confirmed) != 2 {
+		t.Fatalf("newer update not handled, confirmed %d times", len(wb.confirmed))
+	}
+}
+
+func TestSeenResetsAfterIdle(t *testing.T) {
+	b := New(nil)
+	now := time.Now()
+	if b.seen(100, now) {
+		t.Fatal("first update reported as seen")
+	}
+	if !b.seen(100, now.Add(time.Minute)) {
+		t.Fatal("redelivered update not detected")
+	}
+	// 长时间无更新后 Telegram 可能从更小的 update_id 重新开始
+	if b.seen(5, now.Add(8*24*time.Hour)) {
+		t.Fatal("update after idle period treated as duplicate")
+	}
+}
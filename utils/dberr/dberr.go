@@ -0,0 +1,13 @@
+// Package dberr 数据库错误判断，不依赖具体驱动
+package dberr
+
+import "errors"
+
+// uniqueViolation Postgres 唯一约束冲突的 SQLSTATE
+const uniqueViolation = "23505"
+
+// IsUniqueViolation 是否为唯一约束冲突（pgx 的 *pgconn.PgError 实现了 SQLState）
+func IsUniqueViolation(err error) bool {
+	var pgErr interface{ SQLState() string }
+	return errors.As(err, &pgErr) && pgErr.SQLState() == uniqueViolation
+}
@@ -0,0 +1,162 @@
+package i18n
+
+// catalog key -> 语言 -> 文案
+var catalog = map[string]map[string]string{
+	// Telegram 绑定
+	"bind.success":  {ZH: "绑定成功", EN: "Your account is now linked."},
+	"bind.replaced": {ZH: "你的账号已绑定到新的 Telegram，此聊天将不再接收通知。", EN: "Your account was linked to another Telegram account. This chat will no longer receive notifications."},
+	"bind.unbound":  {ZH: "已解除绑定，此聊天将不再接收通知。", EN: "Unlinked. This chat will no longer receive notifications."},
+	"bind.goodbye":  {ZH: "你的账号已注销，此聊天将不再接收通知。感谢使用，再见！", EN: "Your account has been deleted. This chat will no longer receive notifications. Thanks for using us, goodbye!"},
+	"bind.login_code": {
+		ZH: "登录验证码：%s（5 分钟内有效）。如果这不是你的操作，请立即修改密码。",
+		EN: "Your login code: %s (valid for 5 minutes). If this wasn't you, change your password now.",
+	},
+
+	// Bot 命令
+	"bot.help": {
+		ZH: "可用命令：\n/subs - 查看我的订阅\n/sub BTCUSDT 1h 4h - 订阅币种的一个或多个周期\n/unsub BTCUSDT 1h - 取消订阅\n/price BTCUSDT - 查看各周期最新数据\n/unbind - 解除账号绑定\n/help - 显示本帮助",
+		EN: "Commands:\n/subs - list my subscriptions\n/sub BTCUSDT 1h 4h - subscribe to one or more cycles\n/unsub BTCUSDT 1h - unsubscribe\n/price BTCUSDT - latest data for each cycle\n/unbind - unlink this Telegram account\n/help - show this help",
+	},
+	"bot.unbound":              {ZH: "你还没有绑定账号，请先在网页端登录并点击「绑定 Telegram」。", EN: "This Telegram account is not linked yet. Sign in on the website and click \"Link Telegram\" first."},
+	"bot.unknown_command":      {ZH: "未知命令，发送 /help 查看可用命令。", EN: "Unknown command. Send /help to see available commands."},
+	"bot.unavailable":          {ZH: "服务暂时不可用，请稍后再试。", EN: "Service temporarily unavailable, please try again later."},
+	"bot.disabled":             {ZH: "你的账号已被停用。", EN: "Your account has been disabled."},
+	"bot.already_bound":        {ZH: "该 Telegram 已绑定其他账号，请先在原账号解绑（或发送 /unbind）后再试。", EN: "This Telegram account is linked to another user. Unlink it there (or send /unbind) and try again."},
+	"bot.bind_invalid":         {ZH: "绑定链接无效或已过期，请在网页端重新获取。", EN: "The link is invalid or expired. Please get a new one on the website."},
+	"bot.subs_failed":          {ZH: "查询订阅失败，请稍后再试。", EN: "Failed to load subscriptions, please try again later."},
+	"bot.subs_empty":           {ZH: "你还没有订阅，发送 /sub BTCUSDT 1h 订阅。", EN: "No subscriptions yet. Send /sub BTCUSDT 1h to subscribe."},
+	"bot.subs_title":           {ZH: "我的订阅：", EN: "My subscriptions:"},
+	"bot.subs_rules":           {ZH: "（规则：%s）", EN: " (rules: %s)"},
+	"bot.verify_first":         {ZH: "请先在网页端完成邮箱验证后再订阅。", EN: "Please verify your email on the website before subscribing."},
+	"bot.sub_failed":           {ZH: "订阅失败，请稍后再试。", EN: "Failed to subscribe, please try again later."},
+	"bot.sub_ok":               {ZH: "已订阅 %s %s", EN: "Subscribed to %s %s"},
+	"bot.unsub_failed":         {ZH: "取消订阅失败，请稍后再试。", EN: "Failed to unsubscribe, please try again later."},
+	"bot.unsub_ok":             {ZH: "已取消订阅 %s %s", EN: "Unsubscribed from %s %s"},
+	"bot.unbind_telegram_only": {ZH: "该账号通过 Telegram 登录创建，请先在网页端设置邮箱和密码后再解除绑定。", EN: "This account was created with Telegram login. Set an email and password on the website before unlinking."},
+	"bot.unbind_failed":        {ZH: "解除绑定失败，请稍后再试。", EN: "Failed to unlink, please try again later."},
+	"bot.usage":                {ZH: "用法：%s", EN: "Usage: %s"},
+	"bot.price_failed":         {ZH: "查询失败，请稍后再试。", EN: "Query failed, please try again later."},
+	"bot.price_not_found":      {ZH: "未找到 %s 的数据。", EN: "No data found for %s."},
+	"bot.price_line":           {ZH: "\n%s 价格 %s | RSI %s | 涨跌 %s%% | 主买 %s", EN: "\n%s price %s | RSI %s | change %s%% | taker buy %s"},
+	"bot.bad_cycle":            {ZH: "不支持的周期：%s，可选 %s", EN: "Unsupported cycle: %s. Choose from %s"},
+
+	// 账号安全通知
+	"account.password_changed": {
+		ZH: "你的账号密码已修改，其他设备已退出登录。如果这不是你的操作，请立即通过「忘记密码」重置密码。",
+		EN: "Your password was changed and other devices were signed out. If this wasn't you, reset your password with \"Forgot password\" now.",
+	},
+	"account.password_reset": {
+		ZH: "你的账号密码已通过邮件重置，所有设备已退出登录。如果这不是你的操作，请立即检查邮箱安全并再次重置密码。",
+		EN: "Your password was reset by email and all devices were signed out. If this wasn't you, secure your email account and reset your password again now.",
+	},
+	"account.email_change_requested": {
+		ZH: "你的账号正在将登录邮箱修改为 %s，确认邮件已发送到新邮箱。如果这不是你的操作，请立即修改密码。",
+		EN: "A request was made to change your sign-in email to %s; a confirmation link was sent there. If this wasn't you, change your password now.",
+	},
+	"account.email_changed": {
+		ZH: "你的账号登录邮箱已修改为 %s。如果这不是你的操作，请立即联系我们。",
+		EN: "Your sign-in email was changed to %s. If this wasn't you, contact us immediately.",
+	},
+
+	// 邮件，subject 为主题，body 为正文
+	"mail.verify_email.subject": {ZH: "验证你的邮箱", EN: "Verify your email"},
+	"mail.verify_email.body": {
+		ZH: "请点击以下链接验证你的邮箱（24 小时内有效）：\n%s\n\n如果这不是你的操作，请忽略本邮件。",
+		EN: "Click the link below to verify your email (valid for 24 hours):\n%s\n\nIf this wasn't you, ignore this email.",
+	},
+	"mail.reset_password.subject": {ZH: "重置密码", EN: "Reset your password"},
+	"mail.reset_password.body": {
+		ZH: "请点击以下链接重置密码（30 分钟内有效，仅可使用一次）：\n%s\n\n如果这不是你的操作，请忽略本邮件，你的密码不会改变。",
+		EN: "Click the link below to reset your password (valid for 30 minutes, single use):\n%s\n\nIf this wasn't you, ignore this email; your password will not change.",
+	},
+	"mail.change_email.subject": {ZH: "确认修改邮箱", EN: "Confirm your new email"},
+	"mail.change_email.body": {
+		ZH: "你正在将账号登录邮箱修改为此邮箱，请点击以下链接确认（24 小时内有效）：\n%s\n\n如果这不是你的操作，请忽略本邮件。",
+		EN: "You asked to change your sign-in email to this address. Click the link below to confirm (valid for 24 hours):\n%s\n\nIf this wasn't you, ignore this email.",
+	},
+	"mail.email_changed.subject": {ZH: "登录邮箱已修改", EN: "Your sign-in email was changed"},
+	"mail.email_changed.body": {
+		ZH: "你的账号登录邮箱已修改为 %s。\n\n如果这不是你的操作，请立即联系我们。",
+		EN: "Your sign-in email was changed to %s.\n\nIf this wasn't you, contact us immediately.",
+	},
+
+	// 告警推送
+	"alert.price_line":     {ZH: "价格: %s | RSI: %s | 涨跌幅: %s%%", EN: "Price: %s | RSI: %s | Change: %s%%"},
+	"alert.time":           {ZH: "时间: %s", EN: "Time: %s"},
+	"alert.cross":          {ZH: "MACD %s", EN: "MACD %s"},
+	"alert.shape":          {ZH: "分型: %s", EN: "Fractal: %s"},
+	"alert.vp_signal":      {ZH: "量价信号: %s", EN: "Volume-price signal: %s"},
+	"alert.smc_signal":     {ZH: "SMC 信号: %s", EN: "SMC signal: %s"},
+	"alert.rsi_overbought": {ZH: "RSI 进入超买: %s → %s", EN: "RSI entered overbought: %s → %s"},
+	"alert.rsi_oversold":   {ZH: "RSI 进入超卖: %s → %s", EN: "RSI entered oversold: %s → %s"},
+	"alert.rule":           {ZH: "规则触发: %s", EN: "Rule triggered: %s"},
+	"alert.cross.1":        {ZH: "金叉(0轴上)", EN: "golden cross (above zero)"},
+	"alert.cross.2":        {ZH: "金叉(0轴下)", EN: "golden cross (below zero)"},
+	"alert.cross.3":        {ZH: "死叉(0轴上)", EN: "death cross (above zero)"},
+	"alert.cross.4":        {ZH: "死叉(0轴下)", EN: "death cross (below zero)"},
+	"alert.shape.1":        {ZH: "顶分型", EN: "top fractal"},
+	"alert.shape.2":        {ZH: "底分型", EN: "bottom fractal"},
+
+	// 接口错误码，见 response 包
+	"error.invalid_request":            {ZH: "请求参数有误", EN: "The request is invalid."},
+	"error.invalid_id":                 {ZH: "ID 无效", EN: "The ID is invalid."},
+	"error.invalid_filter":             {ZH: "筛选表达式有误", EN: "The filter expression is invalid."},
+	"error.invalid_fields":             {ZH: "字段列表有误", EN: "The fields list is invalid."},
+	"error.invalid_sort":               {ZH: "排序参数有误", EN: "The sort parameter is invalid."},
+	"error.invalid_page_size":          {ZH: "每页条数超出范围", EN: "The page size is out of range."},
+	"error.invalid_cursor":             {ZH: "分页游标无效", EN: "The pagination cursor is invalid."},
+	"error.invalid_cycle":              {ZH: "不支持的周期", EN: "Unsupported cycle."},
+	"error.invalid_cycles":             {ZH: "至少需要两个周期", EN: "At least two cycles are required."},
+	"error.invalid_field":              {ZH: "字段不存在", EN: "Unknown field."},
+	"error.invalid_min_match":          {ZH: "min_match 超出范围", EN: "min_match is out of range."},
+	"error.invalid_rule":               {ZH: "规则表达式有误", EN: "The rule expression is invalid."},
+	"error.too_many_rules":             {ZH: "该订阅的规则数量已达上限", EN: "This subscription has too many rules."},
+	"error.invalid_scope":              {ZH: "API key 权限无效", EN: "Invalid API key scope."},
+	"error.too_many_api_keys":          {ZH: "API key 数量已达上限", EN: "Too many API keys."},
+	"error.invalid_webhook_url":        {ZH: "请填写有效的 http(s) 地址，且不能指向内网地址", EN: "A valid public http(s) URL is required."},
+	"error.too_many_webhooks":          {ZH: "Webhook 数量已达上限", EN: "Too many webhooks."},
+	"error.invalid_preference":         {ZH: "偏好设置有误", EN: "Invalid preference."},
+	"error.invalid_or_expired_token":   {ZH: "链接无效或已过期", EN: "The link is invalid or has expired."},
+	"error.email_not_set":              {ZH: "账号尚未设置邮箱", EN: "No email is set for this account."},
+	"error.email_already_verified":     {ZH: "邮箱已验证", EN: "The email is already verified."},
+	"error.email_unchanged":            {ZH: "新邮箱与当前邮箱相同", EN: "The new email is the same as the current one."},
+	"error.email_reserved":             {ZH: "该邮箱域名为系统保留，不能使用", EN: "This email domain is reserved and cannot be used."},
+	"error.credentials_not_set":        {ZH: "账号尚未设置邮箱和密码", EN: "No email and password are set for this account."},
+	"error.credentials_already_set":    {ZH: "账号已设置邮箱和密码", EN: "Email and password are already set."},
+	"error.confirm_required":           {ZH: "请输入 DELETE 确认注销", EN: "Type DELETE to confirm."},
+	"error.telegram_not_bound":         {ZH: "尚未绑定 Telegram", EN: "No Telegram account is linked."},
+	"error.telegram_only_account":      {ZH: "该账号通过 Telegram 登录创建，请先设置邮箱和密码", EN: "This account was created with Telegram login. Set an email and password first."},
+	"error.two_factor_already_enabled": {ZH: "两步验证已开启", EN: "Two-factor authentication is already enabled."},
+	"error.two_factor_not_enabled":     {ZH: "两步验证未开启", EN: "Two-factor authentication is not enabled."},
+	"error.two_factor_not_setup":       {ZH: "请先生成两步验证密钥", EN: "Set up two-factor authentication first."},
+	"error.cannot_modify_self":         {ZH: "不能对自己执行该操作", EN: "You cannot do this to your own account."},
+	"error.unauthorized":               {ZH: "请先登录", EN: "Please sign in."},
+	"error.session_revoked":            {ZH: "登录已失效，请重新登录", EN: "Your session has ended. Please sign in again."},
+	"error.email_or_password_wrong":    {ZH: "邮箱或密码错误", EN: "Incorrect email or password."},
+	"error.password_wrong":             {ZH: "密码错误", EN: "Incorrect password."},
+	"error.invalid_refresh_token":      {ZH: "登录已过期，请重新登录", EN: "Your sign-in has expired. Please sign in again."},
+	"error.invalid_challenge":          {ZH: "验证已过期，请重新登录", EN: "The verification has expired. Please sign in again."},
+	"error.invalid_code":               {ZH: "验证码错误", EN: "Incorrect verification code."},
+	"error.invalid_api_key":            {ZH: "API key 无效", EN: "Invalid API key."},
+	"error.invalid_signature":          {ZH: "签名无效", EN: "Invalid signature."},
+	"error.invalid_telegram_login":     {ZH: "Telegram 登录校验失败", EN: "Telegram login verification failed."},
+	"error.forbidden":                  {ZH: "没有权限", EN: "Permission denied."},
+	"error.account_disabled":           {ZH: "账号已停用", EN: "This account has been disabled."},
+	"error.email_not_verified":         {ZH: "请先验证邮箱", EN: "Please verify your email first."},
+	"error.insufficient_scope":         {ZH: "API key 权限不足", EN: "The API key does not have the required scope."},
+	"error.user_not_found":             {ZH: "用户不存在", EN: "User not found."},
+	"error.subscription_not_found":     {ZH: "订阅不存在", EN: "Subscription not found."},
+	"error.rule_not_found":             {ZH: "规则不存在", EN: "Rule not found."},
+	"error.session_not_found":          {ZH: "会话不存在", EN: "Session not found."},
+	"error.api_key_not_found":          {ZH: "API key 不存在", EN: "API key not found."},
+	"error.webhook_not_found":          {ZH: "Webhook 不存在", EN: "Webhook not found."},
+	"error.bind_token_not_found":       {ZH: "绑定链接无效或已过期", EN: "The link is invalid or has expired."},
+	"error.email_already_registered":   {ZH: "该邮箱已注册", EN: "This email is already registered."},
+	"error.telegram_already_bound":     {ZH: "该 Telegram 已绑定其他账号", EN: "This Telegram account is linked to another user."},
+	"error.bind_token_used":            {ZH: "绑定链接已使用", EN: "The link has already been used."},
+	"error.duplicate_rule":             {ZH: "该订阅已有相同的规则", EN: "The subscription already has this rule."},
+	"error.too_many_requests":          {ZH: "请求过于频繁，请稍后再试", EN: "Too many requests. Please try again later."},
+	"error.account_locked":             {ZH: "登录失败次数过多，请稍后再试", EN: "Too many failed sign-in attempts. Please try again later."},
+	"error.server_error":               {ZH: "服务暂时不可用，请稍后再试", EN: "Service temporarily unavailable, please try again later."},
+	"error.send_mail_failed":           {ZH: "邮件发送失败，请稍后再试", EN: "Failed to send the email, please try again later."},
+}
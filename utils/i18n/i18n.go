@@ -0,0 +1,35 @@
+// Package i18n 面向用户的文案（Bot 回复、告警推送、绑定通知、接口错误信息）的多语言目录
+package i18n
+
+import "fmt"
+
+const (
+	ZH = "zh"
+	EN = "en"
+	// Default 默认语言
+	Default = ZH
+)
+
+// Languages 支持的语言
+var Languages = []string{ZH, EN}
+
+// Supported 是否为支持的语言
+func Supported(lang string) bool {
+	return lang == ZH || lang == EN
+}
+
+// T 返回 key 对应语言的文案，args 按 fmt.Sprintf 格式化；缺少该语言时回退到默认语言，缺少 key 时返回 key
+func T(lang, key string, args ...interface{}) string {
+	texts, ok := catalog[key]
+	if !ok {
+		return key
+	}
+	text, ok := texts[lang]
+	if !ok {
+		text = texts[Default]
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(text, args...)
+	}
+	return text
+}
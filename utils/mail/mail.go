@@ -0,0 +1,112 @@
+// Package mail 邮件发送：SMTP 用于生产，log 驱动把邮件写入日志或文件便于本地调试
+package mail
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/smtp"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cryptoSelect/backendapi/config"
+	"github.com/cryptoSelect/backendapi/utils/logger"
+)
+
+// Sender 邮件发送接口
+type Sender interface {
+	Send(to, subject, body string) error
+}
+
+var sender Sender = LogSender{}
+
+// ErrLinkBaseRequired 使用 smtp 真实发信时未配置 LinkBase，邮件中的验证、重置链接将无法打开
+var ErrLinkBaseRequired = errors.New("Mail.LinkBase is required when Mail.Driver is smtp")
+
+// Init 按 config.Mail.Driver 选择发送方式：smtp 或 log（默认）；smtp 时必须配置 LinkBase
+func Init(cfg config.MailConfig) error {
+	switch cfg.Driver {
+	case "smtp":
+		if strings.TrimSpace(cfg.LinkBase) == "" {
+			return ErrLinkBaseRequired
+		}
+		sender = SMTPSender{Host: cfg.SMTPHost, Port: cfg.SMTPPort, Username: cfg.Username, Password: cfg.Password, From: cfg.From}
+	default:
+		sender = LogSender{Path: cfg.LogFile}
+	}
+	return nil
+}
+
+// SetSender 替换发送实现
+func SetSender(s Sender) {
+	sender = s
+}
+
+// Send 使用当前发送实现发送纯文本邮件
+func Send(to, subject, body string) error {
+	return sender.Send(to, subject, body)
+}
+
+// SMTPSender 通过 SMTP（PLAIN 认证，服务端支持时自动 STARTTLS）发送
+type SMTPSender struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+}
+
+func (s SMTPSender) Send(to, subject, body string) error {
+	if s.Host == "" || s.From == "" {
+		return errors.New("smtp host or from not configured")
+	}
+	port := s.Port
+	if port == 0 {
+		port = 587
+	}
+	var auth smtp.Auth
+	if s.Username != "" {
+		auth = smtp.PlainAuth("", s.Username, s.Password, s.Host)
+	}
+	addr := s.Host + ":" + strconv.Itoa(port)
+	return smtp.SendMail(addr, auth, s.From, []string{to}, buildMessage(s.From, to, subject, body))
+}
+
+// LogSender 不真正发信：Path 为空时写入日志，否则追加到文件
+type LogSender struct {
+	Path string
+}
+
+var logFileMu sync.Mutex
+
+func (s LogSender) Send(to, subject, body string) error {
+	if s.Path == "" {
+		logger.Log.Info("mail (log driver)", map[string]interface{}{"to": to, "subject": subject, "body": body})
+		return nil
+	}
+	logFileMu.Lock()
+	defer logFileMu.Unlock()
+	f, err := os.OpenFile(s.Path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = fmt.Fprintf(f, "----- %s -----\nTo: %s\nSubject: %s\n\n%s\n\n", time.Now().Format(time.RFC3339), to, subject, body)
+	return err
+}
+
+func buildMessage(from, to, subject, body string) []byte {
+	var b strings.Builder
+	b.WriteString("From: " + from + "\r\n")
+	b.WriteString("To: " + to + "\r\n")
+	b.WriteString("Subject: =?UTF-8?B?" + base64.StdEncoding.EncodeToString([]byte(subject)) + "?=\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+	return []byte(b.String())
+}
@@ -0,0 +1,28 @@
+package mail
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/cryptoSelect/backendapi/config"
+)
+
+func TestInitRequiresLinkBaseForSMTP(t *testing.T) {
+	defer SetSender(LogSender{})
+
+	tests := []struct {
+		cfg  config.MailConfig
+		want error
+	}{
+		{config.MailConfig{Driver: "smtp", SMTPHost: "smtp.example.com", From: "no-reply@example.com"}, ErrLinkBaseRequired},
+		{config.MailConfig{Driver: "smtp", SMTPHost: "smtp.example.com", From: "no-reply@example.com", LinkBase: "  "}, ErrLinkBaseRequired},
+		{config.MailConfig{Driver: "smtp", SMTPHost: "smtp.example.com", From: "no-reply@example.com", LinkBase: "https://app.example.com"}, nil},
+		{config.MailConfig{}, nil},
+		{config.MailConfig{Driver: "log"}, nil},
+	}
+	for _, tt := range tests {
+		if err := Init(tt.cfg); !errors.Is(err, tt.want) {
+			t.Errorf("Init(%+v) = %v, want %v", tt.cfg, err, tt.want)
+		}
+	}
+}
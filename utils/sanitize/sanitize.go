@@ -0,0 +1,12 @@
+// Package sanitize 接口输出前的数值清理
+package sanitize
+
+import "math"
+
+// Float NaN/Inf 无法编码为 JSON，统一置为 0
+func Float(v float64) float64 {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return 0
+	}
+	return v
+}
@@ -0,0 +1,75 @@
+// Package telegram 封装 Bot API 调用（sendMessage / getMe 等），供 auth、tgBot 与告警推送共用
+package telegram
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/cryptoSelect/backendapi/config"
+)
+
+const defaultAPIBase = "https://api.telegram.org"
+
+var (
+	ErrNotConfigured = errors.New("telegram bot token not configured")
+	ErrEmptyChatID   = errors.New("telegram chat id empty")
+
+	httpClient = &http.Client{Timeout: 15 * time.Second}
+)
+
+// APIBase 返回 Bot API 地址：优先 config.TelegramAPIBase（本地测试可指向假服务），默认 https://api.telegram.org
+func APIBase() string {
+	if config.Cfg != nil {
+		if base := strings.TrimRight(strings.TrimSpace(config.Cfg.TelegramAPIBase), "/"); base != "" {
+			return base
+		}
+	}
+	return defaultAPIBase
+}
+
+// BotToken 返回配置中的 Bot token
+func BotToken() string {
+	if config.Cfg == nil {
+		return ""
+	}
+	return strings.TrimSpace(config.Cfg.TelegramBotToken)
+}
+
+// MethodURL 拼接 Bot API 方法地址，如 <base>/bot<token>/sendMessage
+func MethodURL(token, method string) string {
+	return APIBase() + "/bot" + token + "/" + method
+}
+
+// SendMessage 通过 Bot 向 chatID（即 telegram_id）发送文本消息
+func SendMessage(chatID, text string) error {
+	token := BotToken()
+	if token == "" {
+		return ErrNotConfigured
+	}
+	chatID = strings.TrimSpace(chatID)
+	if chatID == "" {
+		return ErrEmptyChatID
+	}
+	body, _ := json.Marshal(map[string]string{"chat_id": chatID, "text": text})
+	req, err := http.NewRequest("POST", MethodURL(token, "sendMessage"), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	respBody, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("telegram sendMessage status %d: %s", resp.StatusCode, string(respBody))
+	}
+	return nil
+}
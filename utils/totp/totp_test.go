@@ -0,0 +1,63 @@
+package totp
+
+import (
+	"encoding/base32"
+	"testing"
+	"time"
+)
+
+// RFC 6238 附录 B 的 SHA1 测试向量，密钥为 ASCII "12345678901234567890"；
+// 原向量为 8 位，本包输出 6 位，即取后 6 位
+func TestCodeAtRFC6238(t *testing.T) {
+	secret := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+	for _, tt := range tests {
+		got, err := CodeAt(secret, Step(time.Unix(tt.unix, 0)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.code {
+			t.Errorf("CodeAt at %d = %s, want %s", tt.unix, got, tt.code)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	secret := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+	at := time.Unix(1111111111, 0)
+	step := Step(at)
+	// 忽略密钥与验证码的首尾空白
+	if got, ok := Validate(" "+secret+" ", " 050471 ", at, 1); !ok || got != step {
+		t.Fatalf("Validate = %d %v, want step %d", got, ok, step)
+	}
+	// 允许前后一个步长
+	prev, _ := CodeAt(secret, step-1)
+	next, _ := CodeAt(secret, step+1)
+	if got, ok := Validate(secret, prev, at, 1); !ok || got != step-1 {
+		t.Errorf("previous step: %d %v", got, ok)
+	}
+	if got, ok := Validate(secret, next, at, 1); !ok || got != step+1 {
+		t.Errorf("next step: %d %v", got, ok)
+	}
+	if _, ok := Validate(secret, prev, at, 0); ok {
+		t.Error("previous step accepted without skew")
+	}
+	for _, code := range []string{"", "05047", "0504710", "123456"} {
+		if _, ok := Validate(secret, code, at, 1); ok {
+			t.Errorf("code %q accepted", code)
+		}
+	}
+	if _, ok := Validate("not base32!", "050471", at, 1); ok {
+		t.Error("invalid secret accepted")
+	}
+}
@@ -0,0 +1,118 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+var (
+	// ErrInvalidURL 不是 http(s) 地址或缺少主机
+	ErrInvalidURL = errors.New("webhook url must be an absolute http(s) url")
+	// ErrPrivateAddress 地址解析到回环、内网、链路本地等地址，防止借 Webhook 访问内部服务（SSRF）
+	ErrPrivateAddress = errors.New("webhook url resolves to a private or reserved address")
+)
+
+// allowPrivateNetworks 为 true 时不拦截内网地址，仅供测试向本机的 httptest 服务投递
+var allowPrivateNetworks = false
+
+// resolveTimeout 保存 Webhook 时解析域名的超时
+const resolveTimeout = 5 * time.Second
+
+// reservedNets net.IP 方法未覆盖的保留网段
+var reservedNets = func() []*net.IPNet {
+	var nets []*net.IPNet
+	for _, cidr := range []string{
+		"0.0.0.0/8",     // 本网络
+		"100.64.0.0/10", // 运营商级 NAT，部分云厂商的元数据服务在此网段
+		"192.0.0.0/24",  // IETF 协议分配
+		"198.18.0.0/15", // 基准测试
+		"240.0.0.0/4",   // 保留
+		"64:ff9b::/96",  // NAT64，可映射到内网 IPv4
+	} {
+		_, n, _ := net.ParseCIDR(cidr)
+		nets = append(nets, n)
+	}
+	return nets
+}()
+
+// blockedIP 是否为不允许投递的地址：回环、内网、链路本地（含 169.254.169.254 元数据地址）、未指定、组播与保留网段
+func blockedIP(ip net.IP) bool {
+	if allowPrivateNetworks {
+		return false
+	}
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() {
+		return true
+	}
+	for _, n := range reservedNets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateURL 校验 Webhook 地址：需为 http(s)，主机解析出的全部地址都不能是内网或保留地址。
+// 保存时校验一次，投递时在建立连接前再按实际连接的 IP 校验，防止 DNS 重绑定
+func ValidateURL(raw string) error {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return ErrInvalidURL
+	}
+	host := u.Hostname()
+	if ip := net.ParseIP(host); ip != nil {
+		if blockedIP(ip) {
+			return ErrPrivateAddress
+		}
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), resolveTimeout)
+	defer cancel()
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil || len(addrs) == 0 {
+		return ErrInvalidURL
+	}
+	for _, addr := range addrs {
+		if blockedIP(addr.IP) {
+			return ErrPrivateAddress
+		}
+	}
+	return nil
+}
+
+// dialControl 在建立连接前检查实际连接的 IP，DNS 解析结果在保存后变为内网地址时同样拦截
+func dialControl(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || blockedIP(ip) {
+		return ErrPrivateAddress
+	}
+	return nil
+}
+
+// newClient 投递用的 HTTP 客户端：连接时校验目标地址，不走环境变量代理，不跟随重定向（3xx 视为失败）
+func newClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{Timeout: timeout, Control: dialControl}
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			Proxy:                 nil,
+			DialContext:           dialer.DialContext,
+			TLSHandshakeTimeout:   timeout,
+			ResponseHeaderTimeout: timeout,
+			DisableKeepAlives:     true,
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
@@ -0,0 +1,147 @@
+package webhook
+
+import (
+	"errors"
+	"time"
+
+	"github.com/cryptoSelect/backendapi/models"
+	"github.com/cryptoSelect/public/database"
+	publicModels "github.com/cryptoSelect/public/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// MaxPerUser 每个用户最多可配置的 Webhook 数
+const MaxPerUser = 5
+
+var (
+	ErrNotFound = errors.New("webhook not found")
+	ErrTooMany  = errors.New("too many webhooks")
+)
+
+// ListByUser 获取用户的全部 Webhook
+func ListByUser(userID uint) ([]models.Webhook, error) {
+	var hooks []models.Webhook
+	err := database.DB.Where("user_id = ?", userID).Order("id").Find(&hooks).Error
+	return hooks, err
+}
+
+// EnabledByUser 获取用户已启用的 Webhook
+func EnabledByUser(userID uint) ([]models.Webhook, error) {
+	var hooks []models.Webhook
+	err := database.DB.Where("user_id = ? AND enabled = ?", userID, true).Order("id").Find(&hooks).Error
+	return hooks, err
+}
+
+// Get 获取用户的某个 Webhook
+func Get(userID, id uint) (*models.Webhook, error) {
+	var hook models.Webhook
+	err := database.DB.Where("id = ? AND user_id = ?", id, userID).First(&hook).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &hook, nil
+}
+
+// Create 创建 Webhook，超过 MaxPerUser 时返回 ErrTooMany；事务内锁住用户行，并发创建时逐个计数
+func Create(hook *models.Webhook) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		var user publicModels.UserInfo
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").Where("id = ?", hook.UserID).First(&user).Error; err != nil {
+			return err
+		}
+		var count int64
+		if err := tx.Model(&models.Webhook{}).Where("user_id = ?", hook.UserID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count >= MaxPerUser {
+			return ErrTooMany
+		}
+		return tx.Create(hook).Error
+	})
+}
+
+// Save 保存 Webhook 的修改
+func Save(hook *models.Webhook) error {
+	return database.DB.Save(hook).Error
+}
+
+// Delete 删除用户的某个 Webhook 及其投递日志
+func Delete(userID, id uint) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		res := tx.Where("id = ? AND user_id = ?", id, userID).Delete(&models.Webhook{})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrNotFound
+		}
+		return tx.Where("webhook_id = ?", id).Delete(&models.WebhookDelivery{}).Error
+	})
+}
+
+// Deliveries 获取 Webhook 最近的投递日志
+func Deliveries(webhookID uint, limit int) ([]models.WebhookDelivery, error) {
+	var rows []models.WebhookDelivery
+	err := database.DB.Where("webhook_id = ?", webhookID).Order("id DESC").Limit(limit).Find(&rows).Error
+	return rows, err
+}
+
+// deliveryStore 投递日志与重试队列的读写，测试时替换为内存实现
+type deliveryStore interface {
+	// handled 该事件是否已投递过该 Webhook：成功、排队或正在重试，以及重试用尽的最终失败都算，
+	// 告警重复检测到同一事件时不会开始新一轮尝试
+	handled(webhookID uint, eventKey string) (bool, error)
+	create(row *models.WebhookDelivery) error
+	// due 下次重试时间不晚于 now 的投递
+	due(now time.Time, limit int) ([]models.WebhookDelivery, error)
+	// claim 认领一条待重试的投递，多实例时只有一个实例返回 true
+	claim(id uint) (bool, error)
+	hook(id uint) (*models.Webhook, error)
+}
+
+var store deliveryStore = dbStore{}
+
+// dbStore 基于 webhook_delivery 表
+type dbStore struct{}
+
+func (dbStore) handled(webhookID uint, eventKey string) (bool, error) {
+	var count int64
+	err := database.DB.Model(&models.WebhookDelivery{}).
+		Where("webhook_id = ? AND event_key = ?", webhookID, eventKey).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (dbStore) create(row *models.WebhookDelivery) error {
+	return database.DB.Create(row).Error
+}
+
+func (dbStore) due(now time.Time, limit int) ([]models.WebhookDelivery, error) {
+	var rows []models.WebhookDelivery
+	err := database.DB.Where("next_attempt_at IS NOT NULL AND next_attempt_at <= ?", now).
+		Order("next_attempt_at").Limit(limit).Find(&rows).Error
+	return rows, err
+}
+
+func (dbStore) claim(id uint) (bool, error) {
+	res := database.DB.Model(&models.WebhookDelivery{}).
+		Where("id = ? AND next_attempt_at IS NOT NULL", id).
+		Updates(map[string]interface{}{"next_attempt_at": nil, "payload": ""})
+	return res.RowsAffected == 1, res.Error
+}
+
+func (dbStore) hook(id uint) (*models.Webhook, error) {
+	var hook models.Webhook
+	err := database.DB.Where("id = ?", id).First(&hook).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &hook, nil
+}
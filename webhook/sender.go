@@ -0,0 +1,202 @@
+// Package webhook 用户外发 Webhook：HMAC 签名、排队重试与投递日志
+package webhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/cryptoSelect/backendapi/config"
+	"github.com/cryptoSelect/backendapi/models"
+	"github.com/cryptoSelect/backendapi/utils/logger"
+)
+
+// 请求头
+const (
+	HeaderSignature = "X-CryptoSelect-Signature" // sha256=<hex(HMAC-SHA256(secret, timestamp + "." + body))>
+	HeaderTimestamp = "X-CryptoSelect-Timestamp" // Unix 秒
+	HeaderEvent     = "X-CryptoSelect-Event"     // 事件类型
+	HeaderDelivery  = "X-CryptoSelect-Delivery"  // 事件唯一键
+)
+
+// Envelope 推送给 Webhook 的请求体
+type Envelope struct {
+	ID        string      `json:"id"`
+	Type      string      `json:"type"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
+
+// ErrRetryScheduled 本次尝试失败，已排队等待 RunRetries 重试
+var ErrRetryScheduled = errors.New("webhook delivery failed, retry scheduled")
+
+// retryBatch 每轮最多重试的投递数
+const retryBatch = 100
+
+// Options 投递参数
+type Options struct {
+	MaxAttempts int
+	Backoff     time.Duration // 首次重试等待，之后每次翻倍
+	Timeout     time.Duration
+}
+
+// DefaultOptions 读取 config.Webhook，未配置时为 3 次尝试、1 秒起步退避、10 秒超时
+func DefaultOptions() Options {
+	opts := Options{MaxAttempts: 3, Backoff: time.Second, Timeout: 10 * time.Second}
+	if config.Cfg != nil {
+		cfg := config.Cfg.Webhook
+		if cfg.MaxAttempts > 0 {
+			opts.MaxAttempts = cfg.MaxAttempts
+		}
+		if cfg.BackoffSeconds > 0 {
+			opts.Backoff = time.Duration(cfg.BackoffSeconds) * time.Second
+		}
+		if cfg.TimeoutSeconds > 0 {
+			opts.Timeout = time.Duration(cfg.TimeoutSeconds) * time.Second
+		}
+	}
+	return opts
+}
+
+// Sign 计算签名头的值
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// NewSecret 生成随机签名密钥
+func NewSecret() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
+
+// Deliver 向 Webhook 投递事件（只尝试一次，不阻塞调用方）并写入投递日志；失败且未达 MaxAttempts 时
+// 排队由 RunRetries 按指数退避重试，返回 ErrRetryScheduled。
+// 同一 Webhook 已投递过该事件（含排队重试与重试用尽的失败）时直接返回 nil
+func Deliver(hook *models.Webhook, eventKey, eventType string, data interface{}, opts Options) (*models.WebhookDelivery, error) {
+	handled, err := store.handled(hook.ID, eventKey)
+	if err != nil {
+		return nil, err
+	}
+	if handled {
+		return nil, nil
+	}
+	body, err := json.Marshal(Envelope{ID: eventKey, Type: eventType, CreatedAt: time.Now().UTC(), Data: data})
+	if err != nil {
+		return nil, err
+	}
+	return attempt(hook, eventKey, eventType, body, 1, opts)
+}
+
+// attempt 发送第 n 次尝试并写入投递日志，失败且可重试时记录下次重试时间（Backoff * 2^(n-1) 之后）
+func attempt(hook *models.Webhook, eventKey, eventType string, body []byte, n int, opts Options) (*models.WebhookDelivery, error) {
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = 1
+	}
+	row := post(newClient(opts.Timeout), hook, eventKey, eventType, body, n)
+	if !row.Success && n < opts.MaxAttempts {
+		next := time.Now().Add(opts.Backoff << (n - 1))
+		row.NextAttemptAt = &next
+		row.Payload = string(body)
+	}
+	if err := store.create(row); err != nil {
+		logger.Log.Error("webhook log delivery failed", map[string]interface{}{"webhook_id": hook.ID, "error": err.Error()})
+	}
+	switch {
+	case row.Success:
+		return row, nil
+	case row.NextAttemptAt != nil:
+		return row, ErrRetryScheduled
+	}
+	logger.Log.Error("webhook delivery failed", map[string]interface{}{"webhook_id": hook.ID, "event": eventKey, "status": row.StatusCode, "error": row.Error})
+	return row, fmt.Errorf("webhook %d delivery failed after %d attempts: %s", hook.ID, n, row.Error)
+}
+
+// RunRetries 按 interval 周期重试到期的失败投递
+func RunRetries(interval time.Duration) {
+	for {
+		time.Sleep(interval)
+		if n := retryDue(time.Now(), DefaultOptions()); n > 0 {
+			logger.Log.Debug("webhook retries", map[string]interface{}{"retried": n})
+		}
+	}
+}
+
+// retryDue 重试下次重试时间已到的投递，返回实际重试的条数；Webhook 已删除或停用时放弃重试
+func retryDue(now time.Time, opts Options) int {
+	rows, err := store.due(now, retryBatch)
+	if err != nil {
+		logger.Log.Error("webhook load due retries failed", map[string]interface{}{"error": err.Error()})
+		return 0
+	}
+	retried := 0
+	for _, row := range rows {
+		claimed, err := store.claim(row.ID)
+		if err != nil {
+			logger.Log.Error("webhook claim retry failed", map[string]interface{}{"delivery_id": row.ID, "error": err.Error()})
+			continue
+		}
+		if !claimed {
+			continue
+		}
+		hook, err := store.hook(row.WebhookID)
+		if err != nil {
+			if !errors.Is(err, ErrNotFound) {
+				logger.Log.Error("webhook load for retry failed", map[string]interface{}{"webhook_id": row.WebhookID, "error": err.Error()})
+			}
+			continue
+		}
+		if !hook.Enabled {
+			continue
+		}
+		attempt(hook, row.EventKey, row.EventType, []byte(row.Payload), row.Attempt+1, opts)
+		retried++
+	}
+	return retried
+}
+
+func post(client *http.Client, hook *models.Webhook, eventKey, eventType string, body []byte, attempt int) *models.WebhookDelivery {
+	row := &models.WebhookDelivery{WebhookID: hook.ID, EventKey: eventKey, EventType: eventType, Attempt: attempt}
+	start := time.Now()
+	defer func() { row.DurationMs = time.Since(start).Milliseconds() }()
+
+	req, err := http.NewRequest("POST", hook.URL, bytes.NewReader(body))
+	if err != nil {
+		row.Error = err.Error()
+		return row
+	}
+	ts := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "CryptoSelect-Webhook/1.0")
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(ts, 10))
+	req.Header.Set(HeaderSignature, Sign(hook.Secret, ts, body))
+	req.Header.Set(HeaderEvent, eventType)
+	req.Header.Set(HeaderDelivery, eventKey)
+	resp, err := client.Do(req)
+	if err != nil {
+		row.Error = err.Error()
+		return row
+	}
+	// 只记录状态码，不读取、不保存响应内容，避免把目标服务的响应回显给用户
+	resp.Body.Close()
+	row.StatusCode = resp.StatusCode
+	row.Success = resp.StatusCode >= 200 && resp.StatusCode < 300
+	if !row.Success {
+		row.Error = fmt.Sprintf("status %d", resp.StatusCode)
+	}
+	return row
+}
@@ -0,0 +1,352 @@
+package webhook
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cryptoSelect/backendapi/models"
+	"github.com/cryptoSelect/backendapi/utils/logger"
+)
+
+// memoryStore 内存版投递日志与重试队列
+type memoryStore struct {
+	mu    sync.Mutex
+	rows  []*models.WebhookDelivery
+	hooks map[uint]*models.Webhook
+}
+
+func (s *memoryStore) handled(webhookID uint, eventKey string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, row := range s.rows {
+		if row.WebhookID == webhookID && row.EventKey == eventKey {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (s *memoryStore) create(row *models.WebhookDelivery) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	row.ID = uint(len(s.rows) + 1)
+	cp := *row
+	s.rows = append(s.rows, &cp)
+	return nil
+}
+
+func (s *memoryStore) due(now time.Time, limit int) ([]models.WebhookDelivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []models.WebhookDelivery
+	for _, row := range s.rows {
+		if row.NextAttemptAt != nil && !row.NextAttemptAt.After(now) && len(out) < limit {
+			out = append(out, *row)
+		}
+	}
+	return out, nil
+}
+
+func (s *memoryStore) claim(id uint) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, row := range s.rows {
+		if row.ID == id && row.NextAttemptAt != nil {
+			row.NextAttemptAt, row.Payload = nil, ""
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (s *memoryStore) hook(id uint) (*models.Webhook, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if h, ok := s.hooks[id]; ok {
+		return h, nil
+	}
+	return nil, ErrNotFound
+}
+
+func (s *memoryStore) all() []models.WebhookDelivery {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]models.WebhookDelivery, len(s.rows))
+	for i, row := range s.rows {
+		out[i] = *row
+	}
+	return out
+}
+
+// receiver 记录收到的请求，按 status 返回
+type receiver struct {
+	mu       sync.Mutex
+	status   int
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func (r *receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.requests = append(r.requests, req)
+	r.bodies = append(r.bodies, body)
+	w.WriteHeader(r.status)
+	w.Write([]byte("internal details"))
+}
+
+func (r *receiver) setStatus(status int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.status = status
+}
+
+func (r *receiver) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.requests)
+}
+
+// setup 启动本机接收端并替换存储；httptest 监听 127.0.0.1，需放开内网拦截
+func setup(t *testing.T, status int) (*models.Webhook, *receiver, *memoryStore) {
+	t.Helper()
+	logger.Init("test")
+	recv := &receiver{status: status}
+	srv := httptest.NewServer(recv)
+	t.Cleanup(srv.Close)
+
+	hook := &models.Webhook{ID: 7, UserID: 1, URL: srv.URL, Secret: "whsec_test", Enabled: true}
+	mem := &memoryStore{hooks: map[uint]*models.Webhook{hook.ID: hook}}
+	oldStore, oldAllow := store, allowPrivateNetworks
+	store, allowPrivateNetworks = mem, true
+	t.Cleanup(func() { store, allowPrivateNetworks = oldStore, oldAllow })
+	return hook, recv, mem
+}
+
+var testOptions = Options{MaxAttempts: 3, Backoff: time.Minute, Timeout: 5 * time.Second}
+
+func TestDeliverSignsRequest(t *testing.T) {
+	hook, recv, _ := setup(t, http.StatusOK)
+
+	row, err := Deliver(hook, "evt-1", "cross", map[string]string{"symbol": "BTCUSDT"}, testOptions)
+	if err != nil {
+		t.Fatalf("Deliver: %v", err)
+	}
+	if !row.Success || row.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected row %+v", row)
+	}
+	if recv.count() != 1 {
+		t.Fatalf("received %d requests, want 1", recv.count())
+	}
+	req, body := recv.requests[0], recv.bodies[0]
+	ts, err := strconv.ParseInt(req.Header.Get(HeaderTimestamp), 10, 64)
+	if err != nil {
+		t.Fatalf("bad timestamp header %q", req.Header.Get(HeaderTimestamp))
+	}
+	if got, want := req.Header.Get(HeaderSignature), Sign(hook.Secret, ts, body); got != want {
+		t.Fatalf("signature = %q, want %q", got, want)
+	}
+	if req.Header.Get(HeaderEvent) != "cross" || req.Header.Get(HeaderDelivery) != "evt-1" {
+		t.Fatalf("unexpected event headers %v", req.Header)
+	}
+	if !strings.Contains(string(body), `"symbol":"BTCUSDT"`) {
+		t.Fatalf("body does not carry data: %s", body)
+	}
+}
+
+func TestDeliverSkipsHandledEvent(t *testing.T) {
+	hook, recv, mem := setup(t, http.StatusOK)
+
+	for i := 0; i < 2; i++ {
+		if _, err := Deliver(hook, "evt-1", "cross", nil, testOptions); err != nil {
+			t.Fatalf("Deliver #%d: %v", i+1, err)
+		}
+	}
+	if recv.count() != 1 || len(mem.all()) != 1 {
+		t.Fatalf("sent %d requests and logged %d rows, want 1 each", recv.count(), len(mem.all()))
+	}
+}
+
+func TestDeliverQueuesRetriesWithBackoff(t *testing.T) {
+	hook, recv, mem := setup(t, http.StatusInternalServerError)
+
+	start := time.Now()
+	row, err := Deliver(hook, "evt-1", "cross", nil, testOptions)
+	if !errors.Is(err, ErrRetryScheduled) {
+		t.Fatalf("err = %v, want ErrRetryScheduled", err)
+	}
+	if row.NextAttemptAt == nil || row.NextAttemptAt.Sub(start) < testOptions.Backoff {
+		t.Fatalf("next attempt %v, want about %v after %v", row.NextAttemptAt, testOptions.Backoff, start)
+	}
+	// 已排队的事件不会被告警扫描重复投递
+	if _, err := Deliver(hook, "evt-1", "cross", nil, testOptions); err != nil || recv.count() != 1 {
+		t.Fatalf("queued event delivered again: err=%v requests=%d", err, recv.count())
+	}
+	// 未到重试时间
+	if n := retryDue(start, testOptions); n != 0 {
+		t.Fatalf("retried %d deliveries before they were due", n)
+	}
+
+	// 第 2 次尝试失败，等待时间翻倍
+	before := time.Now()
+	if n := retryDue(*row.NextAttemptAt, testOptions); n != 1 {
+		t.Fatalf("retried %d deliveries, want 1", n)
+	}
+	rows := mem.all()
+	last := rows[len(rows)-1]
+	if last.Attempt != 2 || last.NextAttemptAt == nil || last.NextAttemptAt.Sub(before) < 2*testOptions.Backoff {
+		t.Fatalf("unexpected second attempt %+v", last)
+	}
+	if rows[0].NextAttemptAt != nil || rows[0].Payload != "" {
+		t.Fatal("claimed delivery is still queued")
+	}
+
+	// 第 3 次为最后一次，失败后不再排队
+	if n := retryDue(*last.NextAttemptAt, testOptions); n != 1 {
+		t.Fatalf("retried %d deliveries, want 1", n)
+	}
+	rows = mem.all()
+	last = rows[len(rows)-1]
+	if last.Attempt != 3 || last.NextAttemptAt != nil {
+		t.Fatalf("final attempt must not be rescheduled: %+v", last)
+	}
+	if n := retryDue(time.Now().Add(time.Hour), testOptions); n != 0 || recv.count() != 3 {
+		t.Fatalf("retried %d more, received %d, want 0 and 3", n, recv.count())
+	}
+}
+
+// 重试用尽后再次检测到同一事件不会开始新一轮尝试
+func TestDeliverSkipsExhaustedEvent(t *testing.T) {
+	hook, recv, _ := setup(t, http.StatusInternalServerError)
+
+	opts := testOptions
+	opts.MaxAttempts = 1
+	if _, err := Deliver(hook, "evt-1", "cross", nil, opts); err == nil || errors.Is(err, ErrRetryScheduled) {
+		t.Fatalf("err = %v, want a final failure", err)
+	}
+	recv.setStatus(http.StatusOK)
+	if row, err := Deliver(hook, "evt-1", "cross", nil, opts); row != nil || err != nil || recv.count() != 1 {
+		t.Fatalf("exhausted event delivered again: row=%+v err=%v requests=%d", row, err, recv.count())
+	}
+	if _, err := Deliver(hook, "evt-2", "cross", nil, opts); err != nil || recv.count() != 2 {
+		t.Fatalf("new event not delivered: err=%v requests=%d", err, recv.count())
+	}
+}
+
+func TestRetryStopsAfterSuccess(t *testing.T) {
+	hook, recv, mem := setup(t, http.StatusBadGateway)
+
+	if _, err := Deliver(hook, "evt-1", "cross", nil, testOptions); !errors.Is(err, ErrRetryScheduled) {
+		t.Fatalf("err = %v, want ErrRetryScheduled", err)
+	}
+	recv.setStatus(http.StatusNoContent)
+	if n := retryDue(time.Now().Add(time.Hour), testOptions); n != 1 {
+		t.Fatalf("retried %d deliveries, want 1", n)
+	}
+	rows := mem.all()
+	if last := rows[len(rows)-1]; !last.Success || last.Attempt != 2 || last.NextAttemptAt != nil {
+		t.Fatalf("unexpected retry row %+v", last)
+	}
+	if n := retryDue(time.Now().Add(24*time.Hour), testOptions); n != 0 {
+		t.Fatalf("retried %d deliveries after success", n)
+	}
+}
+
+func TestRetrySkipsDisabledWebhook(t *testing.T) {
+	hook, recv, _ := setup(t, http.StatusInternalServerError)
+
+	if _, err := Deliver(hook, "evt-1", "cross", nil, testOptions); !errors.Is(err, ErrRetryScheduled) {
+		t.Fatalf("err = %v, want ErrRetryScheduled", err)
+	}
+	hook.Enabled = false
+	if n := retryDue(time.Now().Add(time.Hour), testOptions); n != 0 || recv.count() != 1 {
+		t.Fatalf("retried %d deliveries to a disabled webhook", n)
+	}
+}
+
+func TestDeliveryLogStoresStatusOnly(t *testing.T) {
+	hook, _, mem := setup(t, http.StatusForbidden)
+
+	opts := testOptions
+	opts.MaxAttempts = 1
+	row, err := Deliver(hook, "evt-1", "cross", nil, opts)
+	if err == nil || errors.Is(err, ErrRetryScheduled) {
+		t.Fatalf("err = %v, want final failure", err)
+	}
+	if row.StatusCode != http.StatusForbidden || row.Error != "status 403" || row.Payload != "" {
+		t.Fatalf("unexpected row %+v", row)
+	}
+	for _, r := range mem.all() {
+		if strings.Contains(r.Error, "internal details") {
+			t.Fatalf("response body leaked into delivery log: %q", r.Error)
+		}
+	}
+}
+
+func TestDeliverDoesNotFollowRedirects(t *testing.T) {
+	hook, recv, _ := setup(t, http.StatusOK)
+	redirect := httptest.NewServer(http.RedirectHandler(hook.URL, http.StatusFound))
+	defer redirect.Close()
+	hook.URL = redirect.URL
+
+	opts := testOptions
+	opts.MaxAttempts = 1
+	row, err := Deliver(hook, "evt-1", "cross", nil, opts)
+	if err == nil || row.StatusCode != http.StatusFound {
+		t.Fatalf("redirect should fail the delivery: err=%v row=%+v", err, row)
+	}
+	if recv.count() != 0 {
+		t.Fatal("redirect was followed")
+	}
+}
+
+func TestDeliverBlocksPrivateAddressAtDial(t *testing.T) {
+	hook, recv, _ := setup(t, http.StatusOK)
+	allowPrivateNetworks = false
+
+	opts := testOptions
+	opts.MaxAttempts = 1
+	row, err := Deliver(hook, "evt-1", "cross", nil, opts)
+	if err == nil || !strings.Contains(row.Error, ErrPrivateAddress.Error()) {
+		t.Fatalf("dial to loopback should be blocked: err=%v row=%+v", err, row)
+	}
+	if recv.count() != 0 {
+		t.Fatal("request reached loopback receiver")
+	}
+}
+
+func TestValidateURL(t *testing.T) {
+	tests := []struct {
+		url  string
+		want error
+	}{
+		{"https://8.8.8.8/hook", nil},
+		{"http://1.1.1.1:8080/hook", nil},
+		{"ftp://8.8.8.8/hook", ErrInvalidURL},
+		{"https:///hook", ErrInvalidURL},
+		{"not a url", ErrInvalidURL},
+		{"http://127.0.0.1/hook", ErrPrivateAddress},
+		{"http://localhost:8080/hook", ErrPrivateAddress},
+		{"http://10.0.0.1/hook", ErrPrivateAddress},
+		{"http://192.168.1.10/hook", ErrPrivateAddress},
+		{"http://169.254.169.254/latest/meta-data", ErrPrivateAddress},
+		{"http://100.100.100.200/hook", ErrPrivateAddress},
+		{"http://0.0.0.0/hook", ErrPrivateAddress},
+		{"http://[::1]/hook", ErrPrivateAddress},
+		{"http://[fe80::1]/hook", ErrPrivateAddress},
+		{"http://[::ffff:127.0.0.1]/hook", ErrPrivateAddress},
+	}
+	for _, tt := range tests {
+		if got := ValidateURL(tt.url); !errors.Is(got, tt.want) {
+			t.Errorf("ValidateURL(%q) = %v, want %v", tt.url, got, tt.want)
+		}
+	}
+}